@@ -0,0 +1,127 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+)
+
+/*
+Permissions which can be granted in an ACL. File systems may support
+additional, implementation specific permissions.
+*/
+const (
+	PermissionRead        = "read"
+	PermissionWrite       = "write"
+	PermissionFullControl = "full-control"
+)
+
+/*
+Grant gives a single grantee a permission on a file.
+*/
+type Grant struct {
+	// User, group or other principal receiving the permission, in the
+	// notation of the file system.
+	Grantee string `json:"grantee"`
+
+	// Permission granted, e.g. PermissionRead.
+	Permission string `json:"permission"`
+}
+
+/*
+ACL describes the access control list of a file, as supported by most
+object stores and enterprise file systems.
+*/
+type ACL struct {
+	Owner  string  `json:"owner"`
+	Grants []Grant `json:"grants,omitempty"`
+}
+
+/*
+ToJSON serializes the ACL to JSON.
+*/
+func (a *ACL) ToJSON() ([]byte, error) {
+	return json.Marshal(a)
+}
+
+/*
+FromJSON replaces the contents of the ACL with the ones serialized in data.
+*/
+func (a *ACL) FromJSON(data []byte) error {
+	var acl ACL
+
+	if err := json.Unmarshal(data, &acl); err != nil {
+		return err
+	}
+
+	*a = acl
+	return nil
+}
+
+/*
+ACLFileSystem is implemented by file systems which support per-file access
+control lists.
+*/
+type ACLFileSystem interface {
+	// Retrieve the access control list of the file.
+	GetACL(context.Context, *url.URL) (ACL, error)
+
+	// Replace the access control list of the file.
+	SetACL(context.Context, *url.URL, ACL) error
+}
+
+/*
+getACLFileSystem determines the ACLFileSystem responsible for the URL, or
+returns ENOFS or EUNSUPP if there is none.
+*/
+func getACLFileSystem(fileurl *url.URL) (ACLFileSystem, error) {
+	var fs = GetImplementation(fileurl)
+	var afs ACLFileSystem
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if afs, ok = fs.(ACLFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	return afs, nil
+}
+
+/*
+GetACL retrieves the access control list of the referenced file.
+*/
+func GetACL(ctx context.Context, fileurl *url.URL) (ACL, error) {
+	var afs ACLFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if afs, err = getACLFileSystem(fileurl); err != nil {
+		return ACL{}, err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return afs.GetACL(ctx, fileurl)
+}
+
+/*
+SetACL replaces the access control list of the referenced file.
+*/
+func SetACL(ctx context.Context, fileurl *url.URL, acl ACL) error {
+	var afs ACLFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if afs, err = getACLFileSystem(fileurl); err != nil {
+		return err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return afs.SetACL(ctx, fileurl, acl)
+}
@@ -0,0 +1,118 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+/*
+aclFileSystem keeps track of the access control lists of existing files.
+Files without an ACL of their own are owned by "root".
+*/
+type aclFileSystem struct {
+	*virtualfs.VirtualFileSystem
+	lock sync.Mutex
+	acls map[string]filesystem.ACL
+}
+
+func (a *aclFileSystem) GetACL(ctx context.Context, u *url.URL) (
+	filesystem.ACL, error) {
+	if _, err := a.Stat(ctx, u); err != nil {
+		return filesystem.ACL{}, err
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if acl, ok := a.acls[u.Path]; ok {
+		return acl, nil
+	}
+	return filesystem.ACL{Owner: "root"}, nil
+}
+
+func (a *aclFileSystem) SetACL(ctx context.Context, u *url.URL,
+	acl filesystem.ACL) error {
+	if _, err := a.Stat(ctx, u); err != nil {
+		return err
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.acls[u.Path] = acl
+	return nil
+}
+
+func TestACLJSON(t *testing.T) {
+	acl := filesystem.ACL{
+		Owner: "alice",
+		Grants: []filesystem.Grant{
+			{Grantee: "bob", Permission: filesystem.PermissionRead},
+			{Grantee: "admins", Permission: filesystem.PermissionFullControl},
+		},
+	}
+	var decoded = filesystem.ACL{Owner: "previous",
+		Grants: []filesystem.Grant{{Grantee: "x"}}}
+
+	data, err := acl.ToJSON()
+	if err != nil {
+		t.Fatalf("Error reported from ToJSON: %v", err)
+	}
+	if err = decoded.FromJSON(data); err != nil {
+		t.Fatalf("Error reported from FromJSON: %v", err)
+	}
+	if decoded.Owner != "alice" || len(decoded.Grants) != 2 ||
+		decoded.Grants[0] != acl.Grants[0] || decoded.Grants[1] != acl.Grants[1] {
+		t.Errorf("Unexpected decoded ACL %+v", decoded)
+	}
+
+	if err = decoded.FromJSON([]byte(`{"owner":`)); err == nil {
+		t.Error("Expected error decoding truncated JSON")
+	}
+	if decoded.Owner != "alice" {
+		t.Errorf("ACL modified by failed FromJSON: %+v", decoded)
+	}
+}
+
+func TestACL(t *testing.T) {
+	filesystem.AddImplementation("aclplain", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("acl", &aclFileSystem{
+		VirtualFileSystem: virtualfs.NewVirtualFileSystem(),
+		acls:              make(map[string]filesystem.ACL),
+	})
+	u := &url.URL{Scheme: "acl", Path: "/file"}
+	acl := filesystem.ACL{Owner: "alice", Grants: []filesystem.Grant{
+		{Grantee: "bob", Permission: filesystem.PermissionRead}}}
+	ctx := context.Background()
+
+	if _, err := filesystem.GetACL(ctx, &url.URL{Scheme: "aclunregistered",
+		Path: "/file"}); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+	if err := filesystem.SetACL(ctx, &url.URL{Scheme: "aclplain",
+		Path: "/file"}, acl); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP without support, got %v", err)
+	}
+
+	if err := filesystem.SetACL(ctx, u, acl); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error for missing file: %v", err)
+	}
+
+	writeTestFile(t, u, "protected")
+	if got, err := filesystem.GetACL(ctx, u); err != nil || got.Owner != "root" {
+		t.Errorf("Unexpected initial ACL %+v (%v)", got, err)
+	}
+	if err := filesystem.SetACL(ctx, u, acl); err != nil {
+		t.Errorf("Error reported from SetACL: %v", err)
+	}
+	got, err := filesystem.GetACL(ctx, u)
+	if err != nil {
+		t.Fatalf("Error reported from GetACL: %v", err)
+	}
+	if got.Owner != "alice" || len(got.Grants) != 1 || got.Grants[0] != acl.Grants[0] {
+		t.Errorf("Unexpected ACL %+v", got)
+	}
+}
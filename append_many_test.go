@@ -0,0 +1,41 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestAppendMany(t *testing.T) {
+	filesystem.AddImplementation("appendmany", virtualfs.NewVirtualFileSystem())
+	u := &url.URL{Scheme: "appendmany", Path: "/log"}
+
+	if err := filesystem.AppendMany(context.Background(), u,
+		[][]byte{[]byte("a"), []byte("bb")}, []byte("\n")); err != nil {
+		t.Fatalf("Error reported from AppendMany: %v", err)
+	}
+	if err := filesystem.AppendMany(context.Background(), u,
+		[][]byte{[]byte("c")}, []byte("\n")); err != nil {
+		t.Fatalf("Error reported from AppendMany: %v", err)
+	}
+	if data, _ := readTestFile(t, u); data != "a\nbb\nc\n" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+
+	if err := filesystem.AppendMany(context.Background(), u,
+		[][]byte{[]byte("d"), []byte("e")}, nil); err != nil {
+		t.Fatalf("Error reported from AppendMany: %v", err)
+	}
+	if data, _ := readTestFile(t, u); data != "a\nbb\nc\nde" {
+		t.Errorf("Unexpected contents without separator %q", data)
+	}
+
+	if err := filesystem.AppendMany(context.Background(),
+		&url.URL{Scheme: "appendmanyunregistered", Path: "/log"},
+		[][]byte{[]byte("a")}, nil); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+/*
+ErrForbidden is returned by the file system created by
+NewAuthorizedFileSystem if the policy denies an operation. It matches
+os.ErrPermission when compared using errors.Is.
+*/
+var ErrForbidden = fmt.Errorf("Operation forbidden by policy: %w", os.ErrPermission)
+
+/*
+AuthPolicy decides whether an operation may be executed. The operation is
+named after the FileSystem method being invoked, e.g. "OpenReader" or
+"Remove". A non-nil return value denies the operation.
+*/
+type AuthPolicy func(ctx context.Context, op string, fileurl *url.URL) error
+
+/*
+FileSystem wrapper which checks all operations against a policy.
+*/
+type authorizedFileSystem struct {
+	inner  FileSystem
+	policy AuthPolicy
+}
+
+/*
+NewAuthorizedFileSystem wraps inner into a FileSystem which calls policy
+before every operation and fails the operation with ErrForbidden if the
+policy denies it. This allows separating authorization from the file system
+implementation, e.g. in multi-tenant applications.
+
+Stat is authorized as the "Stat" operation. Optional interfaces of inner are
+hidden so they cannot be used to bypass the policy.
+*/
+func NewAuthorizedFileSystem(inner FileSystem, policy AuthPolicy) FileSystem {
+	return &authorizedFileSystem{inner: inner, policy: policy}
+}
+
+/*
+authorize checks the operation against the policy.
+*/
+func (a *authorizedFileSystem) authorize(ctx context.Context, op string,
+	fileurl *url.URL) error {
+	var err = a.policy(ctx, op, fileurl)
+
+	if err == nil || errors.Is(err, ErrForbidden) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrForbidden, err)
+}
+
+/*
+OpenReader opens the file for reading if the policy permits it.
+*/
+func (a *authorizedFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	if err := a.authorize(ctx, "OpenReader", u); err != nil {
+		return nil, err
+	}
+	return a.inner.OpenReader(ctx, u)
+}
+
+/*
+OpenWriter opens the file for writing if the policy permits it.
+*/
+func (a *authorizedFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	if err := a.authorize(ctx, "OpenWriter", u); err != nil {
+		return nil, err
+	}
+	return a.inner.OpenWriter(ctx, u)
+}
+
+/*
+OpenAppender opens the file for appending if the policy permits it.
+*/
+func (a *authorizedFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	if err := a.authorize(ctx, "OpenAppender", u); err != nil {
+		return nil, err
+	}
+	return a.inner.OpenAppender(ctx, u)
+}
+
+/*
+ListEntries lists the directory if the policy permits it.
+*/
+func (a *authorizedFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	if err := a.authorize(ctx, "ListEntries", u); err != nil {
+		return nil, err
+	}
+	return a.inner.ListEntries(ctx, u)
+}
+
+/*
+WatchFile watches the file if the policy permits it.
+*/
+func (a *authorizedFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	if err := a.authorize(ctx, "WatchFile", u); err != nil {
+		return nil, nil, err
+	}
+	return a.inner.WatchFile(ctx, u, watcher)
+}
+
+/*
+Remove deletes the file if the policy permits it.
+*/
+func (a *authorizedFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	if err := a.authorize(ctx, "Remove", u); err != nil {
+		return err
+	}
+	return a.inner.Remove(ctx, u)
+}
+
+/*
+Stat retrieves the metadata of the file if the policy permits it.
+*/
+func (a *authorizedFileSystem) Stat(ctx context.Context, u *url.URL) (
+	FileInfo, error) {
+	if err := a.authorize(ctx, "Stat", u); err != nil {
+		return nil, err
+	}
+	return a.inner.Stat(ctx, u)
+}
@@ -0,0 +1,39 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestAuthorizedFileSystemDeniesOperation(t *testing.T) {
+	var ops []string
+
+	fs := NewAuthorizedFileSystem(&MockFileSystem{},
+		func(ctx context.Context, op string, u *url.URL) error {
+			ops = append(ops, op)
+			if op == "Remove" {
+				return errors.New("Read only")
+			}
+			return nil
+		})
+
+	if _, err := fs.OpenReader(context.Background(),
+		mustParse(t, "mock:///file")); err != nil {
+		t.Errorf("Error reported from OpenReader: %v", err)
+	}
+
+	err := fs.Remove(context.Background(), mustParse(t, "mock:///file"))
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("Unexpected error from Remove: %v", err)
+	}
+	if !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Error from Remove does not match os.ErrPermission: %v", err)
+	}
+
+	if len(ops) != 2 || ops[0] != "OpenReader" || ops[1] != "Remove" {
+		t.Errorf("Unexpected operations passed to policy: %v", ops)
+	}
+}
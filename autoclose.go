@@ -0,0 +1,97 @@
+package filesystem
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+autocloser closes a ContextCloser exactly once, either explicitly or when a
+context is done, whichever comes first.
+*/
+type autocloser struct {
+	closer ContextCloser
+	stop   func() bool
+	once   sync.Once
+	err    error
+}
+
+/*
+newAutocloser arranges for c to be closed once ctx is done.
+*/
+func newAutocloser(ctx context.Context, c ContextCloser) *autocloser {
+	var a = &autocloser{closer: c}
+
+	a.stop = context.AfterFunc(ctx, func() {
+		a.closeOnce(context.Background())
+	})
+	return a
+}
+
+/*
+closeOnce closes the underlying closer unless that happened already.
+*/
+func (a *autocloser) closeOnce(ctx context.Context) error {
+	a.once.Do(func() {
+		a.err = a.closer.Close(ctx)
+	})
+	return a.err
+}
+
+/*
+Close stops waiting for the context and closes the underlying closer, unless
+it has been closed automatically already. In that case, the result of the
+automatic close is returned.
+*/
+func (a *autocloser) Close(ctx context.Context) error {
+	a.stop()
+	return a.closeOnce(ctx)
+}
+
+/*
+ReadCloser which is closed automatically when a context is done.
+*/
+type autocloseReadCloser struct {
+	ReadCloser
+	*autocloser
+}
+
+/*
+Close closes the underlying ReadCloser. See autocloser.Close.
+*/
+func (a *autocloseReadCloser) Close(ctx context.Context) error {
+	return a.autocloser.Close(ctx)
+}
+
+/*
+NewAutocloseReadCloser wraps r into a ReadCloser which is closed using
+context.Background() as soon as ctx is done, unless Close has been called
+before. This is a safety net for handles whose lifetime is bound to e.g. a
+request context. r is closed at most once.
+*/
+func NewAutocloseReadCloser(ctx context.Context, r ReadCloser) ReadCloser {
+	return &autocloseReadCloser{ReadCloser: r, autocloser: newAutocloser(ctx, r)}
+}
+
+/*
+WriteCloser which is closed automatically when a context is done.
+*/
+type autocloseWriteCloser struct {
+	WriteCloser
+	*autocloser
+}
+
+/*
+Close closes the underlying WriteCloser. See autocloser.Close.
+*/
+func (a *autocloseWriteCloser) Close(ctx context.Context) error {
+	return a.autocloser.Close(ctx)
+}
+
+/*
+NewAutocloseWriteCloser wraps w into a WriteCloser which is closed as soon
+as ctx is done, like NewAutocloseReadCloser.
+*/
+func NewAutocloseWriteCloser(ctx context.Context, w WriteCloser) WriteCloser {
+	return &autocloseWriteCloser{WriteCloser: w, autocloser: newAutocloser(ctx, w)}
+}
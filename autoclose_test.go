@@ -0,0 +1,51 @@
+package filesystem
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type CountingCloser struct {
+	MockReadCloser
+	Closes atomic.Int32
+}
+
+func (c *CountingCloser) Close(ctx context.Context) error {
+	c.Closes.Add(1)
+	return nil
+}
+
+func TestAutocloseOnContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &CountingCloser{}
+	rc := NewAutocloseReadCloser(ctx, c)
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for c.Closes.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := rc.Close(context.Background()); err != nil {
+		t.Errorf("Error reported from Close: %v", err)
+	}
+	if n := c.Closes.Load(); n != 1 {
+		t.Errorf("Reader closed %d times, expected once", n)
+	}
+}
+
+func TestAutocloseExplicitClose(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &CountingCloser{}
+	rc := NewAutocloseReadCloser(ctx, c)
+
+	rc.Close(context.Background())
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+
+	if n := c.Closes.Load(); n != 1 {
+		t.Errorf("Reader closed %d times, expected once", n)
+	}
+}
@@ -0,0 +1,189 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+/*
+BackupManifestEntry describes a single file contained in a backup.
+*/
+type BackupManifestEntry struct {
+	// Path of the file relative to the backed up directory.
+	Path string `json:"path"`
+
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+
+	// Hex encoded checksum of the backed up copy, computed with the
+	// algorithm named in the manifest.
+	Checksum string `json:"checksum"`
+}
+
+/*
+BackupManifest lists all files contained in a backup written by
+IncrementalBackup.
+*/
+type BackupManifest struct {
+	// Time at which the backup was started.
+	Created time.Time `json:"created"`
+
+	// Checksum algorithm used for all entries, e.g. ChecksumSHA256.
+	Algorithm string `json:"algorithm"`
+
+	Files []BackupManifestEntry `json:"files"`
+}
+
+/*
+readBackupManifest reads and parses the manifest at the URL.
+*/
+func readBackupManifest(ctx context.Context, manifesturl *url.URL) (
+	*BackupManifest, error) {
+	var manifest BackupManifest
+	var data []byte
+	var err error
+
+	if data, err = readFile(ctx, manifesturl); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("Cannot parse backup manifest %s: %w", manifesturl, err)
+	}
+
+	return &manifest, nil
+}
+
+/*
+IncrementalBackup copies all files beneath src whose size or modification
+time differs from their entry in the backup described by lastBackupManifest
+to the same relative paths beneath dst. If lastBackupManifest is nil, all
+files are copied.
+
+Afterwards, a manifest listing all files currently beneath src along with
+the checksums of their backed up copies is written to dst, and its URL is
+returned for use as lastBackupManifest of the next backup. Checksums of
+unchanged files are taken over from the previous manifest. Files removed
+from src are left in dst, but are not listed in the new manifest. The
+manifest is written to a temporary file first and moved into place. As
+backing up an entire tree can take very long, the default timeout is not
+applied.
+*/
+func IncrementalBackup(ctx context.Context, src, dst *url.URL,
+	lastBackupManifest *url.URL) (*url.URL, error) {
+	var manifest = BackupManifest{
+		Created:   time.Now().UTC(),
+		Algorithm: DefaultChecksumAlgorithm,
+	}
+	var previous = make(map[string]BackupManifestEntry)
+	var dstfs = GetImplementation(dst)
+	var manifesturl *url.URL
+	var paths []string
+	var data []byte
+	var err error
+
+	if dstfs == nil {
+		return nil, ENOFS
+	}
+
+	if lastBackupManifest != nil {
+		var last *BackupManifest
+
+		if last, err = readBackupManifest(ctx, lastBackupManifest); err != nil {
+			return nil, err
+		}
+		if last.Algorithm == manifest.Algorithm {
+			for _, entry := range last.Files {
+				previous[entry.Path] = entry
+			}
+		}
+	}
+
+	if paths, err = ListEntriesRecursive(ctx, src); err != nil {
+		return nil, err
+	}
+
+	for _, rel := range paths {
+		var srcurl, dsturl = childURL(src, rel), childURL(dst, rel)
+		var entry, ok = previous[rel]
+		var fi FileInfo
+		var sum []byte
+
+		if fi, err = Stat(ctx, srcurl); err != nil {
+			return nil, err
+		}
+
+		if !ok || fi.Size() != entry.Size || !fi.ModTime().Equal(entry.ModTime) {
+			if _, err = CopyFile(ctx, srcurl, dsturl); err != nil {
+				return nil, err
+			}
+			if sum, err = ChecksumFile(ctx, dsturl, manifest.Algorithm); err != nil {
+				return nil, err
+			}
+			entry = BackupManifestEntry{
+				Path:     rel,
+				Size:     fi.Size(),
+				ModTime:  fi.ModTime(),
+				Checksum: hex.EncodeToString(sum),
+			}
+		}
+
+		manifest.Files = append(manifest.Files, entry)
+	}
+
+	if data, err = json.Marshal(&manifest); err != nil {
+		return nil, err
+	}
+
+	manifesturl = childURL(dst,
+		"manifest-"+manifest.Created.Format("20060102T150405.000000000Z")+".json")
+	if err = writeFileReplacing(ctx, dstfs, manifesturl, data); err != nil {
+		return nil, err
+	}
+
+	return manifesturl, nil
+}
+
+/*
+RestoreFromManifest copies all files listed in the manifest from src, which
+is usually the destination of IncrementalBackup, to the same relative paths
+beneath dst. The checksum of every restored file is verified against the
+manifest; ErrChecksumMismatch is returned for the first file which differs.
+As restoring an entire backup can take very long, the default timeout is not
+applied.
+*/
+func RestoreFromManifest(ctx context.Context, manifesturl, src, dst *url.URL) error {
+	var manifest *BackupManifest
+	var err error
+
+	if manifest, err = readBackupManifest(ctx, manifesturl); err != nil {
+		return err
+	}
+
+	for _, entry := range manifest.Files {
+		var dsturl = childURL(dst, entry.Path)
+		var expected, sum []byte
+
+		if expected, err = hex.DecodeString(entry.Checksum); err != nil {
+			return fmt.Errorf("Invalid checksum for %s in backup manifest: %w",
+				entry.Path, err)
+		}
+
+		if _, err = CopyFile(ctx, childURL(src, entry.Path), dsturl); err != nil {
+			return err
+		}
+		if sum, err = ChecksumFile(ctx, dsturl, manifest.Algorithm); err != nil {
+			return err
+		}
+		if !bytes.Equal(sum, expected) {
+			return fmt.Errorf("%w: %s has %x, manifest lists %x",
+				ErrChecksumMismatch, dsturl, sum, expected)
+		}
+	}
+
+	return nil
+}
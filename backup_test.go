@@ -0,0 +1,62 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestIncrementalBackup(t *testing.T) {
+	filesystem.AddImplementation("backup", virtualfs.NewVirtualFileSystem())
+	ctx := context.Background()
+	src := &url.URL{Scheme: "backup", Path: "/live"}
+	dst := &url.URL{Scheme: "backup", Path: "/backup"}
+	restored := &url.URL{Scheme: "backup", Path: "/restored"}
+
+	writeTestFile(t, &url.URL{Scheme: "backup", Path: "/live/a"}, "first")
+	writeTestFile(t, &url.URL{Scheme: "backup", Path: "/live/sub/b"}, "second")
+
+	first, err := filesystem.IncrementalBackup(ctx, src, dst, nil)
+	if err != nil {
+		t.Fatalf("Error reported from IncrementalBackup: %v", err)
+	}
+
+	// Files restored from elsewhere may carry modification times older
+	// than the previous backup; they must be backed up nonetheless.
+	writeTestFile(t, &url.URL{Scheme: "backup", Path: "/live/a"}, "third")
+	if err = filesystem.TouchWithTime(ctx, &url.URL{Scheme: "backup", Path: "/live/a"},
+		time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)); err != nil {
+		t.Fatalf("Error reported from TouchWithTime: %v", err)
+	}
+	// Modify the backup of the unchanged file to show that it is not copied.
+	writeTestFile(t, &url.URL{Scheme: "backup", Path: "/backup/sub/b"}, "stale")
+
+	second, err := filesystem.IncrementalBackup(ctx, src, dst, first)
+	if err != nil {
+		t.Fatalf("Error reported from IncrementalBackup: %v", err)
+	}
+	if data, _ := readTestFile(t, &url.URL{Scheme: "backup", Path: "/backup/a"}); data != "third" {
+		t.Errorf("Changed file was not backed up, got %q", data)
+	}
+	if data, _ := readTestFile(t, &url.URL{Scheme: "backup", Path: "/backup/sub/b"}); data != "stale" {
+		t.Errorf("Unchanged file was copied again, got %q", data)
+	}
+
+	err = filesystem.RestoreFromManifest(ctx, second, dst, restored)
+	if !errors.Is(err, filesystem.ErrChecksumMismatch) {
+		t.Errorf("Expected checksum mismatch for tampered backup, got %v", err)
+	}
+
+	writeTestFile(t, &url.URL{Scheme: "backup", Path: "/backup/sub/b"}, "second")
+	if err = filesystem.RestoreFromManifest(ctx, second, dst, restored); err != nil {
+		t.Fatalf("Error reported from RestoreFromManifest: %v", err)
+	}
+	if data, _ := readTestFile(t, &url.URL{Scheme: "backup", Path: "/restored/a"}); data != "third" {
+		t.Errorf("Unexpected restored contents %q", data)
+	}
+}
@@ -0,0 +1,78 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"sync"
+)
+
+/*
+Maximum number of concurrent Stat calls issued by BulkStat for file systems
+without native batch support.
+*/
+const bulkStatConcurrency = 16
+
+/*
+BulkStatFileSystem is implemented by file systems which can retrieve the
+metadata of many files in a single request.
+*/
+type BulkStatFileSystem interface {
+	// Retrieve metadata for all URLs. Returns one FileInfo and one error
+	// per URL, and an error if no metadata could be retrieved at all.
+	BulkStat(context.Context, []*url.URL) ([]FileInfo, []error, error)
+}
+
+/*
+BulkStat retrieves metadata for all referenced files. The returned slices
+contain one entry for each URL: either the FileInfo, or the error which
+occurred retrieving it. The third return value reports problems which
+prevented any metadata from being retrieved.
+
+If all URLs are handled by the same file system and it implements
+BulkStatFileSystem, a single batched request is made. Otherwise Stat is
+called for every URL with bounded concurrency.
+*/
+func BulkStat(ctx context.Context, urls []*url.URL) ([]FileInfo, []error, error) {
+	var infos = make([]FileInfo, len(urls))
+	var errs = make([]error, len(urls))
+	var sem = make(chan struct{}, bulkStatConcurrency)
+	var wg sync.WaitGroup
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(urls) > 0 {
+		var fs = GetImplementation(urls[0])
+		var same = true
+
+		for _, u := range urls {
+			same = same && u.Scheme == urls[0].Scheme
+		}
+
+		if bfs, ok := fs.(BulkStatFileSystem); ok && same {
+			var cancel context.CancelFunc
+
+			ctx, cancel = withDefaultTimeout(ctx)
+			defer cancel()
+
+			return bfs.BulkStat(ctx, urls)
+		}
+	}
+
+	for i, u := range urls {
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(i int, u *url.URL) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			infos[i], errs[i] = Stat(ctx, u)
+		}(i, u)
+	}
+
+	wg.Wait()
+
+	return infos, errs, nil
+}
@@ -0,0 +1,45 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestBulkStat(t *testing.T) {
+	filesystem.AddImplementation("bulkstat", virtualfs.NewVirtualFileSystem())
+	urls := []*url.URL{
+		{Scheme: "bulkstat", Path: "/a"},
+		{Scheme: "bulkstat", Path: "/missing"},
+		{Scheme: "bulkstatunregistered", Path: "/b"},
+	}
+	writeTestFile(t, urls[0], "hello")
+
+	infos, errs, err := filesystem.BulkStat(context.Background(), urls)
+	if err != nil {
+		t.Fatalf("Error reported from BulkStat: %v", err)
+	}
+	if len(infos) != 3 || len(errs) != 3 {
+		t.Fatalf("Unexpected result lengths %d and %d", len(infos), len(errs))
+	}
+	if errs[0] != nil || infos[0].Size() != 5 {
+		t.Errorf("Unexpected result for existing file: %v", errs[0])
+	}
+	if !errors.Is(errs[1], fs.ErrNotExist) || infos[1] != nil {
+		t.Errorf("Unexpected error for missing file: %v", errs[1])
+	}
+	if errs[2] != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", errs[2])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err = filesystem.BulkStat(ctx, urls); err != context.Canceled {
+		t.Errorf("Unexpected error for cancelled context: %v", err)
+	}
+}
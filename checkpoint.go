@@ -0,0 +1,128 @@
+package filesystem
+
+import (
+	"context"
+	iofs "io/fs"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+/*
+CheckpointingFileSystem is implemented by file systems which can store the
+read positions of consumers of their files themselves, e.g. in a database
+or key-value store.
+*/
+type CheckpointingFileSystem interface {
+	// Store the offset up to which the named consumer has read the file.
+	SetCheckpoint(context.Context, string, *url.URL, int64) error
+
+	// Retrieve the offset stored for the named consumer and the file.
+	// Must fail with an error matching fs.ErrNotExist if there is none.
+	GetCheckpoint(context.Context, string, *url.URL) (int64, error)
+
+	// Remove the offset stored for the named consumer and the file.
+	DeleteCheckpoint(context.Context, string, *url.URL) error
+}
+
+/*
+checkpointURL determines the location of the sidecar file holding the
+checkpoint of the consumer for file systems not implementing
+CheckpointingFileSystem.
+*/
+func checkpointURL(consumerID string, fileurl *url.URL) *url.URL {
+	var u = *fileurl
+
+	u.Path = path.Join(path.Dir(fileurl.Path), "."+path.Base(fileurl.Path)+
+		".checkpoint."+url.PathEscape(consumerID))
+	return &u
+}
+
+/*
+SetCheckpoint persistently stores the offset up to which the consumer has
+read the referenced file, typically an append-only log, so that it can
+resume from there after a restart. This is the equivalent of the offsets
+of Kafka consumer groups.
+
+File systems not implementing CheckpointingFileSystem store the checkpoint
+in a hidden sidecar file next to the referenced file. It is written to a
+temporary file first and moved into place, so a crash never leaves a
+truncated checkpoint behind on file systems with atomic moves.
+*/
+func SetCheckpoint(ctx context.Context, consumerID string, fileurl *url.URL,
+	offset int64) error {
+	var fs = GetImplementation(fileurl)
+	var cancel context.CancelFunc
+
+	if fs == nil {
+		return ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if cfs, ok := fs.(CheckpointingFileSystem); ok {
+		return cfs.SetCheckpoint(ctx, consumerID, fileurl, offset)
+	}
+
+	return writeFileReplacing(ctx, fs, checkpointURL(consumerID, fileurl),
+		[]byte(strconv.FormatInt(offset, 10)))
+}
+
+/*
+GetCheckpoint retrieves the offset stored for the consumer and the
+referenced file by SetCheckpoint. If no checkpoint has been stored, an
+error matching fs.ErrNotExist is returned. An empty sidecar file, as left
+behind by an interrupted write on some file systems, counts as no
+checkpoint.
+*/
+func GetCheckpoint(ctx context.Context, consumerID string, fileurl *url.URL) (
+	int64, error) {
+	var fs = GetImplementation(fileurl)
+	var cancel context.CancelFunc
+	var u = checkpointURL(consumerID, fileurl)
+	var data []byte
+	var err error
+
+	if fs == nil {
+		return 0, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if cfs, ok := fs.(CheckpointingFileSystem); ok {
+		return cfs.GetCheckpoint(ctx, consumerID, fileurl)
+	}
+
+	if data, err = readFile(ctx, u); err != nil {
+		return 0, err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return 0, &iofs.PathError{Op: "read", Path: u.Path, Err: iofs.ErrNotExist}
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+/*
+DeleteCheckpoint removes the offset stored for the consumer and the
+referenced file, e.g. once the consumer has been decommissioned.
+*/
+func DeleteCheckpoint(ctx context.Context, consumerID string, fileurl *url.URL) error {
+	var fs = GetImplementation(fileurl)
+	var cancel context.CancelFunc
+
+	if fs == nil {
+		return ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if cfs, ok := fs.(CheckpointingFileSystem); ok {
+		return cfs.DeleteCheckpoint(ctx, consumerID, fileurl)
+	}
+
+	return fs.Remove(ctx, checkpointURL(consumerID, fileurl))
+}
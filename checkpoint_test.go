@@ -0,0 +1,63 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestCheckpoints(t *testing.T) {
+	vfs := virtualfs.NewVirtualFileSystem()
+	filesystem.AddImplementation("checkpoint", vfs)
+	u := &url.URL{Scheme: "checkpoint", Path: "/logs/events"}
+	ctx := context.Background()
+
+	if _, err := filesystem.GetCheckpoint(ctx, "indexer", u); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error for missing checkpoint: %v", err)
+	}
+
+	if err := filesystem.SetCheckpoint(ctx, "indexer", u, 4096); err != nil {
+		t.Fatalf("Error reported from SetCheckpoint: %v", err)
+	}
+	filesystem.SetCheckpoint(ctx, "archiver", u, 12)
+
+	if offset, err := filesystem.GetCheckpoint(ctx, "indexer", u); err != nil || offset != 4096 {
+		t.Errorf("Unexpected checkpoint %d (%v)", offset, err)
+	}
+
+	if err := filesystem.DeleteCheckpoint(ctx, "indexer", u); err != nil {
+		t.Errorf("Error reported from DeleteCheckpoint: %v", err)
+	}
+	if _, err := filesystem.GetCheckpoint(ctx, "indexer", u); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error for deleted checkpoint: %v", err)
+	}
+	if offset, _ := filesystem.GetCheckpoint(ctx, "archiver", u); offset != 12 {
+		t.Errorf("Checkpoint of other consumer changed to %d", offset)
+	}
+
+	entries, _ := vfs.ListEntries(ctx, &url.URL{Path: "/logs"})
+	for _, entry := range entries {
+		if strings.HasSuffix(entry, ".tmp") {
+			t.Errorf("Temporary file %s left behind", entry)
+		}
+	}
+}
+
+func TestEmptyCheckpoint(t *testing.T) {
+	filesystem.AddImplementation("emptycheckpoint", virtualfs.NewVirtualFileSystem())
+	u := &url.URL{Scheme: "emptycheckpoint", Path: "/logs/events"}
+
+	writeTestFile(t, &url.URL{Scheme: "emptycheckpoint",
+		Path: "/logs/.events.checkpoint.indexer"}, "")
+
+	if _, err := filesystem.GetCheckpoint(context.Background(), "indexer",
+		u); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error for empty checkpoint: %v", err)
+	}
+}
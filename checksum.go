@@ -0,0 +1,180 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"net/url"
+)
+
+/*
+Names of the checksum algorithms supported by ChecksumFile.
+*/
+const (
+	ChecksumMD5    = "md5"
+	ChecksumSHA256 = "sha256"
+	ChecksumCRC32C = "crc32c"
+)
+
+/*
+DefaultChecksumAlgorithm is used by ChecksumFile when no algorithm is
+specified and the file system does not have a native checksum algorithm.
+*/
+const DefaultChecksumAlgorithm = ChecksumSHA256
+
+/*
+ErrUnknownChecksum is returned when an unsupported checksum algorithm is
+requested.
+*/
+var ErrUnknownChecksum = errors.New("Unknown checksum algorithm")
+
+/*
+ErrChecksumMismatch is returned when the checksums of two files which should
+be identical differ.
+*/
+var ErrChecksumMismatch = errors.New("Checksum mismatch")
+
+/*
+ChecksumFileSystem is implemented by file systems which store checksums of
+files as metadata, such as the S3 ETag or the GCS CRC32C, and can thus
+return them without reading the file.
+*/
+type ChecksumFileSystem interface {
+	// Return the checksum of the specified file computed with the named
+	// algorithm. An empty algorithm name selects the native algorithm of
+	// the file system. Should return EUNSUPP if the checksum is not
+	// available without reading the file.
+	ChecksumFile(context.Context, *url.URL, string) ([]byte, error)
+}
+
+/*
+newHash creates a hash implementation for the named algorithm.
+*/
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli)), nil
+	}
+	return nil, ErrUnknownChecksum
+}
+
+/*
+ChecksumFile computes the checksum of the referenced file using the named
+algorithm (one of the Checksum* constants). If algorithm is empty, the
+native algorithm of the file system is used, or DefaultChecksumAlgorithm if
+it does not have one.
+
+If the file system implements ChecksumFileSystem and has the checksum
+available as metadata, it is returned directly. Otherwise the file is read
+and hashed.
+*/
+func ChecksumFile(ctx context.Context, fileurl *url.URL, algorithm string) (
+	[]byte, error) {
+	var fs = GetImplementation(fileurl)
+	var h hash.Hash
+	var rc ReadCloser
+	var sum []byte
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if cfs, ok := fs.(ChecksumFileSystem); ok {
+		if sum, err = cfs.ChecksumFile(ctx, fileurl, algorithm); err != EUNSUPP {
+			return sum, err
+		}
+	}
+
+	if algorithm == "" {
+		algorithm = DefaultChecksumAlgorithm
+	}
+
+	if h, err = newHash(algorithm); err != nil {
+		return nil, err
+	}
+
+	if rc, err = fs.OpenReader(ctx, fileurl); err != nil {
+		return nil, err
+	}
+	defer rc.Close(ctx)
+
+	if _, err = copyContents(ctx, &hashWriteCloser{h: h}, rc); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+/*
+CopyFileWithVerification copies the file at src to dst like CopyFile, then
+computes the checksums of both files independently using ChecksumFile and
+returns ErrChecksumMismatch if they differ. This is also done for server
+side copies. If algorithm is empty, DefaultChecksumAlgorithm is used so
+that the checksums of both file systems are comparable.
+
+Returns the number of bytes copied. The destination is left in place if
+the verification fails.
+*/
+func CopyFileWithVerification(ctx context.Context, src, dst *url.URL,
+	algorithm string) (int64, error) {
+	var srcSum, dstSum []byte
+	var n int64
+	var err error
+
+	if algorithm == "" {
+		algorithm = DefaultChecksumAlgorithm
+	}
+
+	if n, err = CopyFile(ctx, src, dst); err != nil {
+		return n, err
+	}
+
+	if srcSum, err = ChecksumFile(ctx, src, algorithm); err != nil {
+		return n, err
+	}
+	if dstSum, err = ChecksumFile(ctx, dst, algorithm); err != nil {
+		return n, err
+	}
+
+	if !bytes.Equal(srcSum, dstSum) {
+		return n, fmt.Errorf("%w: %s has %x, %s has %x", ErrChecksumMismatch,
+			src, srcSum, dst, dstSum)
+	}
+
+	return n, nil
+}
+
+/*
+WriteCloser which feeds all data written to it into a hash.
+*/
+type hashWriteCloser struct {
+	h hash.Hash
+}
+
+/*
+Write adds p to the hash.
+*/
+func (w *hashWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	return w.h.Write(p)
+}
+
+/*
+Close does nothing.
+*/
+func (w *hashWriteCloser) Close(ctx context.Context) error {
+	return nil
+}
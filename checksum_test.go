@@ -0,0 +1,98 @@
+package filesystem_test
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestChecksumFile(t *testing.T) {
+	filesystem.AddImplementation("checksum", virtualfs.NewVirtualFileSystem())
+	u := &url.URL{Scheme: "checksum", Path: "/file"}
+
+	wc, err := filesystem.OpenWriter(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenWriter: %v", err)
+	}
+	wc.Write(context.Background(), []byte("hello world"))
+	wc.Close(context.Background())
+
+	expected := map[string]string{
+		filesystem.ChecksumMD5: "5eb63bbbe01eeed093cb22bb8f5acdc3",
+		filesystem.ChecksumSHA256: "b94d27b9934d3e08a52e52d7da7dabfa" +
+			"c484efe37a5380ee9088f7ace2efcde9",
+		filesystem.ChecksumCRC32C: "c99465aa",
+		"": "b94d27b9934d3e08a52e52d7da7dabfa" +
+			"c484efe37a5380ee9088f7ace2efcde9",
+	}
+
+	for algorithm, sum := range expected {
+		result, err := filesystem.ChecksumFile(context.Background(), u, algorithm)
+		if err != nil {
+			t.Errorf("Error reported from ChecksumFile(%q): %v", algorithm, err)
+			continue
+		}
+		if hex.EncodeToString(result) != sum {
+			t.Errorf("Unexpected %q checksum %x", algorithm, result)
+		}
+	}
+
+	_, err = filesystem.ChecksumFile(context.Background(), u, "unknown")
+	if err != filesystem.ErrUnknownChecksum {
+		t.Errorf("Unexpected error for unknown algorithm: %v", err)
+	}
+}
+
+type corruptingFileSystem struct {
+	filesystem.FileSystem
+}
+
+func (c *corruptingFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	filesystem.WriteCloser, error) {
+	wc, err := c.FileSystem.OpenWriter(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	return &corruptingWriteCloser{wc}, nil
+}
+
+type corruptingWriteCloser struct {
+	filesystem.WriteCloser
+}
+
+func (c *corruptingWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	corrupted := append([]byte(nil), p...)
+	corrupted[0] ^= 0xff
+	return c.WriteCloser.Write(ctx, corrupted)
+}
+
+func TestCopyFileWithVerification(t *testing.T) {
+	filesystem.AddImplementation("verifysrc", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("verifydst", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("verifycorrupt", &corruptingFileSystem{
+		virtualfs.NewVirtualFileSystem()})
+	src := &url.URL{Scheme: "verifysrc", Path: "/file"}
+
+	writeTestFile(t, src, "important data")
+
+	n, err := filesystem.CopyFileWithVerification(context.Background(), src,
+		&url.URL{Scheme: "verifydst", Path: "/file"}, "")
+	if err != nil {
+		t.Errorf("Error reported from CopyFileWithVerification: %v", err)
+	}
+	if n != 14 {
+		t.Errorf("Unexpected number of bytes copied: %d", n)
+	}
+
+	_, err = filesystem.CopyFileWithVerification(context.Background(), src,
+		&url.URL{Scheme: "verifycorrupt", Path: "/file"},
+		filesystem.ChecksumMD5)
+	if !errors.Is(err, filesystem.ErrChecksumMismatch) {
+		t.Errorf("Unexpected error for corrupted copy: %v", err)
+	}
+}
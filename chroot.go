@@ -0,0 +1,158 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+/*
+ErrChrooted is returned by file systems created by Chroot for URLs which
+would resolve to a location outside of the root. It matches os.ErrPermission
+when compared using errors.Is.
+*/
+var ErrChrooted = fmt.Errorf("Path escapes the chroot: %w", os.ErrPermission)
+
+/*
+FileSystem which confines all operations to a subtree of another one.
+*/
+type chrootFileSystem struct {
+	inner FileSystem
+	root  *url.URL
+}
+
+/*
+Chroot creates a FileSystem which presents the subtree of fs beneath root
+as its entire contents. The paths of all URLs passed to it, including
+absolute ones, are resolved relative to root; the scheme, user and host of
+root always take precedence over those of the URL. URLs whose paths would
+escape root using ".." fail with ErrChrooted.
+
+The wrapper only supports the FileSystem methods.
+*/
+func Chroot(fs FileSystem, root *url.URL) FileSystem {
+	var r = *root
+
+	r.Path = path.Clean("/" + root.Path)
+	return &chrootFileSystem{inner: fs, root: &r}
+}
+
+/*
+resolve maps the URL into the subtree beneath root.
+*/
+func (c *chrootFileSystem) resolve(fileurl *url.URL) (*url.URL, error) {
+	var rel = path.Clean(strings.TrimLeft(fileurl.Path, "/"))
+	var resolved = *c.root
+
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return nil, ErrChrooted
+	}
+
+	resolved.Path = path.Join(c.root.Path, rel)
+	if resolved.Path != c.root.Path &&
+		!strings.HasPrefix(resolved.Path, strings.TrimSuffix(c.root.Path, "/")+"/") {
+		return nil, ErrChrooted
+	}
+	resolved.RawPath = ""
+	resolved.RawQuery = fileurl.RawQuery
+	resolved.Fragment = fileurl.Fragment
+
+	return &resolved, nil
+}
+
+/*
+OpenReader opens the file beneath the root for reading.
+*/
+func (c *chrootFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	var resolved, err = c.resolve(u)
+
+	if err != nil {
+		return nil, err
+	}
+	return c.inner.OpenReader(ctx, resolved)
+}
+
+/*
+OpenWriter opens the file beneath the root for writing.
+*/
+func (c *chrootFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	var resolved, err = c.resolve(u)
+
+	if err != nil {
+		return nil, err
+	}
+	return c.inner.OpenWriter(ctx, resolved)
+}
+
+/*
+OpenAppender opens the file beneath the root for appending.
+*/
+func (c *chrootFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	var resolved, err = c.resolve(u)
+
+	if err != nil {
+		return nil, err
+	}
+	return c.inner.OpenAppender(ctx, resolved)
+}
+
+/*
+ListEntries lists the directory beneath the root.
+*/
+func (c *chrootFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	var resolved, err = c.resolve(u)
+
+	if err != nil {
+		return nil, err
+	}
+	return c.inner.ListEntries(ctx, resolved)
+}
+
+/*
+WatchFile watches the file beneath the root. The watcher is invoked with the
+URL originally passed in, so the location of the root is not revealed.
+*/
+func (c *chrootFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	var resolved, err = c.resolve(u)
+
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.inner.WatchFile(ctx, resolved, func(_ *url.URL, rc ReadCloser) {
+		watcher(u, rc)
+	})
+}
+
+/*
+Remove deletes the file beneath the root.
+*/
+func (c *chrootFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	var resolved, err = c.resolve(u)
+
+	if err != nil {
+		return err
+	}
+	return c.inner.Remove(ctx, resolved)
+}
+
+/*
+Stat retrieves the metadata of the file beneath the root.
+*/
+func (c *chrootFileSystem) Stat(ctx context.Context, u *url.URL) (
+	FileInfo, error) {
+	var resolved *url.URL
+	var err error
+
+	if resolved, err = c.resolve(u); err != nil {
+		return nil, err
+	}
+	return c.inner.Stat(ctx, resolved)
+}
@@ -0,0 +1,34 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestChroot(t *testing.T) {
+	inner := virtualfs.NewVirtualFileSystem()
+	filesystem.AddImplementation("chroot-inner", inner)
+	filesystem.AddImplementation("jail", filesystem.Chroot(inner,
+		&url.URL{Scheme: "chroot-inner", Path: "/srv/tenant"}))
+
+	writeTestFile(t, &url.URL{Scheme: "chroot-inner", Path: "/srv/tenant/file"}, "inside")
+	writeTestFile(t, &url.URL{Scheme: "chroot-inner", Path: "/srv/secret"}, "outside")
+
+	for _, p := range []string{"/file", "file", "/sub/../file"} {
+		if data, err := readTestFile(t, &url.URL{Scheme: "jail", Path: p}); err != nil || data != "inside" {
+			t.Errorf("Unexpected result reading %s: %q (%v)", p, data, err)
+		}
+	}
+
+	for _, p := range []string{"/../secret", "../secret", "/a/../../secret", ".."} {
+		_, err := filesystem.OpenReader(context.Background(),
+			&url.URL{Scheme: "jail", Path: p})
+		if err != filesystem.ErrChrooted {
+			t.Errorf("Unexpected error reading %s: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package filesystem
+
+import (
+	"context"
+)
+
+/*
+ContextCloser is a context-aware variant of the good old io.Closer. It is
+satisfied by ReadCloser and WriteCloser alike.
+*/
+type ContextCloser interface {
+	Close(context.Context) error
+}
+
+/*
+Implementation of a ContextCloser which closes multiple other closers.
+*/
+type mergedCloser struct {
+	closers []ContextCloser
+}
+
+/*
+Close closes all merged closers. See CloseAll.
+*/
+func (m *mergedCloser) Close(ctx context.Context) error {
+	return CloseAll(ctx, m.closers...)
+}
+
+/*
+MergeClosers creates a single ContextCloser which closes all of the given
+closers when it is closed.
+*/
+func MergeClosers(closers ...ContextCloser) ContextCloser {
+	var c = make([]ContextCloser, len(closers))
+	copy(c, closers)
+	return &mergedCloser{closers: c}
+}
+
+/*
+CloseAll closes all of the given closers, regardless of whether closing any
+of them fails. All errors are reported as a MultiError.
+*/
+func CloseAll(ctx context.Context, closers ...ContextCloser) error {
+	var errs MultiError
+
+	for _, c := range closers {
+		if err := c.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
@@ -0,0 +1,39 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMergeClosers(t *testing.T) {
+	closers := []ContextCloser{
+		&MockWriteCloser{}, &MockWriteCloser{Fail: true}, &MockWriteCloser{},
+	}
+	merged := MergeClosers(closers...)
+	// Modifying the slice afterwards must not affect the merged closer.
+	closers[2] = &MockWriteCloser{}
+
+	err := merged.Close(context.Background())
+	if !errors.Is(err, ErrExpected) {
+		t.Errorf("Unexpected error from Close: %v", err)
+	}
+
+	var merr MultiError
+	if !errors.As(err, &merr) || len(merr) != 1 {
+		t.Errorf("Expected a single error, got %v", err)
+	}
+	if !closers[0].(*MockWriteCloser).Closed || !closers[1].(*MockWriteCloser).Closed {
+		t.Error("Not all closers were closed")
+	}
+	if closers[2].(*MockWriteCloser).Closed {
+		t.Error("Closer added after merging was closed")
+	}
+}
+
+func TestCloseAllWithoutErrors(t *testing.T) {
+	if err := CloseAll(context.Background(), &MockWriteCloser{},
+		&MockWriteCloser{}); err != nil {
+		t.Errorf("Unexpected error from CloseAll: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+/*
+CompactingFileSystem is implemented by append-only file systems, such as
+write-ahead logs or event streams, which can discard old data once it is no
+longer needed.
+*/
+type CompactingFileSystem interface {
+	// Remove all data appended to the file before the specified time.
+	// Returns the number of bytes freed.
+	Compact(context.Context, *url.URL, time.Time) (int64, error)
+}
+
+/*
+Compact removes all data appended to the referenced file before the
+specified time and returns the number of bytes freed. Unlike Remove, the
+file itself and all data appended later are kept.
+
+File systems which do not implement CompactingFileSystem will cause EUNSUPP
+to be returned.
+*/
+func Compact(ctx context.Context, fileurl *url.URL, before time.Time) (
+	int64, error) {
+	var fs = GetImplementation(fileurl)
+	var cfs CompactingFileSystem
+	var cancel context.CancelFunc
+	var ok bool
+
+	if fs == nil {
+		return 0, ENOFS
+	}
+
+	if cfs, ok = fs.(CompactingFileSystem); !ok {
+		return 0, EUNSUPP
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return cfs.Compact(ctx, fileurl, before)
+}
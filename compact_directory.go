@@ -0,0 +1,299 @@
+package filesystem
+
+import (
+	"bufio"
+	"context"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+Name of the journal file recording the merge in progress in a directory
+being compacted by CompactDirectory.
+*/
+const compactJournalName = ".compact-journal"
+
+/*
+Suffix of the temporary file a group of files is merged into.
+*/
+const compactTempSuffix = ".compacting"
+
+/*
+CompactResult describes the outcome of CompactDirectory.
+*/
+type CompactResult struct {
+	// Number of files which were merged into larger ones.
+	FilesMerged int
+
+	// Number of bytes written to the merged files.
+	BytesWritten int64
+}
+
+/*
+DirectoryCompactingFileSystem is implemented by file systems which can merge
+many small files into fewer large ones natively, e.g. using object
+composition in object stores.
+*/
+type DirectoryCompactingFileSystem interface {
+	// Merge the files in the directory into files of at most the specified
+	// size, ordered by modification time.
+	CompactDirectory(context.Context, *url.URL, int64) (CompactResult, error)
+}
+
+/*
+CompactDirectory merges the files in the referenced directory into fewer
+files of at most maxObjectSize bytes each, as is commonly required for
+workloads creating many small files, such as sensor data or log shipping.
+Files are concatenated in the order of their modification time, then name,
+and each group is stored under the name and modification time of its oldest
+file. Files larger than maxObjectSize are left alone, as are subdirectories
+and entries whose names start with a dot, such as checkpoints.
+
+For file systems which do not implement DirectoryCompactingFileSystem, the
+merge is recorded in a journal file in the directory, so an interrupted
+compaction is completed by the next invocation, which makes the operation
+idempotent. Merged files only keep the modification time of their oldest
+file if the file system implements TouchingFileSystem.
+*/
+func CompactDirectory(ctx context.Context, dirurl *url.URL, maxObjectSize int64) (
+	CompactResult, error) {
+	var fs = GetImplementation(dirurl)
+	var result CompactResult
+	var entries []string
+	var infos []FileInfo
+	var group []FileInfo
+	var groupSize int64
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return result, ENOFS
+	}
+
+	if dfs, ok := fs.(DirectoryCompactingFileSystem); ok {
+		ctx, cancel = withDefaultTimeout(ctx)
+		defer cancel()
+
+		return dfs.CompactDirectory(ctx, dirurl, maxObjectSize)
+	}
+
+	if err = resumeCompaction(ctx, fs, dirurl); err != nil {
+		return result, err
+	}
+
+	if entries, err = fs.ListEntries(ctx, dirurl); err != nil {
+		return result, err
+	}
+
+	for _, entry := range entries {
+		var fi FileInfo
+
+		if strings.HasPrefix(entry, ".") ||
+			strings.HasSuffix(entry, compactTempSuffix) {
+			continue
+		}
+		if fi, err = fs.Stat(ctx, childURL(dirurl, entry)); err != nil {
+			return result, err
+		}
+		if !fi.IsDir() {
+			infos = append(infos, fi)
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].ModTime().Equal(infos[j].ModTime()) {
+			return infos[i].ModTime().Before(infos[j].ModTime())
+		}
+		return infos[i].Name() < infos[j].Name()
+	})
+
+	// Append a sentinel which never fits into a group to flush the last one.
+	for i := 0; i <= len(infos); i++ {
+		if i < len(infos) && groupSize+infos[i].Size() <= maxObjectSize {
+			group = append(group, infos[i])
+			groupSize += infos[i].Size()
+			continue
+		}
+
+		if len(group) > 1 {
+			var n int64
+
+			if n, err = mergeFiles(ctx, fs, dirurl, group); err != nil {
+				return result, err
+			}
+			result.FilesMerged += len(group)
+			result.BytesWritten += n
+		}
+
+		group, groupSize = nil, 0
+		if i < len(infos) && infos[i].Size() <= maxObjectSize {
+			group = append(group, infos[i])
+			groupSize = infos[i].Size()
+		}
+	}
+
+	return result, nil
+}
+
+/*
+childURL creates the URL of the named entry in the directory.
+*/
+func childURL(dirurl *url.URL, name string) *url.URL {
+	var u = *dirurl
+	u.Path = path.Join(dirurl.Path, name)
+	return &u
+}
+
+/*
+mergeFiles concatenates the files of the group into a temporary file, then
+replaces the first file of the group with it and removes the others. The
+journal, holding the modification time of the first file followed by the
+names of the merged files, is written once the temporary file is complete,
+so resumeCompaction can finish the merge from there.
+*/
+func mergeFiles(ctx context.Context, fs FileSystem, dirurl *url.URL,
+	group []FileInfo) (int64, error) {
+	var target = childURL(dirurl, group[0].Name())
+	var temp = childURL(dirurl, group[0].Name()+compactTempSuffix)
+	var journal strings.Builder
+	var wc WriteCloser
+	var written int64
+	var err error
+
+	if wc, err = fs.OpenWriter(ctx, temp); err != nil {
+		return 0, err
+	}
+
+	for _, fi := range group {
+		var rc ReadCloser
+		var n int64
+
+		if rc, err = fs.OpenReader(ctx, childURL(dirurl, fi.Name())); err != nil {
+			wc.Close(ctx)
+			return written, err
+		}
+		n, err = copyContents(ctx, wc, rc)
+		written += n
+		rc.Close(ctx)
+		if err != nil {
+			wc.Close(ctx)
+			return written, err
+		}
+	}
+
+	if err = wc.Close(ctx); err != nil {
+		return written, err
+	}
+
+	journal.WriteString(group[0].ModTime().Format(time.RFC3339Nano) + "\n")
+	for _, fi := range group {
+		journal.WriteString(fi.Name() + "\n")
+	}
+	if err = writeJournal(ctx, fs, dirurl, journal.String()); err != nil {
+		return written, err
+	}
+
+	return written, finishCompaction(ctx, fs, dirurl, target, temp,
+		group[0].ModTime(), strings.Split(
+			strings.TrimSuffix(journal.String(), "\n"), "\n")[1:])
+}
+
+/*
+writeJournal stores the journal of the merge in progress.
+*/
+func writeJournal(ctx context.Context, fs FileSystem, dirurl *url.URL,
+	contents string) error {
+	var wc, err = fs.OpenWriter(ctx, childURL(dirurl, compactJournalName))
+
+	if err != nil {
+		return err
+	}
+	if _, err = wc.Write(ctx, []byte(contents)); err != nil {
+		wc.Close(ctx)
+		return err
+	}
+	return wc.Close(ctx)
+}
+
+/*
+finishCompaction moves the merged temporary file into place if this has not
+happened yet, restores its modification time if supported, removes the
+merged files and finally the journal.
+*/
+func finishCompaction(ctx context.Context, fs FileSystem, dirurl, target,
+	temp *url.URL, modTime time.Time, names []string) error {
+	var exists bool
+	var err error
+
+	if exists, err = fileExists(ctx, fs, temp); err != nil {
+		return err
+	} else if exists {
+		if err = Move(ctx, temp, target); err != nil {
+			return err
+		}
+	}
+
+	if tfs, ok := fs.(TouchingFileSystem); ok {
+		if err = tfs.Touch(ctx, target, modTime); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names[1:] {
+		var u = childURL(dirurl, name)
+
+		if exists, err = fileExists(ctx, fs, u); err != nil {
+			return err
+		} else if exists {
+			if err = fs.Remove(ctx, u); err != nil {
+				return err
+			}
+		}
+	}
+
+	return fs.Remove(ctx, childURL(dirurl, compactJournalName))
+}
+
+/*
+resumeCompaction completes a merge recorded in the journal of the directory,
+if there is one.
+*/
+func resumeCompaction(ctx context.Context, fs FileSystem, dirurl *url.URL) error {
+	var journal = childURL(dirurl, compactJournalName)
+	var names []string
+	var modTime time.Time
+	var rc ReadCloser
+	var exists bool
+	var err error
+
+	if exists, err = fileExists(ctx, fs, journal); err != nil || !exists {
+		return err
+	}
+
+	if rc, err = fs.OpenReader(ctx, journal); err != nil {
+		return err
+	}
+
+	var scanner = bufio.NewScanner(&contextReader{r: rc, ctx: ctx})
+	for scanner.Scan() {
+		names = append(names, scanner.Text())
+	}
+	rc.Close(ctx)
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(names) < 2 {
+		return fs.Remove(ctx, journal)
+	}
+	if modTime, err = time.Parse(time.RFC3339Nano, names[0]); err != nil {
+		return err
+	}
+	names = names[1:]
+
+	return finishCompaction(ctx, fs, dirurl, childURL(dirurl, names[0]),
+		childURL(dirurl, names[0]+compactTempSuffix), modTime, names)
+}
@@ -0,0 +1,58 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestCompactDirectory(t *testing.T) {
+	vfs := virtualfs.NewVirtualFileSystem()
+	filesystem.AddImplementation("compactdir", vfs)
+	dir := &url.URL{Scheme: "compactdir", Path: "/data"}
+	ctx := context.Background()
+
+	for _, name := range []string{"c", "a", "b", "d"} {
+		writeTestFile(t, &url.URL{Scheme: "compactdir", Path: "/data/" + name},
+			strings.Repeat(name, map[string]int{"d": 10}[name]+3))
+	}
+
+	writeTestFile(t, &url.URL{Scheme: "compactdir", Path: "/data/.checkpoint"},
+		"1")
+	first, err := vfs.Stat(ctx, &url.URL{Scheme: "compactdir", Path: "/data/c"})
+	if err != nil {
+		t.Fatalf("Error reported from Stat: %v", err)
+	}
+
+	result, err := filesystem.CompactDirectory(ctx, dir, 7)
+	if err != nil {
+		t.Fatalf("Error reported from CompactDirectory: %v", err)
+	}
+	if result.FilesMerged != 2 || result.BytesWritten != 6 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	entries, _ := vfs.ListEntries(ctx, dir)
+	if strings.Join(entries, ",") != ".checkpoint,b,c,d" {
+		t.Errorf("Unexpected entries after compaction: %v", entries)
+	}
+	if data, _ := readTestFile(t, &url.URL{
+		Scheme: "compactdir", Path: "/data/c"}); data != "cccaaa" {
+		t.Errorf("Unexpected merged contents %q", data)
+	}
+	if fi, err := vfs.Stat(ctx, &url.URL{
+		Scheme: "compactdir", Path: "/data/c"}); err != nil ||
+		!fi.ModTime().Equal(first.ModTime()) {
+		t.Errorf("Merged file lost its modification time %v (%v)",
+			first.ModTime(), err)
+	}
+
+	result, err = filesystem.CompactDirectory(ctx, dir, 7)
+	if err != nil || result.FilesMerged != 0 {
+		t.Errorf("Unexpected result of second compaction: %+v (%v)", result, err)
+	}
+}
@@ -0,0 +1,84 @@
+package filesystem_test
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+/*
+compactingFileSystem stores logs with one entry per line, each starting with
+an RFC 3339 timestamp, and compacts them by dropping old lines.
+*/
+type compactingFileSystem struct {
+	*virtualfs.VirtualFileSystem
+}
+
+func (c compactingFileSystem) Compact(ctx context.Context, u *url.URL,
+	before time.Time) (int64, error) {
+	rc, err := c.OpenReader(ctx, u)
+	if err != nil {
+		return 0, err
+	}
+	data, err := io.ReadAll(filesystem.ToIoReadCloser(rc))
+	rc.Close(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var kept strings.Builder
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		stamp, _, _ := strings.Cut(line, " ")
+		if ts, err := time.Parse(time.RFC3339, stamp); err == nil && ts.Before(before) {
+			continue
+		}
+		kept.WriteString(line)
+	}
+
+	wc, err := c.OpenWriter(ctx, u)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = wc.Write(ctx, []byte(kept.String())); err != nil {
+		wc.Close(ctx)
+		return 0, err
+	}
+	return int64(len(data) - kept.Len()), wc.Close(ctx)
+}
+
+func TestCompact(t *testing.T) {
+	filesystem.AddImplementation("compactplain", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("compact", compactingFileSystem{
+		virtualfs.NewVirtualFileSystem()})
+	u := &url.URL{Scheme: "compact", Path: "/log"}
+	before := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	if _, err := filesystem.Compact(ctx, &url.URL{Scheme: "compactunregistered",
+		Path: "/log"}, before); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+	if _, err := filesystem.Compact(ctx, &url.URL{Scheme: "compactplain",
+		Path: "/log"}, before); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP without support, got %v", err)
+	}
+
+	writeTestFile(t, u, "2019-12-31T23:59:59Z old\n2020-01-01T00:00:00Z new\n")
+
+	freed, err := filesystem.Compact(ctx, u, before)
+	if err != nil {
+		t.Fatalf("Error reported from Compact: %v", err)
+	}
+	if freed != 25 {
+		t.Errorf("Unexpected number of freed bytes %d", freed)
+	}
+	if data, _ := readTestFile(t, u); data != "2020-01-01T00:00:00Z new\n" {
+		t.Errorf("Unexpected contents %q after Compact", data)
+	}
+}
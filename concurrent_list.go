@@ -0,0 +1,75 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"sync"
+)
+
+/*
+ConcurrentListEntries retrieves the entries of all of the specified URLs,
+running up to concurrency listings in parallel. The results are returned in
+a map keyed by the string representation of each URL.
+
+The first error encountered cancels all outstanding listings and is
+returned, along with all results which had been retrieved successfully up
+to that point.
+*/
+func ConcurrentListEntries(ctx context.Context, dirURLs []*url.URL,
+	concurrency int) (map[string][]string, error) {
+	var results = make(map[string][]string)
+	var firstErr error
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	var sem chan struct{}
+	var cancel context.CancelFunc
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem = make(chan struct{}, concurrency)
+
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+
+	for _, dirurl := range dirURLs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(dirurl *url.URL) {
+			var entries []string
+			var err error
+
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			entries, err = ListEntries(ctx, dirurl)
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			results[dirurl.String()] = entries
+		}(dirurl)
+	}
+
+	wg.Wait()
+
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = ctx.Err()
+	}
+
+	return results, firstErr
+}
@@ -0,0 +1,53 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestConcurrentListEntries(t *testing.T) {
+	AddImplementation("mock", &MockFileSystem{
+		Entries: map[string][]string{
+			"/a": {"1", "2"},
+			"/b": {"3"},
+			"/c": {},
+		},
+	})
+
+	urls := []*url.URL{
+		mustParse(t, "mock:///a"),
+		mustParse(t, "mock:///b"),
+		mustParse(t, "mock:///c"),
+	}
+
+	results, err := ConcurrentListEntries(context.Background(), urls, 2)
+	if err != nil {
+		t.Fatalf("Error reported from ConcurrentListEntries: %v", err)
+	}
+	if len(results) != 3 {
+		t.Errorf("Expected 3 results, got %d", len(results))
+	}
+	if len(results["mock:///a"]) != 2 || len(results["mock:///b"]) != 1 {
+		t.Errorf("Unexpected results %v", results)
+	}
+}
+
+func TestConcurrentListEntriesReportsError(t *testing.T) {
+	AddImplementation("mock", &MockFileSystem{
+		Entries: map[string][]string{"/a": {"1"}},
+	})
+
+	urls := []*url.URL{
+		mustParse(t, "mock:///a"),
+		mustParse(t, "mock:///missing"),
+	}
+
+	results, err := ConcurrentListEntries(context.Background(), urls, 1)
+	if err != ErrExpected {
+		t.Errorf("Unexpected error from ConcurrentListEntries: %v", err)
+	}
+	if len(results["mock:///a"]) != 1 {
+		t.Errorf("Successful result not returned: %v", results)
+	}
+}
@@ -0,0 +1,191 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+/*
+ErrUnsupportedEncoding is returned when data stored with a content encoding
+has to be decoded, but the encoding is not known to this package.
+*/
+var ErrUnsupportedEncoding = fmt.Errorf("Unsupported content encoding: %w", EUNSUPP)
+
+/*
+Name of the URL query parameter listing the content encodings the reader of
+a file is able to handle. See OpenReader.
+*/
+const acceptEncodingParam = "accept-encoding"
+
+/*
+ContentEncodingFileSystem is implemented by file systems which can store the
+content encoding of a file as metadata, like the Content-Encoding header in
+HTTP, so it can be advertised to readers.
+*/
+type ContentEncodingFileSystem interface {
+	// Set the content encoding of the file, e.g. "gzip", "br" or "zstd".
+	SetContentEncoding(context.Context, *url.URL, string) error
+
+	// Retrieve the content encoding of the file, or an empty string if the
+	// contents are not encoded.
+	GetContentEncoding(context.Context, *url.URL) (string, error)
+}
+
+/*
+getContentEncodingFileSystem determines the ContentEncodingFileSystem
+responsible for the URL, or returns ENOFS or EUNSUPP if there is none.
+*/
+func getContentEncodingFileSystem(fileurl *url.URL) (
+	ContentEncodingFileSystem, error) {
+	var fs = GetImplementation(fileurl)
+	var cfs ContentEncodingFileSystem
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if cfs, ok = fs.(ContentEncodingFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	return cfs, nil
+}
+
+/*
+SetContentEncoding records the encoding the contents of the referenced file
+were written in, such as "gzip".
+*/
+func SetContentEncoding(ctx context.Context, fileurl *url.URL, encoding string) error {
+	var cfs ContentEncodingFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if cfs, err = getContentEncodingFileSystem(fileurl); err != nil {
+		return err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return cfs.SetContentEncoding(ctx, fileurl, encoding)
+}
+
+/*
+GetContentEncoding retrieves the encoding the contents of the referenced
+file were written in, or an empty string if they are not encoded.
+*/
+func GetContentEncoding(ctx context.Context, fileurl *url.URL) (string, error) {
+	var cfs ContentEncodingFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if cfs, err = getContentEncodingFileSystem(fileurl); err != nil {
+		return "", err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return cfs.GetContentEncoding(ctx, fileurl)
+}
+
+/*
+openDecodingReader opens the file for reading, honoring the accept-encoding
+query parameter: if the stored content encoding of the file is not among
+the comma separated encodings listed there, the contents are decoded
+transparently. The parameter is removed from the URL before it is passed to
+the file system.
+*/
+func openDecodingReader(ctx context.Context, fs FileSystem, fileurl *url.URL) (
+	ReadCloser, error) {
+	var query = fileurl.Query()
+	var accepted = strings.Split(query.Get(acceptEncodingParam), ",")
+	var stripped = *fileurl
+	var encoding string
+	var factory DecompressorFactory
+	var rc ReadCloser
+	var err error
+
+	query.Del(acceptEncodingParam)
+	stripped.RawQuery = query.Encode()
+
+	if rc, err = fs.OpenReader(ctx, &stripped); err != nil {
+		return nil, err
+	}
+
+	if cfs, ok := fs.(ContentEncodingFileSystem); ok {
+		if encoding, err = cfs.GetContentEncoding(ctx, &stripped); err != nil {
+			rc.Close(ctx)
+			return nil, err
+		}
+	}
+
+	for i := range accepted {
+		accepted[i] = strings.TrimSpace(accepted[i])
+	}
+	if encoding == "" || encoding == "identity" ||
+		slices.Contains(accepted, encoding) {
+		return rc, nil
+	}
+
+	if factory = getDecompressor(encoding); factory == nil {
+		rc.Close(ctx)
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+
+	return decompress(ctx, factory, rc)
+}
+
+/*
+io.Reader reading from a ReadCloser using the context of the operation
+currently in progress.
+*/
+type contextReader struct {
+	r   ReadCloser
+	ctx context.Context
+}
+
+/*
+Read reads from the ReadCloser using the current context.
+*/
+func (c *contextReader) Read(p []byte) (int, error) {
+	return c.r.Read(c.ctx, p)
+}
+
+/*
+ReadCloser which decodes the contents of another ReadCloser. The decoder is
+only created on the first read, since it may already consume data.
+*/
+type decodingReadCloser struct {
+	src        *contextReader
+	newDecoder func(io.Reader) (io.Reader, error)
+	decoder    io.Reader
+}
+
+/*
+Read reads decoded data.
+*/
+func (d *decodingReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	var err error
+
+	d.src.ctx = ctx
+	if d.decoder == nil {
+		if d.decoder, err = d.newDecoder(d.src); err != nil {
+			return 0, err
+		}
+	}
+
+	return d.decoder.Read(p)
+}
+
+/*
+Close closes the underlying ReadCloser.
+*/
+func (d *decodingReadCloser) Close(ctx context.Context) error {
+	return d.src.r.Close(ctx)
+}
@@ -0,0 +1,84 @@
+package filesystem_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestOpenReaderAcceptEncoding(t *testing.T) {
+	filesystem.AddImplementation("encoded", virtualfs.NewVirtualFileSystem())
+	u := &url.URL{Scheme: "encoded", Path: "/file.txt"}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("hello world"))
+	gz.Close()
+
+	writeTestFile(t, u, buf.String())
+	if err := filesystem.SetContentEncoding(context.Background(), u, "gzip"); err != nil {
+		t.Fatalf("Error reported from SetContentEncoding: %v", err)
+	}
+
+	for query, expected := range map[string]string{
+		"":                          buf.String(),
+		"accept-encoding=gzip":      buf.String(),
+		"accept-encoding=identity":  "hello world",
+		"accept-encoding=br,+gzip2": "hello world",
+	} {
+		data, err := readTestFile(t, &url.URL{
+			Scheme: u.Scheme, Path: u.Path, RawQuery: query})
+		if err != nil {
+			t.Errorf("Error reading with %q: %v", query, err)
+		} else if data != expected {
+			t.Errorf("Unexpected contents with %q: %q", query, data)
+		}
+	}
+}
+
+func TestOpenReaderWithDecompression(t *testing.T) {
+	filesystem.AddImplementation("decompress", virtualfs.NewVirtualFileSystem())
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("hello world"))
+	gz.Close()
+
+	for path, encoding := range map[string]string{
+		"/by-extension.txt.gz": "",
+		"/by-metadata.txt":     "gzip",
+		"/plain.txt":           "",
+	} {
+		u := &url.URL{Scheme: "decompress", Path: path}
+		if path == "/plain.txt" {
+			writeTestFile(t, u, "hello world")
+		} else {
+			writeTestFile(t, u, buf.String())
+		}
+		if encoding != "" {
+			filesystem.SetContentEncoding(ctx, u, encoding)
+		}
+
+		rc, detected, err := filesystem.OpenReaderWithDecompression(ctx, u)
+		if err != nil {
+			t.Errorf("Error reported from OpenReaderWithDecompression(%s): %v", path, err)
+			continue
+		}
+		data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc))
+		rc.Close(ctx)
+
+		if string(data) != "hello world" {
+			t.Errorf("Unexpected data for %s: %q", path, data)
+		}
+		if (path == "/plain.txt") != (detected == "") {
+			t.Errorf("Unexpected encoding %q detected for %s", detected, path)
+		}
+	}
+}
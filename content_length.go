@@ -0,0 +1,57 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+LengthReportingFileSystem is implemented by file systems which learn the
+length of a file while opening it, e.g. from the Content-Length header of a
+HTTP response, and can thus report it without an additional round trip.
+*/
+type LengthReportingFileSystem interface {
+	// Open the specified file for reading and return its length in bytes,
+	// or -1 if it is unknown.
+	OpenReaderWithLength(context.Context, *url.URL) (ReadCloser, int64, error)
+}
+
+/*
+OpenReaderWithLength opens the referenced file for reading like OpenReader,
+and additionally returns its length in bytes, or -1 if it is unknown. The
+length can be used to pre-allocate buffers or to report progress.
+
+File systems implementing LengthReportingFileSystem determine the length
+while opening the file. For all others, the length is retrieved using Stat
+if supported.
+*/
+func OpenReaderWithLength(ctx context.Context, fileurl *url.URL) (
+	ReadCloser, int64, error) {
+	var fs = GetImplementation(fileurl)
+	var size int64 = -1
+	var rc ReadCloser
+	var err error
+
+	if fs == nil {
+		return nil, -1, ENOFS
+	}
+
+	if lfs, ok := fs.(LengthReportingFileSystem); ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = withDefaultTimeout(ctx)
+		defer cancel()
+
+		return lfs.OpenReaderWithLength(ctx, fileurl)
+	}
+
+	if fi, err := Stat(ctx, fileurl); err == nil && !fi.IsDir() {
+		size = fi.Size()
+	}
+
+	if rc, err = OpenReader(ctx, fileurl); err != nil {
+		return nil, -1, err
+	}
+
+	return rc, size, nil
+}
@@ -0,0 +1,39 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestOpenReaderWithLength(t *testing.T) {
+	filesystem.AddImplementation("withlength", virtualfs.NewVirtualFileSystem())
+	u := &url.URL{Scheme: "withlength", Path: "/file"}
+
+	writeTestFile(t, u, "hello world")
+
+	rc, size, err := filesystem.OpenReaderWithLength(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenReaderWithLength: %v", err)
+	}
+	defer rc.Close(context.Background())
+
+	if size != 11 {
+		t.Errorf("Unexpected length %d", size)
+	}
+	if data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc)); string(data) != "hello world" {
+		t.Errorf("Unexpected contents %q", string(data))
+	}
+
+	rc, size, err = filesystem.OpenReaderWithLength(context.Background(),
+		&url.URL{Scheme: "withlength", Path: "/missing"})
+	if !errors.Is(err, fs.ErrNotExist) || rc != nil || size != -1 {
+		t.Errorf("Unexpected result for missing file: %v, %d, %v", rc, size, err)
+	}
+}
@@ -0,0 +1,44 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+SizeHintingFileSystem is implemented by file systems which can make use of
+the expected size of a file when opening it for writing, e.g. to choose
+between simple and multipart uploads or to preallocate storage.
+*/
+type SizeHintingFileSystem interface {
+	// Open the specified file for writing like OpenWriter, expecting the
+	// specified number of bytes to be written.
+	OpenWriterWithContentLength(context.Context, *url.URL, int64) (WriteCloser, error)
+}
+
+/*
+OpenWriterWithContentLength opens the referenced file for writing like
+OpenWriter, and informs the file system that contentLength bytes are about
+to be written. This is only a hint: writing more or fewer bytes is not an
+error. File systems which do not implement SizeHintingFileSystem ignore it.
+*/
+func OpenWriterWithContentLength(ctx context.Context, fileurl *url.URL,
+	contentLength int64) (WriteCloser, error) {
+	var fs = GetImplementation(fileurl)
+	var shfs SizeHintingFileSystem
+	var cancel context.CancelFunc
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if shfs, ok = fs.(SizeHintingFileSystem); !ok {
+		return OpenWriter(ctx, fileurl)
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return shfs.OpenWriterWithContentLength(ctx, fileurl, contentLength)
+}
@@ -0,0 +1,66 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+/*
+sizeHintingFileSystem remembers the content length announced for the last
+file opened using OpenWriterWithContentLength.
+*/
+type sizeHintingFileSystem struct {
+	*virtualfs.VirtualFileSystem
+	contentLength int64
+}
+
+func (s *sizeHintingFileSystem) OpenWriterWithContentLength(ctx context.Context,
+	u *url.URL, contentLength int64) (filesystem.WriteCloser, error) {
+	s.contentLength = contentLength
+	return s.OpenWriter(ctx, u)
+}
+
+func writeWithContentLength(t *testing.T, u *url.URL, data string) {
+	wc, err := filesystem.OpenWriterWithContentLength(context.Background(), u,
+		int64(len(data)))
+	if err != nil {
+		t.Fatalf("Error reported from OpenWriterWithContentLength: %v", err)
+	}
+	wc.Write(context.Background(), []byte(data))
+	if err = wc.Close(context.Background()); err != nil {
+		t.Fatalf("Error reported from Close: %v", err)
+	}
+}
+
+func TestOpenWriterWithContentLength(t *testing.T) {
+	shfs := &sizeHintingFileSystem{
+		VirtualFileSystem: virtualfs.NewVirtualFileSystem(), contentLength: -1}
+	filesystem.AddImplementation("contentlengthplain", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("contentlength", shfs)
+	plain := &url.URL{Scheme: "contentlengthplain", Path: "/file"}
+	u := &url.URL{Scheme: "contentlength", Path: "/file"}
+
+	if _, err := filesystem.OpenWriterWithContentLength(context.Background(),
+		&url.URL{Scheme: "contentlengthunregistered", Path: "/file"},
+		5); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+
+	writeWithContentLength(t, plain, "hello")
+	if data, _ := readTestFile(t, plain); data != "hello" {
+		t.Errorf("Unexpected contents %q written by OpenWriter fallback", data)
+	}
+
+	writeWithContentLength(t, u, "hello world")
+	if data, _ := readTestFile(t, u); data != "hello world" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+	if shfs.contentLength != 11 {
+		t.Errorf("Unexpected content length %d passed to file system",
+			shfs.contentLength)
+	}
+}
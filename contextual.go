@@ -0,0 +1,88 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+ContextEnricher derives the context used for a file system operation from
+the context passed by the caller, e.g. by adding request or user IDs.
+*/
+type ContextEnricher func(context.Context) context.Context
+
+/*
+FileSystem wrapper which passes all contexts through an enricher.
+*/
+type contextualFileSystem struct {
+	inner    FileSystem
+	enricher ContextEnricher
+}
+
+/*
+NewContextualFileSystem wraps inner into a FileSystem which calls enricher
+on the context of every operation before passing it on to inner. This
+ensures that standard attributes, such as request tags (see RequestTag) or
+values extracted from credentials in the outer context, are attached to
+every operation without callers having to remember them.
+
+The wrapper only supports the FileSystem methods.
+*/
+func NewContextualFileSystem(inner FileSystem, enricher ContextEnricher) FileSystem {
+	return &contextualFileSystem{inner: inner, enricher: enricher}
+}
+
+/*
+OpenReader opens the file for reading using the enriched context.
+*/
+func (c *contextualFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	return c.inner.OpenReader(c.enricher(ctx), u)
+}
+
+/*
+OpenWriter opens the file for writing using the enriched context.
+*/
+func (c *contextualFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return c.inner.OpenWriter(c.enricher(ctx), u)
+}
+
+/*
+OpenAppender opens the file for appending using the enriched context.
+*/
+func (c *contextualFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return c.inner.OpenAppender(c.enricher(ctx), u)
+}
+
+/*
+ListEntries lists the directory using the enriched context.
+*/
+func (c *contextualFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	return c.inner.ListEntries(c.enricher(ctx), u)
+}
+
+/*
+WatchFile watches the file using the enriched context.
+*/
+func (c *contextualFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	return c.inner.WatchFile(c.enricher(ctx), u, watcher)
+}
+
+/*
+Remove deletes the file using the enriched context.
+*/
+func (c *contextualFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	return c.inner.Remove(c.enricher(ctx), u)
+}
+
+/*
+Stat retrieves the metadata of the file using the enriched context.
+*/
+func (c *contextualFileSystem) Stat(ctx context.Context, u *url.URL) (
+	FileInfo, error) {
+	return c.inner.Stat(c.enricher(ctx), u)
+}
@@ -0,0 +1,33 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+type TagRecordingFileSystem struct {
+	MockFileSystem
+	Tags map[string]string
+}
+
+func (fs *TagRecordingFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	fs.Tags = TagsFromContext(ctx)
+	return fs.MockFileSystem.OpenReader(ctx, u)
+}
+
+func TestContextualFileSystem(t *testing.T) {
+	inner := &TagRecordingFileSystem{}
+	AddImplementation("contextual", NewContextualFileSystem(inner,
+		func(ctx context.Context) context.Context {
+			return RequestTag(ctx, map[string]string{"team": "storage"})
+		}))
+
+	if _, err := OpenReader(context.Background(), mustParse(t, "contextual:///file")); err != nil {
+		t.Fatalf("Error reported from OpenReader: %v", err)
+	}
+	if inner.Tags["team"] != "storage" {
+		t.Errorf("Unexpected tags %v", inner.Tags)
+	}
+}
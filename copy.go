@@ -0,0 +1,313 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"net/url"
+)
+
+/*
+Size of the buffer used for copying data between files.
+*/
+const copyBufferSize = 32 * 1024
+
+/*
+ProgressCopyingFileSystem is implemented by file systems which are able to
+copy files on the server side while reporting the progress of the operation,
+e.g. by polling the size of the destination file in a goroutine.
+*/
+type ProgressCopyingFileSystem interface {
+	// Copy the contents of the first URL to the second one, calling the
+	// ProgressFunc as the copy progresses. Returns the number of bytes
+	// copied.
+	CopyWithProgress(context.Context, *url.URL, *url.URL, ProgressFunc) (int64, error)
+}
+
+/*
+copyContents copies everything from src to dst until src reports EOF.
+Neither src nor dst are closed.
+*/
+func copyContents(ctx context.Context, dst WriteCloser, src ReadCloser) (int64, error) {
+	var buf = make([]byte, copyBufferSize)
+	var written int64
+
+	for {
+		var nr, nw int
+		var rerr, werr error
+
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		nr, rerr = src.Read(ctx, buf)
+		if nr > 0 {
+			nw, werr = dst.Write(ctx, buf[0:nr])
+			written += int64(nw)
+			if werr != nil {
+				return written, werr
+			}
+			if nw != nr {
+				return written, io.ErrShortWrite
+			}
+		}
+		if rerr == io.EOF {
+			return written, nil
+		}
+		if rerr != nil {
+			return written, rerr
+		}
+	}
+}
+
+/*
+CopyingFileSystem is implemented by file systems which can copy files on
+the server side without streaming the data through the client.
+*/
+type CopyingFileSystem interface {
+	// Copy the contents of the first URL to the second one. Returns the
+	// number of bytes copied.
+	Copy(context.Context, *url.URL, *url.URL) (int64, error)
+}
+
+/*
+streamCopy copies the file at src to dst by reading it and writing the
+data to dst. If rcWrapper is not nil, it is used to wrap the reader, and
+the wrapped reader is closed once the copy is done.
+*/
+func streamCopy(ctx context.Context, srcfs, dstfs FileSystem, src, dst *url.URL,
+	rcWrapper func(ReadCloser) ReadCloser) (int64, error) {
+	var rc ReadCloser
+	var wc WriteCloser
+	var n int64
+	var err error
+
+	if rc, err = srcfs.OpenReader(ctx, src); err != nil {
+		return 0, err
+	}
+
+	if rcWrapper != nil {
+		rc = rcWrapper(rc)
+	}
+	defer rc.Close(ctx)
+
+	if wc, err = dstfs.OpenWriter(ctx, dst); err != nil {
+		return 0, err
+	}
+
+	if n, err = copyContents(ctx, wc, rc); err != nil {
+		wc.Close(ctx)
+		return n, err
+	}
+
+	return n, wc.Close(ctx)
+}
+
+/*
+CopyFile copies the file at src to dst, which may belong to different file
+systems. Returns the number of bytes copied.
+
+If both URLs are handled by the same file system and it implements
+CopyingFileSystem, the copy is performed on the server side. Otherwise the
+contents are streamed from src to dst.
+*/
+func CopyFile(ctx context.Context, src, dst *url.URL) (int64, error) {
+	var srcfs = GetImplementation(src)
+	var dstfs = GetImplementation(dst)
+	var cancel context.CancelFunc
+
+	if srcfs == nil || dstfs == nil {
+		return 0, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if src.Scheme == dst.Scheme {
+		if cfs, ok := srcfs.(CopyingFileSystem); ok {
+			return cfs.Copy(ctx, src, dst)
+		}
+	}
+
+	return streamCopy(ctx, srcfs, dstfs, src, dst, nil)
+}
+
+/*
+CopyWithProgress copies the file at src to dst and reports the progress of
+the operation to fn. Returns the number of bytes copied.
+
+If both URLs are handled by the same file system and it implements
+ProgressCopyingFileSystem, the copy is performed on the server side.
+Otherwise the contents are streamed from src to dst, and the total passed to
+fn is the size of src as reported by Stat, or -1 if it cannot be determined.
+*/
+func CopyWithProgress(ctx context.Context, src, dst *url.URL, fn ProgressFunc) (
+	int64, error) {
+	var srcfs = GetImplementation(src)
+	var dstfs = GetImplementation(dst)
+	var total int64 = -1
+	var cancel context.CancelFunc
+
+	if srcfs == nil || dstfs == nil {
+		return 0, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if src.Scheme == dst.Scheme {
+		if pcfs, ok := srcfs.(ProgressCopyingFileSystem); ok {
+			return pcfs.CopyWithProgress(ctx, src, dst, fn)
+		}
+	}
+
+	if fi, err := srcfs.Stat(ctx, src); err == nil {
+		total = fi.Size()
+	}
+
+	return streamCopy(ctx, srcfs, dstfs, src, dst,
+		func(rc ReadCloser) ReadCloser {
+			return &ProgressReadCloser{R: rc, Total: total, Fn: fn}
+		})
+}
+
+/*
+CopyOptions controls which attributes of the source file CopyWithOptions
+carries over to the copy. By default, only the contents are copied.
+*/
+type CopyOptions struct {
+	// Copy the custom metadata of the source file.
+	PreserveMetadata bool
+
+	// Copy the tags of the source file.
+	PreserveTags bool
+
+	// Copy the access control list of the source file.
+	PreserveACL bool
+
+	// Store the copy in the same storage class as the source file.
+	PreserveStorageClass bool
+
+	// If set, the content type of the copy, replacing that of the source.
+	NewContentType string
+}
+
+/*
+ContentTypeMetadataKey is the metadata key under which CopyWithOptions
+stores NewContentType when the copy is not performed by the file system.
+*/
+const ContentTypeMetadataKey = "Content-Type"
+
+/*
+OptionsCopyingFileSystem is implemented by file systems which can copy files
+on the server side while preserving their attributes, e.g. using the
+metadata directives of the S3 CopyObject call.
+*/
+type OptionsCopyingFileSystem interface {
+	CopyWithOptions(context.Context, *url.URL, *url.URL, CopyOptions) error
+}
+
+/*
+CopyWithOptions copies the file at src to dst like CopyFile, and carries
+over the attributes of the source selected in opts.
+
+If both URLs are handled by the same file system and it implements
+OptionsCopyingFileSystem, the copy is performed on the server side.
+Otherwise each selected attribute is read from src before the contents are
+streamed, and set on dst afterwards. If either file system does not support
+one of them, EUNSUPP is returned before dst is touched. NewContentType is
+stored as metadata under ContentTypeMetadataKey.
+*/
+func CopyWithOptions(ctx context.Context, src, dst *url.URL, opts CopyOptions) error {
+	var srcfs = GetImplementation(src)
+	var dstfs = GetImplementation(dst)
+	var metadata map[string]string
+	var tags map[string]string
+	var acl ACL
+	var class string
+	var cancel context.CancelFunc
+	var err error
+
+	if srcfs == nil || dstfs == nil {
+		return ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if src.Scheme == dst.Scheme {
+		if ocfs, ok := srcfs.(OptionsCopyingFileSystem); ok {
+			return ocfs.CopyWithOptions(ctx, src, dst, opts)
+		}
+	}
+
+	if opts.PreserveMetadata {
+		if metadata, err = GetMetadata(ctx, src); err != nil {
+			return err
+		}
+	}
+	if opts.NewContentType != "" {
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[ContentTypeMetadataKey] = opts.NewContentType
+	}
+	if len(metadata) > 0 {
+		if _, err = getMetadataFileSystem(dst); err != nil {
+			return err
+		}
+	}
+
+	if opts.PreserveTags {
+		if tags, err = GetTags(ctx, src); err != nil {
+			return err
+		}
+		if _, err = getTaggingFileSystem(dst); err != nil {
+			return err
+		}
+	}
+
+	if opts.PreserveACL {
+		if acl, err = GetACL(ctx, src); err != nil {
+			return err
+		}
+		if _, err = getACLFileSystem(dst); err != nil {
+			return err
+		}
+	}
+
+	if opts.PreserveStorageClass {
+		if class, err = GetStorageClass(ctx, src); err != nil {
+			return err
+		}
+		if _, err = getStorageClassFileSystem(dst); err != nil {
+			return err
+		}
+	}
+
+	if _, err = streamCopy(ctx, srcfs, dstfs, src, dst, nil); err != nil {
+		return err
+	}
+
+	if len(metadata) > 0 {
+		if err = SetMetadata(ctx, dst, metadata); err != nil {
+			return err
+		}
+	}
+	if opts.PreserveTags {
+		if err = Tag(ctx, dst, tags); err != nil {
+			return err
+		}
+	}
+	if opts.PreserveACL {
+		if err = SetACL(ctx, dst, acl); err != nil {
+			return err
+		}
+	}
+	if opts.PreserveStorageClass {
+		if err = SetStorageClass(ctx, dst, class); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestCopyWithOptions(t *testing.T) {
+	filesystem.AddImplementation("copyopts", virtualfs.NewVirtualFileSystem())
+	ctx := context.Background()
+	src := &url.URL{Scheme: "copyopts", Path: "/src"}
+	writeTestFile(t, src, "contents")
+	filesystem.SetMetadata(ctx, src, map[string]string{"origin": "test"})
+	filesystem.Tag(ctx, src, map[string]string{"owner": "tenant-x"})
+
+	plain := &url.URL{Scheme: "copyopts", Path: "/plain"}
+	if err := filesystem.CopyWithOptions(ctx, src, plain,
+		filesystem.CopyOptions{}); err != nil {
+		t.Fatalf("Error reported from CopyWithOptions: %v", err)
+	}
+	if md, _ := filesystem.GetMetadata(ctx, plain); len(md) != 0 {
+		t.Errorf("Metadata was copied without PreserveMetadata: %v", md)
+	}
+
+	dst := &url.URL{Scheme: "copyopts", Path: "/dst"}
+	if err := filesystem.CopyWithOptions(ctx, src, dst, filesystem.CopyOptions{
+		PreserveMetadata: true,
+		PreserveTags:     true,
+		NewContentType:   "text/plain",
+	}); err != nil {
+		t.Fatalf("Error reported from CopyWithOptions: %v", err)
+	}
+	if data, _ := readTestFile(t, dst); data != "contents" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+	md, _ := filesystem.GetMetadata(ctx, dst)
+	if md["origin"] != "test" || md[filesystem.ContentTypeMetadataKey] != "text/plain" {
+		t.Errorf("Unexpected metadata %v", md)
+	}
+	if tags, _ := filesystem.GetTags(ctx, dst); tags["owner"] != "tenant-x" {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+
+	existing := &url.URL{Scheme: "copyopts", Path: "/existing"}
+	writeTestFile(t, existing, "existing")
+	if err := filesystem.CopyWithOptions(ctx, src, existing,
+		filesystem.CopyOptions{PreserveACL: true}); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP for PreserveACL, got %v", err)
+	}
+	if data, _ := readTestFile(t, existing); data != "existing" {
+		t.Errorf("Destination overwritten despite unsupported option: %q", data)
+	}
+}
+
+func TestCopyWithProgress(t *testing.T) {
+	filesystem.AddImplementation("copyprogress", virtualfs.NewVirtualFileSystem())
+	src := &url.URL{Scheme: "copyprogress", Path: "/src"}
+	dst := &url.URL{Scheme: "copyprogress", Path: "/dst"}
+	var progress, total int64
+
+	writeTestFile(t, src, "hello world")
+
+	n, err := filesystem.CopyWithProgress(context.Background(), src, dst,
+		func(copied, size int64) { progress, total = copied, size })
+	if err != nil {
+		t.Fatalf("Error reported from CopyWithProgress: %v", err)
+	}
+	if n != 11 || progress != 11 || total != 11 {
+		t.Errorf("Unexpected byte counts %d, %d of %d", n, progress, total)
+	}
+	if data, _ := readTestFile(t, dst); data != "hello world" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+}
@@ -0,0 +1,146 @@
+package filesystem
+
+import (
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/url"
+	"path"
+	"sync"
+)
+
+/*
+DecompressorFactory wraps a ReadCloser for compressed data into one which
+returns the decompressed data. Closing the returned ReadCloser must close
+the wrapped one.
+*/
+type DecompressorFactory func(ReadCloser) (ReadCloser, error)
+
+/*
+Content encodings implied by file name extensions.
+*/
+var extensionEncodings = map[string]string{
+	".gz":  "gzip",
+	".bz2": "bzip2",
+	".zst": "zstd",
+	".xz":  "xz",
+	".zz":  "deflate",
+}
+
+/*
+Registered decompressors, by content encoding.
+*/
+var (
+	decompressorsLock sync.RWMutex
+	decompressors     = map[string]DecompressorFactory{
+		"gzip": readerDecompressor(func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		}),
+		"bzip2": readerDecompressor(func(r io.Reader) (io.Reader, error) {
+			return bzip2.NewReader(r), nil
+		}),
+		"deflate": readerDecompressor(func(r io.Reader) (io.Reader, error) {
+			return zlib.NewReader(r)
+		}),
+	}
+)
+
+/*
+readerDecompressor creates a DecompressorFactory from a function creating a
+decompressing io.Reader, such as gzip.NewReader.
+*/
+func readerDecompressor(newDecoder func(io.Reader) (io.Reader, error)) DecompressorFactory {
+	return func(rc ReadCloser) (ReadCloser, error) {
+		return &decodingReadCloser{
+			src: &contextReader{r: rc}, newDecoder: newDecoder}, nil
+	}
+}
+
+/*
+RegisterDecompressor makes the decompressor available for the content
+encoding, e.g. "zstd" or "xz", which are not supported by the standard
+library. gzip, bzip2 and deflate (zlib) are registered by default. Any
+previously registered decompressor for the encoding is replaced.
+*/
+func RegisterDecompressor(encoding string, factory DecompressorFactory) {
+	decompressorsLock.Lock()
+	defer decompressorsLock.Unlock()
+
+	decompressors[encoding] = factory
+}
+
+/*
+getDecompressor returns the decompressor registered for the content
+encoding, or nil if there is none.
+*/
+func getDecompressor(encoding string) DecompressorFactory {
+	decompressorsLock.RLock()
+	defer decompressorsLock.RUnlock()
+
+	return decompressors[encoding]
+}
+
+/*
+decompress wraps rc using factory, closing rc if that fails.
+*/
+func decompress(ctx context.Context, factory DecompressorFactory, rc ReadCloser) (
+	ReadCloser, error) {
+	var drc, err = factory(rc)
+
+	if err != nil {
+		rc.Close(ctx)
+		return nil, err
+	}
+	return drc, nil
+}
+
+/*
+OpenReaderWithDecompression opens the referenced file for reading and
+decompresses its contents. The encoding is detected from the extension of
+the file name (e.g. ".gz" or ".bz2") and, failing that, from the content
+encoding stored for the file (see GetContentEncoding).
+
+Returns the reader along with the encoding which was decompressed. If no
+encoding was detected or no decompressor is registered for it (see
+RegisterDecompressor), the contents are returned unchanged and the
+encoding is empty.
+*/
+func OpenReaderWithDecompression(ctx context.Context, fileurl *url.URL) (
+	ReadCloser, string, error) {
+	var fs = GetImplementation(fileurl)
+	var encoding = extensionEncodings[path.Ext(fileurl.Path)]
+	var factory DecompressorFactory
+	var rc ReadCloser
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return nil, "", ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if encoding == "" {
+		if cfs, ok := fs.(ContentEncodingFileSystem); ok {
+			if encoding, err = cfs.GetContentEncoding(ctx, fileurl); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	if rc, err = fs.OpenReader(ctx, fileurl); err != nil {
+		return nil, "", err
+	}
+
+	if factory = getDecompressor(encoding); factory == nil {
+		return rc, "", nil
+	}
+
+	if rc, err = decompress(ctx, factory, rc); err != nil {
+		return nil, "", err
+	}
+	return rc, encoding, nil
+}
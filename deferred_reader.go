@@ -0,0 +1,59 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+DeferredReadCloser is a ReadCloser which opens the underlying file only when
+it is first read from. Create one using NewDeferredReadCloser.
+*/
+type DeferredReadCloser struct {
+	ctx     context.Context
+	fileurl *url.URL
+	r       ReadCloser
+	err     error
+}
+
+/*
+NewDeferredReadCloser creates a ReadCloser for the referenced file which
+calls OpenReader only when Read is first invoked. This avoids opening files
+for pipelines which may be discarded before consuming any data.
+
+ctx controls the opening of the file like the context passed to OpenReader,
+so its deadline applies to the deferred open. The contexts passed to Read
+and Close control the respective operations as usual.
+*/
+func NewDeferredReadCloser(ctx context.Context, fileurl *url.URL) ReadCloser {
+	return &DeferredReadCloser{ctx: ctx, fileurl: fileurl}
+}
+
+/*
+Read opens the file if this has not happened yet and reads from it. If
+opening the file fails, the error is returned from all subsequent reads.
+*/
+func (d *DeferredReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if d.r == nil && d.err == nil {
+		d.r, d.err = OpenReader(d.ctx, d.fileurl)
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+
+	return d.r.Read(ctx, p)
+}
+
+/*
+Close closes the file if it was opened, and does nothing otherwise.
+*/
+func (d *DeferredReadCloser) Close(ctx context.Context) error {
+	if d.r == nil {
+		return nil
+	}
+	return d.r.Close(ctx)
+}
@@ -0,0 +1,53 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+type CountingFileSystem struct {
+	MockFileSystem
+	Opened int
+}
+
+func (fs *CountingFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fs.Opened++
+	return fs.MockFileSystem.OpenReader(ctx, u)
+}
+
+func TestDeferredReadCloser(t *testing.T) {
+	fs := &CountingFileSystem{}
+	AddImplementation("deferred", fs)
+	u := mustParse(t, "deferred:///file")
+
+	r := NewDeferredReadCloser(context.Background(), u)
+	if err := r.Close(context.Background()); err != nil {
+		t.Errorf("Error reported from Close before Read: %v", err)
+	}
+	if fs.Opened != 0 {
+		t.Error("File opened without reading")
+	}
+
+	r = NewDeferredReadCloser(context.Background(), u)
+	for i := 0; i < 2; i++ {
+		if _, err := r.Read(context.Background(), make([]byte, 10)); err != nil {
+			t.Errorf("Error reported from Read: %v", err)
+		}
+	}
+	r.Close(context.Background())
+	if fs.Opened != 1 {
+		t.Errorf("File opened %d times, expected once", fs.Opened)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = NewDeferredReadCloser(ctx, u)
+	if _, err := r.Read(context.Background(), make([]byte, 10)); err == nil {
+		t.Error("Expected error when opening with expired context")
+	}
+}
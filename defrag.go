@@ -0,0 +1,53 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+/*
+DefragResult describes the work done by Defrag.
+*/
+type DefragResult struct {
+	// Number of bytes relocated.
+	BytesMoved int64
+
+	// Number of fragments merged with others.
+	FragmentsConsolidated int64
+
+	// Time spent on defragmentation.
+	Duration time.Duration
+}
+
+/*
+DefraggingFileSystem is implemented by file systems which can reorganize
+the storage of files to reclaim fragmented space, such as custom blob
+stores or local file systems on spinning disks.
+*/
+type DefraggingFileSystem interface {
+	// Defragment the file, or all files beneath the directory.
+	Defrag(context.Context, *url.URL) (DefragResult, error)
+}
+
+/*
+Defrag defragments the referenced file or all files beneath the referenced
+directory. This is a pure maintenance operation, so file systems which do
+not implement DefraggingFileSystem simply report that nothing was done.
+
+As defragmenting a large directory can take very long, the default timeout
+is not applied.
+*/
+func Defrag(ctx context.Context, fileurl *url.URL) (DefragResult, error) {
+	var fs = GetImplementation(fileurl)
+
+	if fs == nil {
+		return DefragResult{}, ENOFS
+	}
+
+	if dfs, ok := fs.(DefraggingFileSystem); ok {
+		return dfs.Defrag(ctx, fileurl)
+	}
+
+	return DefragResult{}, nil
+}
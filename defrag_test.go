@@ -0,0 +1,60 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+/*
+defraggingFileSystem pretends to relocate every file it defragments.
+*/
+type defraggingFileSystem struct {
+	*virtualfs.VirtualFileSystem
+}
+
+func (d defraggingFileSystem) Defrag(ctx context.Context, u *url.URL) (
+	filesystem.DefragResult, error) {
+	fi, err := d.Stat(ctx, u)
+	if err != nil {
+		return filesystem.DefragResult{}, err
+	}
+	return filesystem.DefragResult{BytesMoved: fi.Size(), FragmentsConsolidated: 1}, nil
+}
+
+func TestDefrag(t *testing.T) {
+	filesystem.AddImplementation("defragplain", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("defrag", defraggingFileSystem{
+		virtualfs.NewVirtualFileSystem()})
+	plain := &url.URL{Scheme: "defragplain", Path: "/file"}
+	u := &url.URL{Scheme: "defrag", Path: "/file"}
+	ctx := context.Background()
+
+	if _, err := filesystem.Defrag(ctx, &url.URL{Scheme: "defragunregistered",
+		Path: "/"}); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+
+	writeTestFile(t, plain, "fragmented")
+	if result, err := filesystem.Defrag(ctx, plain); err != nil ||
+		result != (filesystem.DefragResult{}) {
+		t.Errorf("Unexpected result %+v (%v) without support", result, err)
+	}
+	if data, _ := readTestFile(t, plain); data != "fragmented" {
+		t.Errorf("Unexpected contents %q after Defrag", data)
+	}
+
+	writeTestFile(t, u, "fragmented")
+	if result, err := filesystem.Defrag(ctx, u); err != nil {
+		t.Errorf("Error reported from Defrag: %v", err)
+	} else if result.BytesMoved != 10 || result.FragmentsConsolidated != 1 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if _, err := filesystem.Defrag(ctx, &url.URL{Scheme: "defrag",
+		Path: "/missing"}); err == nil {
+		t.Error("Expected error defragmenting missing file")
+	}
+}
@@ -0,0 +1,243 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	iofs "io/fs"
+	"math"
+	"net/url"
+	"path"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Delete throughput assumed by EstimateDeleteTime for file systems which do
+not report their own, in objects per second, stored as float64 bits.
+*/
+var defaultDeleteThroughput atomic.Uint64
+
+func init() {
+	SetDefaultDeleteThroughput(100)
+}
+
+/*
+SetDefaultDeleteThroughput sets the number of objects per second assumed by
+EstimateDeleteTime for file systems which do not implement
+DeleteThroughputFileSystem. The default is 100.
+*/
+func SetDefaultDeleteThroughput(objectsPerSecond float64) {
+	defaultDeleteThroughput.Store(math.Float64bits(objectsPerSecond))
+}
+
+/*
+DeleteThroughputFileSystem is implemented by file systems which know how
+many objects per second they can delete.
+*/
+type DeleteThroughputFileSystem interface {
+	// Number of objects which can be deleted per second.
+	DeleteThroughput() float64
+}
+
+/*
+EstimateDeleteTime estimates how long deleting everything beneath root
+would take, based on ObjectCount and the delete throughput of the file
+system (see SetDefaultDeleteThroughput).
+*/
+func EstimateDeleteTime(ctx context.Context, root *url.URL) (time.Duration, error) {
+	var fs = GetImplementation(root)
+	var throughput = math.Float64frombits(defaultDeleteThroughput.Load())
+	var count int64
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return 0, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if dfs, ok := fs.(DeleteThroughputFileSystem); ok {
+		throughput = dfs.DeleteThroughput()
+	}
+
+	if count, err = ObjectCount(ctx, root); err != nil {
+		return 0, err
+	}
+
+	if throughput <= 0 {
+		return time.Duration(math.MaxInt64), nil
+	}
+	return time.Duration(float64(count) / throughput * float64(time.Second)), nil
+}
+
+/*
+JobStatus describes the progress of an asynchronous job.
+*/
+type JobStatus struct {
+	// Number of objects the job has to process, if known.
+	ObjectsTotal int64
+
+	// Number of objects processed so far.
+	ObjectsDone int64
+
+	// Whether the job has finished, successfully or not.
+	Done bool
+
+	// Error which made the job fail, if any.
+	Err error
+}
+
+/*
+JobHandle allows following an asynchronous job, such as one started by
+DeleteAsync.
+*/
+type JobHandle interface {
+	// Block until the job has finished or the context is done, and return
+	// the error which made the job fail, if any.
+	Wait(context.Context) error
+
+	// Report the progress of the job.
+	Status(context.Context) (JobStatus, error)
+}
+
+/*
+AsyncDeletingFileSystem is implemented by file systems which can delete
+directory trees in the background, e.g. using batch operations or
+lifecycle rules.
+*/
+type AsyncDeletingFileSystem interface {
+	// Start deleting everything beneath the URL.
+	DeleteAsync(context.Context, *url.URL) (JobHandle, error)
+}
+
+/*
+JobHandle for a job running in a goroutine of this process.
+*/
+type localJob struct {
+	lock   sync.Mutex
+	status JobStatus
+	done   chan struct{}
+}
+
+/*
+Wait blocks until the job has finished or ctx is done.
+*/
+func (j *localJob) Wait(ctx context.Context) error {
+	select {
+	case <-j.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.status.Err
+}
+
+/*
+Status reports the progress of the job.
+*/
+func (j *localJob) Status(ctx context.Context) (JobStatus, error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.status, nil
+}
+
+/*
+advance records that an object has been processed.
+*/
+func (j *localJob) advance() {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.status.ObjectsDone++
+}
+
+/*
+finish marks the job as done.
+*/
+func (j *localJob) finish(err error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.status.Done = true
+	j.status.Err = err
+	close(j.done)
+}
+
+/*
+deleteTree removes all files, then all directories beneath root, deepest
+first. Directories which do not exist as objects of their own are skipped.
+*/
+func deleteTree(ctx context.Context, fs FileSystem, root *url.URL, paths []string,
+	job *localJob) error {
+	var dirs = make(map[string]bool)
+	var sorted []string
+
+	for _, p := range paths {
+		if err := fs.Remove(ctx, childURL(root, p)); err != nil &&
+			!errors.Is(err, iofs.ErrNotExist) {
+			return err
+		}
+		job.advance()
+
+		for d := path.Dir(p); d != "." && d != "/"; d = path.Dir(d) {
+			dirs[d] = true
+		}
+	}
+
+	for d := range dirs {
+		sorted = append(sorted, d)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	for _, d := range sorted {
+		if err := fs.Remove(ctx, childURL(root, d)); err != nil &&
+			!errors.Is(err, iofs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+DeleteAsync starts deleting everything beneath root in the background and
+returns a handle for following its progress. Cancelling ctx aborts the
+deletion. As the deletion continues in the background after DeleteAsync
+returns, the default timeout is not applied.
+
+File systems not implementing AsyncDeletingFileSystem are listed using
+ListEntriesRecursive before DeleteAsync returns, and the files are then
+removed one at a time, followed by the (now empty) directories.
+*/
+func DeleteAsync(ctx context.Context, root *url.URL) (JobHandle, error) {
+	var fs = GetImplementation(root)
+	var job *localJob
+	var paths []string
+	var err error
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if afs, ok := fs.(AsyncDeletingFileSystem); ok {
+		return afs.DeleteAsync(ctx, root)
+	}
+
+	if paths, err = ListEntriesRecursive(ctx, root); err != nil {
+		return nil, err
+	}
+
+	job = &localJob{
+		status: JobStatus{ObjectsTotal: int64(len(paths))},
+		done:   make(chan struct{}),
+	}
+	go func() {
+		job.finish(deleteTree(ctx, fs, root, paths, job))
+	}()
+
+	return job, nil
+}
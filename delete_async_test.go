@@ -0,0 +1,45 @@
+package filesystem_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestDeleteAsync(t *testing.T) {
+	filesystem.AddImplementation("deleteasync", virtualfs.NewVirtualFileSystem())
+	root := &url.URL{Scheme: "deleteasync", Path: "/dataset"}
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		writeTestFile(t, &url.URL{Scheme: "deleteasync",
+			Path: fmt.Sprintf("/dataset/part-%d/data", i)}, "x")
+	}
+
+	filesystem.SetDefaultDeleteThroughput(5)
+	defer filesystem.SetDefaultDeleteThroughput(100)
+	if d, err := filesystem.EstimateDeleteTime(ctx, root); err != nil || d != 2*time.Second {
+		t.Errorf("Unexpected estimate %v (%v)", d, err)
+	}
+
+	job, err := filesystem.DeleteAsync(ctx, root)
+	if err != nil {
+		t.Fatalf("Error reported from DeleteAsync: %v", err)
+	}
+	if err = job.Wait(ctx); err != nil {
+		t.Errorf("Error reported from Wait: %v", err)
+	}
+
+	status, _ := job.Status(ctx)
+	if !status.Done || status.ObjectsTotal != 10 || status.ObjectsDone != 10 {
+		t.Errorf("Unexpected status %+v", status)
+	}
+	if count, _ := filesystem.ObjectCount(ctx, root); count != 0 {
+		t.Errorf("%d objects left after deletion", count)
+	}
+}
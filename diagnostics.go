@@ -0,0 +1,422 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+ErrReplayMismatch is returned by ReplayFileSystem if the result of a
+replayed operation differs from the recorded one.
+*/
+var ErrReplayMismatch = errors.New("Replayed operation result differs from recording")
+
+/*
+DiagnosticsRecord describes a single operation recorded by the file system
+returned from NewDiagnosticsFileSystem. Records are written to the log as
+newline delimited JSON.
+*/
+type DiagnosticsRecord struct {
+	// Name of the operation, e.g. "OpenReader" or "Write".
+	Op string `json:"op"`
+
+	// URL the operation was invoked on.
+	URL string `json:"url"`
+
+	// Identifier of the reader or writer for Read, Write and Close
+	// operations, and of the reader or writer opened by Open* operations.
+	Handle int64 `json:"handle,omitempty"`
+
+	// Time at which the operation was started and how long it took.
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+
+	// Data returned by Read or passed to Write.
+	Data []byte `json:"data,omitempty"`
+
+	// Entries returned by ListEntries.
+	Entries []string `json:"entries,omitempty"`
+
+	// Error returned by the operation, if any.
+	Error string `json:"error,omitempty"`
+
+	// Trace and span the operation was invoked in, as embedded into its
+	// context using WithSpanContext.
+	TraceID string `json:"trace_id,omitempty"`
+	SpanID  string `json:"span_id,omitempty"`
+}
+
+/*
+FileSystem wrapper which records all operations to a log.
+*/
+type diagnosticsFileSystem struct {
+	inner   FileSystem
+	lock    sync.Mutex
+	encoder *json.Encoder
+	handles atomic.Int64
+}
+
+/*
+NewDiagnosticsFileSystem wraps inner into a FileSystem which serializes every
+operation, including reads and writes on opened files, as a
+DiagnosticsRecord to log. The log can be used to reproduce issues with
+ReplayFileSystem.
+
+Since the data read and written is recorded as well, the log may become
+large and contain sensitive information.
+*/
+func NewDiagnosticsFileSystem(inner FileSystem, log io.Writer) FileSystem {
+	return &diagnosticsFileSystem{inner: inner, encoder: json.NewEncoder(log)}
+}
+
+/*
+record writes a record for an operation started at the specified time,
+along with the trace span the operation was invoked in, if any.
+*/
+func (d *diagnosticsFileSystem) record(ctx context.Context,
+	rec *DiagnosticsRecord, start time.Time, err error) {
+	var spanCtx = SpanContextFromContext(ctx)
+
+	if spanCtx.IsValid() {
+		rec.TraceID = spanCtx.TraceID().String()
+		rec.SpanID = spanCtx.SpanID().String()
+	}
+
+	rec.Start = start
+	rec.Duration = time.Since(start)
+	if err != nil {
+		rec.Error = err.Error()
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	// There is nowhere to report logging errors to.
+	d.encoder.Encode(rec)
+}
+
+/*
+Reader which records all reads to the diagnostics log.
+*/
+type diagnosticsReadCloser struct {
+	d      *diagnosticsFileSystem
+	r      ReadCloser
+	url    string
+	handle int64
+}
+
+/*
+Read reads from the underlying reader and records the data read.
+*/
+func (r *diagnosticsReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	var start = time.Now()
+	var n, err = r.r.Read(ctx, p)
+
+	r.d.record(ctx, &DiagnosticsRecord{
+		Op: "Read", URL: r.url, Handle: r.handle,
+		Data: slices.Clone(p[0:n]),
+	}, start, err)
+	return n, err
+}
+
+/*
+Close closes the underlying reader and records the result.
+*/
+func (r *diagnosticsReadCloser) Close(ctx context.Context) error {
+	var start = time.Now()
+	var err = r.r.Close(ctx)
+
+	r.d.record(ctx, &DiagnosticsRecord{
+		Op: "Close", URL: r.url, Handle: r.handle}, start, err)
+	return err
+}
+
+/*
+Writer which records all writes to the diagnostics log.
+*/
+type diagnosticsWriteCloser struct {
+	d      *diagnosticsFileSystem
+	w      WriteCloser
+	url    string
+	handle int64
+}
+
+/*
+Write writes to the underlying writer and records the data written.
+*/
+func (w *diagnosticsWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	var start = time.Now()
+	var n, err = w.w.Write(ctx, p)
+
+	w.d.record(ctx, &DiagnosticsRecord{
+		Op: "Write", URL: w.url, Handle: w.handle,
+		Data: slices.Clone(p[0:n]),
+	}, start, err)
+	return n, err
+}
+
+/*
+Close closes the underlying writer and records the result.
+*/
+func (w *diagnosticsWriteCloser) Close(ctx context.Context) error {
+	var start = time.Now()
+	var err = w.w.Close(ctx)
+
+	w.d.record(ctx, &DiagnosticsRecord{
+		Op: "Close", URL: w.url, Handle: w.handle}, start, err)
+	return err
+}
+
+/*
+OpenReader records the opening of a reader and wraps it for recording.
+*/
+func (d *diagnosticsFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	var start = time.Now()
+	var rec = &DiagnosticsRecord{Op: "OpenReader", URL: u.String()}
+	var r, err = d.inner.OpenReader(ctx, u)
+
+	if err != nil {
+		d.record(ctx, rec, start, err)
+		return nil, err
+	}
+
+	rec.Handle = d.handles.Add(1)
+	d.record(ctx, rec, start, nil)
+	return &diagnosticsReadCloser{
+		d: d, r: r, url: rec.URL, handle: rec.Handle}, nil
+}
+
+/*
+openWriter records the opening of a writer using the specified function.
+*/
+func (d *diagnosticsFileSystem) openWriter(ctx context.Context, op string,
+	u *url.URL, open func(context.Context, *url.URL) (WriteCloser, error)) (
+	WriteCloser, error) {
+	var start = time.Now()
+	var rec = &DiagnosticsRecord{Op: op, URL: u.String()}
+	var w, err = open(ctx, u)
+
+	if err != nil {
+		d.record(ctx, rec, start, err)
+		return nil, err
+	}
+
+	rec.Handle = d.handles.Add(1)
+	d.record(ctx, rec, start, nil)
+	return &diagnosticsWriteCloser{
+		d: d, w: w, url: rec.URL, handle: rec.Handle}, nil
+}
+
+/*
+OpenWriter records the opening of a writer and wraps it for recording.
+*/
+func (d *diagnosticsFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return d.openWriter(ctx, "OpenWriter", u, d.inner.OpenWriter)
+}
+
+/*
+OpenAppender records the opening of an appender and wraps it for
+recording.
+*/
+func (d *diagnosticsFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return d.openWriter(ctx, "OpenAppender", u, d.inner.OpenAppender)
+}
+
+/*
+ListEntries records the listing along with the entries returned.
+*/
+func (d *diagnosticsFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	var start = time.Now()
+	var entries, err = d.inner.ListEntries(ctx, u)
+
+	d.record(ctx, &DiagnosticsRecord{
+		Op: "ListEntries", URL: u.String(), Entries: entries}, start, err)
+	return entries, err
+}
+
+/*
+WatchFile records the registration of the watch. Notifications are not
+recorded.
+*/
+func (d *diagnosticsFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	var start = time.Now()
+	var cancel, errChan, err = d.inner.WatchFile(ctx, u, watcher)
+
+	d.record(ctx, &DiagnosticsRecord{Op: "WatchFile", URL: u.String()}, start, err)
+	return cancel, errChan, err
+}
+
+/*
+Remove records the removal of the file.
+*/
+func (d *diagnosticsFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	var start = time.Now()
+	var err = d.inner.Remove(ctx, u)
+
+	d.record(ctx, &DiagnosticsRecord{Op: "Remove", URL: u.String()}, start, err)
+	return err
+}
+
+/*
+Stat records the retrieval of the file's metadata. The metadata itself is
+not recorded.
+*/
+func (d *diagnosticsFileSystem) Stat(ctx context.Context, u *url.URL) (
+	FileInfo, error) {
+	var start = time.Now()
+	var fi, err = d.inner.Stat(ctx, u)
+
+	d.record(ctx, &DiagnosticsRecord{Op: "Stat", URL: u.String()}, start, err)
+	return fi, err
+}
+
+/*
+Create an error describing a mismatch in a replayed operation.
+*/
+func replayMismatch(rec *DiagnosticsRecord, format string, args ...any) error {
+	return fmt.Errorf("%w: %s %s (handle %d): %s", ErrReplayMismatch,
+		rec.Op, rec.URL, rec.Handle, fmt.Sprintf(format, args...))
+}
+
+/*
+Verify that the error status of a replayed operation matches the recording.
+Only the presence of errors is compared, since error messages frequently
+differ between file system implementations.
+*/
+func checkReplayError(rec *DiagnosticsRecord, err error) error {
+	if (rec.Error == "") != (err == nil) {
+		return replayMismatch(rec, "recorded error %q, got %v", rec.Error, err)
+	}
+	return nil
+}
+
+/*
+ReplayFileSystem reads a log written by a file system created with
+NewDiagnosticsFileSystem and replays all operations against virtual, which
+will usually be a virtualfs.VirtualFileSystem. Every operation is verified
+to succeed or fail like the recorded one, and to return the same data and
+directory entries.
+
+Returns an error wrapping ErrReplayMismatch at the first operation whose
+result differs. WatchFile operations are not replayed.
+*/
+func ReplayFileSystem(log io.Reader, virtual FileSystem) error {
+	var ctx = context.Background()
+	var decoder = json.NewDecoder(log)
+	var readers = make(map[int64]ReadCloser)
+	var writers = make(map[int64]WriteCloser)
+
+	for {
+		var rec DiagnosticsRecord
+		var u *url.URL
+		var err error
+
+		if err = decoder.Decode(&rec); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if u, err = ParseURL(rec.URL); err != nil {
+			return err
+		}
+
+		switch rec.Op {
+		case "OpenReader":
+			var r ReadCloser
+
+			r, err = virtual.OpenReader(ctx, u)
+			if err == nil {
+				readers[rec.Handle] = r
+			}
+		case "OpenWriter", "OpenAppender":
+			var w WriteCloser
+
+			if rec.Op == "OpenWriter" {
+				w, err = virtual.OpenWriter(ctx, u)
+			} else {
+				w, err = virtual.OpenAppender(ctx, u)
+			}
+			if err == nil {
+				writers[rec.Handle] = w
+			}
+		case "ListEntries":
+			var entries []string
+
+			entries, err = virtual.ListEntries(ctx, u)
+			if err == nil && !slices.Equal(entries, rec.Entries) {
+				return replayMismatch(&rec, "recorded entries %v, got %v",
+					rec.Entries, entries)
+			}
+		case "Remove":
+			err = virtual.Remove(ctx, u)
+		case "Stat":
+			_, err = virtual.Stat(ctx, u)
+		case "Read":
+			var r, ok = readers[rec.Handle]
+			var buf = make([]byte, len(rec.Data))
+			var n int
+
+			if !ok {
+				return replayMismatch(&rec, "read on unknown handle")
+			}
+
+			if len(rec.Data) > 0 {
+				// Reads may be split up differently by other file systems,
+				// so read until the recorded amount of data is available.
+				// Errors returned along with data are not compared.
+				n, err = io.ReadFull(ToIoReadCloser(r), buf)
+				if !bytes.Equal(buf[0:n], rec.Data) {
+					return replayMismatch(&rec, "recorded data %q, got %q (%v)",
+						rec.Data, buf[0:n], err)
+				}
+				continue
+			}
+
+			if rec.Error != "" {
+				// Expect the reader to be exhausted as well.
+				buf = make([]byte, 1)
+			}
+			if n, err = r.Read(ctx, buf); n > 0 {
+				return replayMismatch(&rec, "unexpected data %q", buf[0:n])
+			}
+		case "Write":
+			var w, ok = writers[rec.Handle]
+
+			if !ok {
+				return replayMismatch(&rec, "write on unknown handle")
+			}
+			_, err = w.Write(ctx, rec.Data)
+		case "Close":
+			if r, ok := readers[rec.Handle]; ok {
+				err = r.Close(ctx)
+				delete(readers, rec.Handle)
+			} else if w, ok := writers[rec.Handle]; ok {
+				err = w.Close(ctx)
+				delete(writers, rec.Handle)
+			} else {
+				return replayMismatch(&rec, "close on unknown handle")
+			}
+		default:
+			continue
+		}
+
+		if err = checkReplayError(&rec, err); err != nil {
+			return err
+		}
+	}
+}
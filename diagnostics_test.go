@@ -0,0 +1,76 @@
+package filesystem_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func runDiagnosticsScenario(t *testing.T, fs filesystem.FileSystem) {
+	ctx := context.Background()
+	u := &url.URL{Scheme: "memory", Path: "/dir/file"}
+
+	wc, err := fs.OpenWriter(ctx, u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenWriter: %v", err)
+	}
+	wc.Write(ctx, []byte("hello "))
+	wc.Write(ctx, []byte("world"))
+	wc.Close(ctx)
+
+	rc, err := fs.OpenReader(ctx, u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenReader: %v", err)
+	}
+	io.ReadAll(filesystem.ToIoReadCloser(rc))
+	rc.Close(ctx)
+
+	fs.ListEntries(ctx, &url.URL{Scheme: "memory", Path: "/dir"})
+	fs.Remove(ctx, u)
+	fs.Remove(ctx, u)
+}
+
+func TestDiagnosticsReplay(t *testing.T) {
+	var log bytes.Buffer
+
+	fs := filesystem.NewDiagnosticsFileSystem(
+		virtualfs.NewVirtualFileSystem(), &log)
+	runDiagnosticsScenario(t, fs)
+
+	if lines := strings.Count(log.String(), "\n"); lines < 10 {
+		t.Errorf("Expected at least 10 records, got %d", lines)
+	}
+
+	err := filesystem.ReplayFileSystem(bytes.NewReader(log.Bytes()),
+		virtualfs.NewVirtualFileSystem())
+	if err != nil {
+		t.Errorf("Error reported from ReplayFileSystem: %v", err)
+	}
+}
+
+func TestDiagnosticsReplayMismatch(t *testing.T) {
+	var log bytes.Buffer
+
+	fs := filesystem.NewDiagnosticsFileSystem(
+		virtualfs.NewVirtualFileSystem(), &log)
+	runDiagnosticsScenario(t, fs)
+
+	// Replaying against a file system which already contains a file will
+	// change the directory listing.
+	virtual := virtualfs.NewVirtualFileSystem()
+	wc, _ := virtual.OpenWriter(context.Background(),
+		&url.URL{Scheme: "memory", Path: "/dir/other"})
+	wc.Close(context.Background())
+
+	err := filesystem.ReplayFileSystem(bytes.NewReader(log.Bytes()), virtual)
+	if !errors.Is(err, filesystem.ErrReplayMismatch) {
+		t.Errorf("Unexpected error from ReplayFileSystem: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"sync"
+)
+
+/*
+Resolver determines the file system responsible for a URL scheme. It may
+return nil without an error if there is none.
+*/
+type Resolver func(ctx context.Context, scheme string) (FileSystem, error)
+
+/*
+DynamicFileSystem is a FileSystem which determines the implementation to use
+for every single operation, instead of relying on the registry populated
+by AddImplementation. This allows switching backends at runtime, e.g. for
+blue/green deployments or failover. Create one using NewDynamicFileSystem.
+*/
+type DynamicFileSystem struct {
+	lock     sync.RWMutex
+	resolver Resolver
+}
+
+/*
+NewDynamicFileSystem creates a DynamicFileSystem which calls resolver with
+the scheme of the URL on every operation and invokes the operation on the
+file system returned. The resolver may be backed by a service registry or a
+configuration file which is reloaded at runtime.
+*/
+func NewDynamicFileSystem(resolver Resolver) *DynamicFileSystem {
+	return &DynamicFileSystem{resolver: resolver}
+}
+
+/*
+SetResolver replaces the resolver. Operations already in progress are not
+affected.
+*/
+func (d *DynamicFileSystem) SetResolver(resolver Resolver) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.resolver = resolver
+}
+
+/*
+resolve determines the file system responsible for the URL.
+*/
+func (d *DynamicFileSystem) resolve(ctx context.Context, u *url.URL) (
+	FileSystem, error) {
+	var resolver Resolver
+	var fs FileSystem
+	var err error
+
+	d.lock.RLock()
+	resolver = d.resolver
+	d.lock.RUnlock()
+
+	if fs, err = resolver(ctx, u.Scheme); err != nil {
+		return nil, err
+	}
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	return fs, nil
+}
+
+/*
+OpenReader opens the file for reading in the file system currently
+responsible for it.
+*/
+func (d *DynamicFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	var fs, err = d.resolve(ctx, u)
+
+	if err != nil {
+		return nil, err
+	}
+	return fs.OpenReader(ctx, u)
+}
+
+/*
+OpenWriter opens the file for writing in the file system currently
+responsible for it.
+*/
+func (d *DynamicFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	var fs, err = d.resolve(ctx, u)
+
+	if err != nil {
+		return nil, err
+	}
+	return fs.OpenWriter(ctx, u)
+}
+
+/*
+OpenAppender opens the file for appending in the file system currently
+responsible for it.
+*/
+func (d *DynamicFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	var fs, err = d.resolve(ctx, u)
+
+	if err != nil {
+		return nil, err
+	}
+	return fs.OpenAppender(ctx, u)
+}
+
+/*
+ListEntries lists the directory in the file system currently responsible
+for it.
+*/
+func (d *DynamicFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	var fs, err = d.resolve(ctx, u)
+
+	if err != nil {
+		return nil, err
+	}
+	return fs.ListEntries(ctx, u)
+}
+
+/*
+WatchFile watches the file in the file system responsible for it at the
+time the watch is started. The watch is not moved if the resolver later
+returns a different file system.
+*/
+func (d *DynamicFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	var fs, err = d.resolve(ctx, u)
+
+	if err != nil {
+		return nil, nil, err
+	}
+	return fs.WatchFile(ctx, u, watcher)
+}
+
+/*
+Remove deletes the file in the file system currently responsible for it.
+*/
+func (d *DynamicFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	var fs, err = d.resolve(ctx, u)
+
+	if err != nil {
+		return err
+	}
+	return fs.Remove(ctx, u)
+}
+
+/*
+Stat retrieves the metadata of the file from the file system currently
+responsible for it.
+*/
+func (d *DynamicFileSystem) Stat(ctx context.Context, u *url.URL) (
+	FileInfo, error) {
+	var fs, err = d.resolve(ctx, u)
+
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(ctx, u)
+}
@@ -0,0 +1,47 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestDynamicFileSystem(t *testing.T) {
+	blue := virtualfs.NewVirtualFileSystem()
+	green := virtualfs.NewVirtualFileSystem()
+	ctx := context.Background()
+	u := &url.URL{Scheme: "dyn", Path: "/file"}
+
+	d := filesystem.NewDynamicFileSystem(
+		func(ctx context.Context, scheme string) (filesystem.FileSystem, error) {
+			return blue, nil
+		})
+	filesystem.AddImplementation("dyn", d)
+	writeTestFile(t, u, "blue")
+
+	d.SetResolver(
+		func(ctx context.Context, scheme string) (filesystem.FileSystem, error) {
+			return green, nil
+		})
+	writeTestFile(t, u, "green")
+
+	for fs, expected := range map[*virtualfs.VirtualFileSystem]string{
+		blue: "blue", green: "green",
+	} {
+		fi, err := fs.Stat(ctx, u)
+		if err != nil || fi.Size() != int64(len(expected)) {
+			t.Errorf("File not written to %s backend (%v)", expected, err)
+		}
+	}
+
+	d.SetResolver(
+		func(ctx context.Context, scheme string) (filesystem.FileSystem, error) {
+			return nil, nil
+		})
+	if _, err := d.OpenReader(ctx, u); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error without backend: %v", err)
+	}
+}
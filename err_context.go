@@ -0,0 +1,83 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+ErrContext wraps an error with contextual fields, such as the URL and
+operation involved, to simplify debugging. Create one using
+WrapWithContext.
+*/
+type ErrContext struct {
+	// The wrapped error.
+	Err error
+
+	// Contextual fields describing the circumstances of the error.
+	Fields map[string]any
+}
+
+/*
+Error returns the message of the wrapped error followed by all fields as
+key=value pairs, sorted by key, on a single line.
+*/
+func (e *ErrContext) Error() string {
+	var keys = make([]string, 0, len(e.Fields))
+	var b strings.Builder
+
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b.WriteString(e.Err.Error())
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, e.Fields[k])
+	}
+
+	return b.String()
+}
+
+/*
+Unwrap returns the wrapped error.
+*/
+func (e *ErrContext) Unwrap() error {
+	return e.Err
+}
+
+/*
+WrapWithContext attaches the contextual fields to err. Returns nil if err
+is nil.
+*/
+func WrapWithContext(err error, fields map[string]any) error {
+	if err == nil {
+		return nil
+	}
+	return &ErrContext{Err: err, Fields: fields}
+}
+
+/*
+UnwrapContext collects the fields of all ErrContext errors in the chain of
+err. If a field is set at several levels, the outermost value wins.
+*/
+func UnwrapContext(err error) map[string]any {
+	var fields = make(map[string]any)
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		var ec, ok = err.(*ErrContext)
+
+		if !ok {
+			continue
+		}
+		for k, v := range ec.Fields {
+			if _, found := fields[k]; !found {
+				fields[k] = v
+			}
+		}
+	}
+
+	return fields
+}
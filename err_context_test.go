@@ -0,0 +1,33 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrContext(t *testing.T) {
+	inner := WrapWithContext(ErrExpected, map[string]any{
+		"url": "s3://bucket/key", "op": "OpenReader"})
+	outer := WrapWithContext(fmt.Errorf("loading config: %w", inner),
+		map[string]any{"user": "alice", "op": "Load"})
+
+	if !errors.Is(outer, ErrExpected) {
+		t.Error("Wrapped error not found in chain")
+	}
+
+	fields := UnwrapContext(outer)
+	if len(fields) != 3 || fields["op"] != "Load" ||
+		fields["url"] != "s3://bucket/key" || fields["user"] != "alice" {
+		t.Errorf("Unexpected fields %v", fields)
+	}
+
+	expected := "Expect this error op=OpenReader url=s3://bucket/key"
+	if msg := inner.Error(); msg != expected {
+		t.Errorf("Unexpected message %q", msg)
+	}
+
+	if WrapWithContext(nil, fields) != nil {
+		t.Error("Wrapping nil error returned non-nil")
+	}
+}
@@ -0,0 +1,45 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+ETagFileSystem is implemented by file systems which can report an entity
+tag for the contents of a file when opening it, e.g. from the ETag header
+of a HTTP response or the object metadata of an object store.
+*/
+type ETagFileSystem interface {
+	// Open the specified file for reading and return the entity tag of the
+	// contents being read.
+	OpenReaderWithETag(context.Context, *url.URL) (ReadCloser, string, error)
+}
+
+/*
+OpenReaderWithETag opens the referenced file for reading like OpenReader,
+and additionally returns the entity tag of the contents being read. This
+saves a separate request for retrieving the ETag for caching purposes.
+File systems which do not implement ETagFileSystem will cause EUNSUPP to be
+returned.
+*/
+func OpenReaderWithETag(ctx context.Context, fileurl *url.URL) (
+	ReadCloser, string, error) {
+	var fs = GetImplementation(fileurl)
+	var efs ETagFileSystem
+	var cancel context.CancelFunc
+	var ok bool
+
+	if fs == nil {
+		return nil, "", ENOFS
+	}
+
+	if efs, ok = fs.(ETagFileSystem); !ok {
+		return nil, "", EUNSUPP
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return efs.OpenReaderWithETag(ctx, fileurl)
+}
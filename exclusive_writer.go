@@ -0,0 +1,151 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	iofs "io/fs"
+	"net/url"
+)
+
+/*
+ExclusiveWriterFileSystem is implemented by file systems which can
+atomically create a file only if it does not exist yet, e.g. using O_EXCL
+or conditional requests.
+*/
+type ExclusiveWriterFileSystem interface {
+	// Create the specified file and open it for writing. Must fail with
+	// ErrAlreadyExists if the file exists already.
+	OpenWriterExclusive(context.Context, *url.URL) (WriteCloser, error)
+}
+
+/*
+fileExists determines whether the referenced file exists in fs, using Stat
+if the file system supports it and attempting to open the file otherwise.
+*/
+func fileExists(ctx context.Context, fs FileSystem, fileurl *url.URL) (
+	bool, error) {
+	var rc ReadCloser
+	var err error
+
+	if _, err = fs.Stat(ctx, fileurl); err == EUNSUPP {
+		if rc, err = fs.OpenReader(ctx, fileurl); err == nil {
+			rc.Close(ctx)
+		}
+	}
+
+	if errors.Is(err, iofs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+Exists determines whether the referenced file exists. Uses Stat if the file
+system supports it, and attempts to open the file otherwise; file systems
+must report missing files using errors matching fs.ErrNotExist for this to
+work.
+*/
+func Exists(ctx context.Context, fileurl *url.URL) (bool, error) {
+	var fs = GetImplementation(fileurl)
+	var cancel context.CancelFunc
+
+	if fs == nil {
+		return false, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return fileExists(ctx, fs, fileurl)
+}
+
+/*
+OpenWriterExclusive creates the referenced file and opens it for writing.
+If the file exists already, ErrAlreadyExists is returned. This is useful as
+a building block for leader election or idempotent writes.
+
+File systems implementing ExclusiveWriterFileSystem perform the check and
+the creation atomically. For all other file systems, the existence of the
+file is checked before opening it, so two concurrent callers may both
+succeed in creating the file.
+*/
+func OpenWriterExclusive(ctx context.Context, fileurl *url.URL) (
+	WriteCloser, error) {
+	var fs = GetImplementation(fileurl)
+	var found bool
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if efs, ok := fs.(ExclusiveWriterFileSystem); ok {
+		return efs.OpenWriterExclusive(ctx, fileurl)
+	}
+
+	if found, err = fileExists(ctx, fs, fileurl); err != nil {
+		return nil, err
+	}
+	if found {
+		return nil, ErrAlreadyExists
+	}
+
+	return fs.OpenWriter(ctx, fileurl)
+}
+
+/*
+ExclusiveAppenderFileSystem is implemented by file systems which can
+atomically create a file for appending only if it does not exist yet, e.g.
+using O_EXCL|O_APPEND.
+*/
+type ExclusiveAppenderFileSystem interface {
+	// Create the specified file and open it for appending. Must fail with
+	// ErrAlreadyExists if the file exists already.
+	OpenAppenderExclusive(context.Context, *url.URL) (WriteCloser, error)
+}
+
+/*
+OpenAppenderExclusive creates the referenced file and opens it for
+appending. If the file exists already, ErrAlreadyExists is returned, which
+allows callers to tell whether they started a new append stream or would
+join an existing one, e.g. when initializing log files or claiming a
+partition.
+
+The same atomicity caveats as for OpenWriterExclusive apply to file systems
+not implementing ExclusiveAppenderFileSystem.
+*/
+func OpenAppenderExclusive(ctx context.Context, fileurl *url.URL) (
+	WriteCloser, error) {
+	var fs = GetImplementation(fileurl)
+	var found bool
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if efs, ok := fs.(ExclusiveAppenderFileSystem); ok {
+		return efs.OpenAppenderExclusive(ctx, fileurl)
+	}
+
+	if found, err = fileExists(ctx, fs, fileurl); err != nil {
+		return nil, err
+	}
+	if found {
+		return nil, ErrAlreadyExists
+	}
+
+	return fs.OpenAppender(ctx, fileurl)
+}
@@ -0,0 +1,87 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+)
+
+/*
+ErrExpired is returned when opening a file whose expiration time has
+passed but which has not been removed by the file system yet.
+*/
+var ErrExpired = errors.New("File has expired")
+
+/*
+ExpiringFileSystem is implemented by file systems which support setting a
+time to live on files, either natively (e.g. Redis EXPIRE or object store
+lifecycle rules) or by storing the expiration time as metadata and
+enforcing it in OpenReader by returning ErrExpired.
+*/
+type ExpiringFileSystem interface {
+	// Set the time at which the file expires.
+	SetExpiry(context.Context, *url.URL, time.Time) error
+
+	// Retrieve the time at which the file expires, or the zero time if it
+	// does not expire.
+	GetExpiry(context.Context, *url.URL) (time.Time, error)
+}
+
+/*
+getExpiringFileSystem determines the ExpiringFileSystem responsible for the
+URL, or returns ENOFS or EUNSUPP if there is none.
+*/
+func getExpiringFileSystem(fileurl *url.URL) (ExpiringFileSystem, error) {
+	var fs = GetImplementation(fileurl)
+	var efs ExpiringFileSystem
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if efs, ok = fs.(ExpiringFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	return efs, nil
+}
+
+/*
+SetExpiry sets the time at which the referenced file expires. After that
+time, the file will be removed or fail to open with ErrExpired.
+*/
+func SetExpiry(ctx context.Context, fileurl *url.URL, expiresAt time.Time) error {
+	var efs ExpiringFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if efs, err = getExpiringFileSystem(fileurl); err != nil {
+		return err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return efs.SetExpiry(ctx, fileurl, expiresAt)
+}
+
+/*
+GetExpiry retrieves the time at which the referenced file expires. The zero
+time is returned for files which do not expire.
+*/
+func GetExpiry(ctx context.Context, fileurl *url.URL) (time.Time, error) {
+	var efs ExpiringFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if efs, err = getExpiringFileSystem(fileurl); err != nil {
+		return time.Time{}, err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return efs.GetExpiry(ctx, fileurl)
+}
@@ -0,0 +1,98 @@
+package filesystem
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+/*
+FilenameGenerator generates names for files created by a writer, such as
+temporary files or the individual chunks of a large file.
+*/
+type FilenameGenerator interface {
+	// Generate the name of the file with the specified index. Indices start
+	// at 0 and increase by one for every file created by the same writer.
+	Generate(index int) (string, error)
+}
+
+/*
+UUIDFilenameGenerator generates random names in the form of version 4 UUIDs
+using crypto/rand. The index is ignored.
+*/
+type UUIDFilenameGenerator struct {
+	// Prefix prepended to each name.
+	Prefix string
+}
+
+/*
+Generate creates a new random name.
+*/
+func (g *UUIDFilenameGenerator) Generate(index int) (string, error) {
+	var b = make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	// Set the version (4) and variant (RFC 4122) bits.
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%s%x-%x-%x-%x-%x", g.Prefix,
+		b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+/*
+DefaultTimestampLayout is the time format used by TimestampFilenameGenerator
+if no Layout is configured. It sorts chronologically.
+*/
+const DefaultTimestampLayout = "20060102-150405.000000000"
+
+/*
+TimestampFilenameGenerator generates names from the current time in UTC,
+followed by the index to keep names created within the same instant apart.
+This allows bucketing files by time using prefix listings.
+*/
+type TimestampFilenameGenerator struct {
+	// Prefix prepended to each name.
+	Prefix string
+
+	// Layout of the time, as understood by time.Format. Defaults to
+	// DefaultTimestampLayout.
+	Layout string
+}
+
+/*
+Generate creates a name from the current time and the index.
+*/
+func (g *TimestampFilenameGenerator) Generate(index int) (string, error) {
+	var layout = g.Layout
+
+	if layout == "" {
+		layout = DefaultTimestampLayout
+	}
+
+	return g.Prefix + time.Now().UTC().Format(layout) + "-" +
+		strconv.Itoa(index), nil
+}
+
+/*
+SequentialFilenameGenerator generates deterministic names from the index,
+e.g. for tests.
+*/
+type SequentialFilenameGenerator struct {
+	// Prefix prepended to each name.
+	Prefix string
+
+	// Minimum number of digits, padded with zeros.
+	Width int
+}
+
+/*
+Generate creates a name from the index.
+*/
+func (g *SequentialFilenameGenerator) Generate(index int) (string, error) {
+	return fmt.Sprintf("%s%0*d", g.Prefix, g.Width, index), nil
+}
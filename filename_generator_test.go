@@ -0,0 +1,33 @@
+package filesystem
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestUUIDFilenameGenerator(t *testing.T) {
+	g := &UUIDFilenameGenerator{Prefix: "tmp-"}
+	re := regexp.MustCompile(
+		"^tmp-[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$")
+
+	a, err := g.Generate(0)
+	if err != nil {
+		t.Fatalf("Error reported from Generate: %v", err)
+	}
+	b, _ := g.Generate(0)
+
+	if !re.MatchString(a) {
+		t.Errorf("Generated name %q is not a version 4 UUID", a)
+	}
+	if a == b {
+		t.Errorf("Generated the same name %q twice", a)
+	}
+}
+
+func TestSequentialFilenameGenerator(t *testing.T) {
+	g := &SequentialFilenameGenerator{Prefix: "chunk-", Width: 4}
+
+	if name, _ := g.Generate(42); name != "chunk-0042" {
+		t.Errorf("Unexpected name %q", name)
+	}
+}
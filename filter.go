@@ -0,0 +1,245 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+)
+
+/*
+FileSystem wrapper which only serves URLs accepted by a filter.
+*/
+type filterFileSystem struct {
+	inner   FileSystem
+	include func(*url.URL) bool
+}
+
+/*
+NewFilterFileSystem wraps inner into a FileSystem which calls include for
+every URL an operation is invoked on, and fails the operation with ENOFS
+without consulting inner if include returns false. Together with
+NewCompositeFileSystem, this allows routing URLs of the same scheme to
+different file systems.
+
+The wrapper only supports the FileSystem methods.
+*/
+func NewFilterFileSystem(inner FileSystem, include func(*url.URL) bool) FileSystem {
+	return &filterFileSystem{inner: inner, include: include}
+}
+
+/*
+NewRegexFilterFileSystem creates a FileSystem like NewFilterFileSystem which
+only serves URLs whose string representation matches the regular
+expression pattern.
+*/
+func NewRegexFilterFileSystem(inner FileSystem, pattern string) (FileSystem, error) {
+	var re, err = regexp.Compile(pattern)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFilterFileSystem(inner, func(u *url.URL) bool {
+		return re.MatchString(u.String())
+	}), nil
+}
+
+/*
+OpenReader opens the file for reading if the URL is included.
+*/
+func (f *filterFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	if !f.include(u) {
+		return nil, ENOFS
+	}
+	return f.inner.OpenReader(ctx, u)
+}
+
+/*
+OpenWriter opens the file for writing if the URL is included.
+*/
+func (f *filterFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	if !f.include(u) {
+		return nil, ENOFS
+	}
+	return f.inner.OpenWriter(ctx, u)
+}
+
+/*
+OpenAppender opens the file for appending if the URL is included.
+*/
+func (f *filterFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	if !f.include(u) {
+		return nil, ENOFS
+	}
+	return f.inner.OpenAppender(ctx, u)
+}
+
+/*
+ListEntries lists the directory if the URL is included.
+*/
+func (f *filterFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	if !f.include(u) {
+		return nil, ENOFS
+	}
+	return f.inner.ListEntries(ctx, u)
+}
+
+/*
+WatchFile watches the file if the URL is included.
+*/
+func (f *filterFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	if !f.include(u) {
+		return nil, nil, ENOFS
+	}
+	return f.inner.WatchFile(ctx, u, watcher)
+}
+
+/*
+Remove deletes the file if the URL is included.
+*/
+func (f *filterFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	if !f.include(u) {
+		return ENOFS
+	}
+	return f.inner.Remove(ctx, u)
+}
+
+/*
+Stat retrieves the metadata of the file if the URL is included.
+*/
+func (f *filterFileSystem) Stat(ctx context.Context, u *url.URL) (
+	FileInfo, error) {
+	if !f.include(u) {
+		return nil, ENOFS
+	}
+	return f.inner.Stat(ctx, u)
+}
+
+/*
+FileSystem which routes every operation to the first of several file
+systems willing to handle the URL.
+*/
+type compositeFileSystem struct {
+	backends []FileSystem
+}
+
+/*
+NewCompositeFileSystem creates a FileSystem which invokes every operation on
+the given file systems in order, until one returns something other than
+ENOFS. The backends are usually created by NewFilterFileSystem, so that
+e.g. different paths of the same scheme can be served by different file
+systems:
+
+	fs := NewCompositeFileSystem(
+		NewFilterFileSystem(archive, isArchived),
+		NewFilterFileSystem(live, func(*url.URL) bool { return true }))
+
+The composite only supports the FileSystem methods.
+*/
+func NewCompositeFileSystem(backends ...FileSystem) FileSystem {
+	return &compositeFileSystem{backends: backends}
+}
+
+/*
+route invokes op on all backends until one handles the URL.
+*/
+func route[T any](backends []FileSystem, op func(FileSystem) (T, error)) (T, error) {
+	var result T
+	var err = ENOFS
+
+	for _, backend := range backends {
+		if result, err = op(backend); err != ENOFS {
+			return result, err
+		}
+	}
+
+	return result, err
+}
+
+/*
+OpenReader opens the file for reading in the first backend handling it.
+*/
+func (c *compositeFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	return route(c.backends, func(fs FileSystem) (ReadCloser, error) {
+		return fs.OpenReader(ctx, u)
+	})
+}
+
+/*
+OpenWriter opens the file for writing in the first backend handling it.
+*/
+func (c *compositeFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return route(c.backends, func(fs FileSystem) (WriteCloser, error) {
+		return fs.OpenWriter(ctx, u)
+	})
+}
+
+/*
+OpenAppender opens the file for appending in the first backend handling it.
+*/
+func (c *compositeFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return route(c.backends, func(fs FileSystem) (WriteCloser, error) {
+		return fs.OpenAppender(ctx, u)
+	})
+}
+
+/*
+ListEntries lists the directory in the first backend handling it.
+*/
+func (c *compositeFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	return route(c.backends, func(fs FileSystem) ([]string, error) {
+		return fs.ListEntries(ctx, u)
+	})
+}
+
+/*
+WatchFile watches the file in the first backend handling it.
+*/
+func (c *compositeFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	var errChan chan error
+	var cancel, err = route(c.backends,
+		func(fs FileSystem) (CancelWatchFunc, error) {
+			var cancel CancelWatchFunc
+			var err error
+
+			cancel, errChan, err = fs.WatchFile(ctx, u, watcher)
+			return cancel, err
+		})
+
+	return cancel, errChan, err
+}
+
+/*
+Remove deletes the file in the first backend handling it.
+*/
+func (c *compositeFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	var _, err = route(c.backends, func(fs FileSystem) (struct{}, error) {
+		return struct{}{}, fs.Remove(ctx, u)
+	})
+	return err
+}
+
+/*
+Stat retrieves the metadata of the file in the first backend handling it.
+Backends which do not support Stat are skipped.
+*/
+func (c *compositeFileSystem) Stat(ctx context.Context, u *url.URL) (
+	FileInfo, error) {
+	return route(c.backends, func(fs FileSystem) (FileInfo, error) {
+		var fi, err = fs.Stat(ctx, u)
+		if err == EUNSUPP {
+			return nil, ENOFS
+		}
+		return fi, err
+	})
+}
@@ -0,0 +1,45 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestCompositeOfFilterFileSystems(t *testing.T) {
+	archive := virtualfs.NewVirtualFileSystem()
+	live := virtualfs.NewVirtualFileSystem()
+
+	archiveOnly, err := filesystem.NewRegexFilterFileSystem(archive,
+		"^routed:///archive/")
+	if err != nil {
+		t.Fatalf("Error reported from NewRegexFilterFileSystem: %v", err)
+	}
+	filesystem.AddImplementation("routed", filesystem.NewCompositeFileSystem(
+		archiveOnly,
+		filesystem.NewFilterFileSystem(live,
+			func(*url.URL) bool { return true })))
+
+	writeTestFile(t, &url.URL{Scheme: "routed", Path: "/archive/a"}, "old")
+	writeTestFile(t, &url.URL{Scheme: "routed", Path: "/current/b"}, "new")
+
+	ctx := context.Background()
+	if _, err = archive.Stat(ctx, &url.URL{Path: "/archive/a"}); err != nil {
+		t.Errorf("Archived file not written to archive: %v", err)
+	}
+	if _, err = live.Stat(ctx, &url.URL{Path: "/current/b"}); err != nil {
+		t.Errorf("Current file not written to live file system: %v", err)
+	}
+	if _, err = archive.Stat(ctx, &url.URL{Path: "/current/b"}); err == nil {
+		t.Error("Current file written to archive")
+	}
+
+	_, err = archiveOnly.OpenReader(ctx,
+		&url.URL{Scheme: "routed", Path: "/current/b"})
+	if err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for excluded URL: %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Package fstest provides conformance tests for implementations of
+filesystem.FileSystem, to be invoked from their own tests.
+*/
+package fstest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"net/url"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+)
+
+/*
+Number of goroutines started for each type of operation.
+*/
+const goroutinesPerOperation = 100
+
+/*
+Number of files shared between the goroutines. Kept small so that
+operations on the same file overlap.
+*/
+const sharedFiles = 10
+
+/*
+Size of the files written by the tests.
+*/
+const fileSize = 4096
+
+/*
+ConcurrencyTimeout is the time after which TestFileSystemConcurrency
+assumes that the file system has deadlocked.
+*/
+var ConcurrencyTimeout = 30 * time.Second
+
+/*
+childURL creates a URL for the named file beneath base.
+*/
+func childURL(base *url.URL, name string) *url.URL {
+	var u = *base
+	u.Path = path.Join(base.Path, name)
+	return &u
+}
+
+/*
+ignoreNotExist returns nil for errors reporting that a file does not exist,
+which is expected while other goroutines are removing files.
+*/
+func ignoreNotExist(err error) error {
+	if errors.Is(err, iofs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+/*
+writeFile replaces the contents of the file with data.
+*/
+func writeFile(ctx context.Context, fs filesystem.FileSystem, u *url.URL,
+	data []byte) error {
+	var wc filesystem.WriteCloser
+	var err error
+
+	if wc, err = fs.OpenWriter(ctx, u); err != nil {
+		return err
+	}
+	if _, err = wc.Write(ctx, data); err != nil {
+		wc.Close(ctx)
+		return err
+	}
+	return wc.Close(ctx)
+}
+
+/*
+readFile reads the entire contents of the file.
+*/
+func readFile(ctx context.Context, fs filesystem.FileSystem, u *url.URL) (
+	[]byte, error) {
+	var rc filesystem.ReadCloser
+	var data []byte
+	var err error
+
+	if rc, err = fs.OpenReader(ctx, u); err != nil {
+		return nil, err
+	}
+	defer rc.Close(ctx)
+
+	data, err = io.ReadAll(filesystem.ToIoReadCloser(
+		filesystem.NewContextReadCloser(rc, ctx)))
+	return data, err
+}
+
+/*
+checkShared verifies that data could have been produced by the writers of
+the shared files. Since readers may observe writes and truncations in
+progress, only the length and the set of bytes are checked.
+*/
+func checkShared(data []byte) error {
+	if len(data) > fileSize {
+		return fmt.Errorf("shared file has %d bytes, expected at most %d",
+			len(data), fileSize)
+	}
+	for i, b := range data {
+		if b < 'a' || b > 'z' {
+			return fmt.Errorf("shared file has unexpected byte %#x at offset %d",
+				b, i)
+		}
+	}
+	return nil
+}
+
+/*
+TestFileSystemConcurrency runs at least 100 goroutines each doing OpenWriter,
+OpenReader, ListEntries, Stat (if supported) and Remove on a small set of
+files beneath baseURL, plus goroutines writing and verifying private files.
+The test fails if any operation reports an unexpected error, if data is
+corrupted, or if the operations do not finish within ConcurrencyTimeout.
+
+The test runs as a parallel subtest of t. Run it with -race to detect data
+races in the implementation. The directory referenced by baseURL must exist
+and should be empty.
+*/
+func TestFileSystemConcurrency(t *testing.T, factory func() filesystem.FileSystem,
+	baseURL *url.URL) {
+	t.Run("Concurrency", func(t *testing.T) {
+		var fs = factory()
+		var ctx = context.Background()
+		var errs = make(chan error, 6*goroutinesPerOperation)
+		var done = make(chan struct{})
+		var wg sync.WaitGroup
+
+		t.Parallel()
+
+		var run = func(op string, fn func(int) error) {
+			for i := 0; i < goroutinesPerOperation; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					if err := fn(i); err != nil {
+						errs <- fmt.Errorf("%s #%d: %w", op, i, err)
+					}
+				}(i)
+			}
+		}
+
+		var shared = func(i int) *url.URL {
+			return childURL(baseURL, fmt.Sprintf("shared-%d", i%sharedFiles))
+		}
+
+		run("OpenWriter", func(i int) error {
+			return writeFile(ctx, fs, shared(i),
+				bytes.Repeat([]byte{byte('a' + i%26)}, fileSize))
+		})
+		run("OpenReader", func(i int) error {
+			var data, err = readFile(ctx, fs, shared(i))
+			if err != nil {
+				return ignoreNotExist(err)
+			}
+			return checkShared(data)
+		})
+		run("ListEntries", func(i int) error {
+			var _, err = fs.ListEntries(ctx, baseURL)
+			return ignoreNotExist(err)
+		})
+		run("Stat", func(i int) error {
+			var _, err = fs.Stat(ctx, shared(i))
+			if err == filesystem.EUNSUPP {
+				return nil
+			}
+			return ignoreNotExist(err)
+		})
+		run("Remove", func(i int) error {
+			return ignoreNotExist(fs.Remove(ctx, shared(i)))
+		})
+		run("Private", func(i int) error {
+			var u = childURL(baseURL, fmt.Sprintf("private-%d", i))
+			var expected = []byte(fmt.Sprintf("private file %d", i))
+			var data []byte
+			var err error
+
+			if err = writeFile(ctx, fs, u, expected); err != nil {
+				return err
+			}
+			if data, err = readFile(ctx, fs, u); err != nil {
+				return err
+			}
+			if !bytes.Equal(data, expected) {
+				return fmt.Errorf("read %q, expected %q", data, expected)
+			}
+			return nil
+		})
+
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(ConcurrencyTimeout):
+			t.Fatalf("Operations did not finish within %v, possible deadlock",
+				ConcurrencyTimeout)
+		}
+
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+	})
+}
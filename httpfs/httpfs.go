@@ -0,0 +1,565 @@
+/*
+Package httpfs provides a file system adapter for plain HTTP and HTTPS URLs.
+
+Files are read using GET requests, written using PUT requests, removed using
+DELETE requests and their metadata is retrieved using HEAD requests. Query
+parameters of the URL are passed on to the server unmodified.
+
+A default instance is registered for the http and https schemes on
+initialization. Use New with the desired options and AddImplementation to
+replace it, e.g. in order to use a custom http.Client.
+*/
+package httpfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+)
+
+/*
+DefaultMaxRedirects is the number of redirects followed by default.
+*/
+const DefaultMaxRedirects = 10
+
+/*
+ErrTooManyRedirects is returned if a request was redirected more often than
+permitted.
+*/
+var ErrTooManyRedirects = errors.New("Too many HTTP redirects")
+
+/*
+StatusError is returned when the server responds with an unexpected HTTP
+status code. Status codes 404 and 403 are reported as fs.ErrNotExist and
+fs.ErrPermission respectively through errors.Is.
+*/
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+/*
+Error returns a description of the HTTP status.
+*/
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Unexpected HTTP status: %s", e.Status)
+}
+
+/*
+Is determines whether the status code corresponds to one of the standard
+file system errors.
+*/
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case fs.ErrNotExist:
+		return e.StatusCode == http.StatusNotFound ||
+			e.StatusCode == http.StatusGone
+	case fs.ErrPermission:
+		return e.StatusCode == http.StatusForbidden ||
+			e.StatusCode == http.StatusUnauthorized
+	}
+	return false
+}
+
+/*
+IsRetryable reports whether the status code indicates a transient failure,
+such as rate limiting or an overloaded server. See
+filesystem.RetryableError.
+*/
+func (e *StatusError) IsRetryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+/*
+Create a StatusError if the response does not indicate success.
+*/
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
+
+/*
+Option describes a configuration option for New.
+*/
+type Option func(*HTTPFileSystem)
+
+/*
+WithClient makes the file system use the specified HTTP client for all
+requests. The client itself is not modified.
+*/
+func WithClient(client *http.Client) Option {
+	return func(h *HTTPFileSystem) {
+		h.client = client
+	}
+}
+
+/*
+WithMaxRedirects sets the number of redirects which will be followed before
+failing a request with ErrTooManyRedirects.
+*/
+func WithMaxRedirects(n int) Option {
+	return func(h *HTTPFileSystem) {
+		h.maxRedirects = n
+	}
+}
+
+/*
+HTTPFileSystem implements the filesystem.FileSystem API on top of HTTP
+requests.
+*/
+type HTTPFileSystem struct {
+	client       *http.Client
+	maxRedirects int
+}
+
+/*
+New creates a new HTTPFileSystem configured with the specified options.
+*/
+func New(opts ...Option) *HTTPFileSystem {
+	var h = &HTTPFileSystem{
+		client:       http.DefaultClient,
+		maxRedirects: DefaultMaxRedirects,
+	}
+	var client http.Client
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	client = *h.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > h.maxRedirects {
+			return ErrTooManyRedirects
+		}
+		return nil
+	}
+	h.client = &client
+
+	return h
+}
+
+func init() {
+	var h = New()
+	filesystem.AddImplementation("http", h)
+	filesystem.AddImplementation("https", h)
+}
+
+/*
+do performs the HTTP request, controlling it with ctx until the response
+headers have arrived. The returned cancel function aborts the request and
+must be called once the response body is no longer needed. header may be
+nil.
+*/
+func (h *HTTPFileSystem) do(ctx context.Context, method string,
+	fileurl *url.URL, header http.Header, body io.Reader) (
+	*http.Response, context.CancelFunc, error) {
+	var reqCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+	var stop = context.AfterFunc(ctx, cancel)
+	var req *http.Request
+	var resp *http.Response
+	var err error
+
+	defer stop()
+
+	if req, err = http.NewRequestWithContext(
+		reqCtx, method, fileurl.String(), body); err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	if resp, err = h.client.Do(req); err != nil {
+		cancel()
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		return nil, nil, err
+	}
+
+	if err = checkStatus(resp); err != nil {
+		resp.Body.Close()
+		cancel()
+		return nil, nil, err
+	}
+
+	return resp, cancel, nil
+}
+
+/*
+Reader for the body of a HTTP response.
+*/
+type bodyReadCloser struct {
+	body   io.ReadCloser
+	cancel context.CancelFunc
+}
+
+/*
+Read reads from the response body. If the context expires during the read,
+the entire request is aborted.
+*/
+func (b *bodyReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	var stop func() bool
+	var n int
+	var err error
+
+	if err = ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	stop = context.AfterFunc(ctx, b.cancel)
+	n, err = b.body.Read(p)
+	if !stop() {
+		return n, ctx.Err()
+	}
+	return n, err
+}
+
+/*
+Close releases the response body.
+*/
+func (b *bodyReadCloser) Close(ctx context.Context) error {
+	defer b.cancel()
+	return b.body.Close()
+}
+
+/*
+OpenReader performs a GET request on the URL and returns a ReadCloser for the
+response body.
+*/
+func (h *HTTPFileSystem) OpenReader(ctx context.Context, fileurl *url.URL) (
+	filesystem.ReadCloser, error) {
+	var rc, _, err = h.OpenReaderWithETag(ctx, fileurl)
+	return rc, err
+}
+
+/*
+OpenReaderWithETag performs a GET request on the URL and returns a
+ReadCloser for the response body along with the ETag header of the
+response, which is empty if the server did not send one.
+*/
+func (h *HTTPFileSystem) OpenReaderWithETag(ctx context.Context,
+	fileurl *url.URL) (filesystem.ReadCloser, string, error) {
+	var resp, rc, err = h.get(ctx, fileurl)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return rc, resp.Header.Get("ETag"), nil
+}
+
+/*
+OpenReaderWithLength performs a GET request on the URL and returns a
+ReadCloser for the response body along with the Content-Length of the
+response, or -1 if the server did not specify it.
+*/
+func (h *HTTPFileSystem) OpenReaderWithLength(ctx context.Context,
+	fileurl *url.URL) (filesystem.ReadCloser, int64, error) {
+	var resp, rc, err = h.get(ctx, fileurl)
+
+	if err != nil {
+		return nil, -1, err
+	}
+
+	return rc, resp.ContentLength, nil
+}
+
+/*
+get performs a GET request on the URL and returns the response along with a
+ReadCloser for its body.
+*/
+func (h *HTTPFileSystem) get(ctx context.Context, fileurl *url.URL) (
+	*http.Response, filesystem.ReadCloser, error) {
+	var resp, cancel, err = h.do(ctx, http.MethodGet, fileurl, nil, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp, &bodyReadCloser{body: resp.Body, cancel: cancel}, nil
+}
+
+/*
+OpenReaderRange performs a GET request on the URL with a Range header and
+returns a ReadCloser for the requested bytes. If the server ignores the
+Range header and sends the entire file, the bytes before start are skipped
+and the rest is limited to the requested length. Empty ranges, including
+those the server rejects as unsatisfiable, yield an empty reader.
+*/
+func (h *HTTPFileSystem) OpenReaderRange(ctx context.Context,
+	fileurl *url.URL, start, end int64) (filesystem.ReadCloser, error) {
+	var header = make(http.Header)
+	var resp *http.Response
+	var cancel context.CancelFunc
+	var rc filesystem.ReadCloser
+	var statusErr *StatusError
+	var err error
+
+	if end >= 0 && end <= start {
+		// An empty range cannot be expressed in a Range header.
+		return &bodyReadCloser{body: http.NoBody, cancel: func() {}}, nil
+	} else if end < 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", start))
+	} else {
+		header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end-1))
+	}
+
+	resp, cancel, err = h.do(ctx, http.MethodGet, fileurl, header, nil)
+	if errors.As(err, &statusErr) &&
+		statusErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// The range starts beyond the end of the file.
+		return &bodyReadCloser{body: http.NoBody, cancel: func() {}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rc = &bodyReadCloser{body: resp.Body, cancel: cancel}
+
+	if resp.StatusCode != http.StatusPartialContent && start > 0 {
+		if _, err = io.CopyN(io.Discard, resp.Body, start); err != nil && err != io.EOF {
+			rc.Close(ctx)
+			return nil, err
+		}
+	}
+
+	if end < 0 {
+		return rc, nil
+	}
+	return &filesystem.LimitedReadCloser{R: rc, N: end - start}, nil
+}
+
+/*
+OpenReaderIfModifiedSince performs a GET request on the URL with an
+If-Modified-Since header, and returns nil, false, nil if the server reports
+that the resource has not been modified.
+*/
+func (h *HTTPFileSystem) OpenReaderIfModifiedSince(ctx context.Context,
+	fileurl *url.URL, since time.Time) (filesystem.ReadCloser, bool, error) {
+	var header = make(http.Header)
+	var resp *http.Response
+	var cancel context.CancelFunc
+	var statusErr *StatusError
+	var err error
+
+	header.Set("If-Modified-Since", since.UTC().Format(http.TimeFormat))
+
+	resp, cancel, err = h.do(ctx, http.MethodGet, fileurl, header, nil)
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotModified {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &bodyReadCloser{body: resp.Body, cancel: cancel}, true, nil
+}
+
+/*
+Writer which streams data into the body of a PUT request.
+*/
+type putWriteCloser struct {
+	pw     *io.PipeWriter
+	result chan error
+}
+
+/*
+Write streams p to the server. If the context expires during the write, the
+upload is aborted.
+*/
+func (w *putWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	var stop func() bool
+	var n int
+	var err error
+
+	if err = ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	stop = context.AfterFunc(ctx, func() {
+		w.pw.CloseWithError(ctx.Err())
+	})
+	defer stop()
+
+	n, err = w.pw.Write(p)
+	return n, err
+}
+
+/*
+Close finishes the upload and waits for the server to respond.
+*/
+func (w *putWriteCloser) Close(ctx context.Context) error {
+	w.pw.Close()
+
+	select {
+	case err := <-w.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+OpenWriter starts a PUT request to the URL and returns a WriteCloser which
+streams into the request body. The upload is complete once Close returns
+successfully.
+*/
+func (h *HTTPFileSystem) OpenWriter(ctx context.Context, fileurl *url.URL) (
+	filesystem.WriteCloser, error) {
+	var pr, pw = io.Pipe()
+	var w = &putWriteCloser{pw: pw, result: make(chan error, 1)}
+	var req *http.Request
+	var err error
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if req, err = http.NewRequestWithContext(context.WithoutCancel(ctx),
+		http.MethodPut, fileurl.String(), pr); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		var resp *http.Response
+		var err error
+
+		if resp, err = h.client.Do(req); err != nil {
+			pr.CloseWithError(err)
+			w.result <- err
+			return
+		}
+		defer resp.Body.Close()
+		pr.Close()
+		w.result <- checkStatus(resp)
+	}()
+
+	return w, nil
+}
+
+/*
+OpenWriterFromReader uploads the contents of r using a PUT request with r as
+the request body, without an intermediate pipe. If contentLength is -1, the
+body is sent using chunked transfer encoding. r is closed once the request
+completes.
+*/
+func (h *HTTPFileSystem) OpenWriterFromReader(ctx context.Context,
+	fileurl *url.URL, r filesystem.ReadCloser, contentLength int64) error {
+	var body = filesystem.ToIoReadCloser(filesystem.NewContextReadCloser(r, ctx))
+	var req *http.Request
+	var resp *http.Response
+	var err error
+
+	if req, err = http.NewRequestWithContext(
+		ctx, http.MethodPut, fileurl.String(), body); err != nil {
+		body.Close()
+		return err
+	}
+	req.ContentLength = contentLength
+
+	if resp, err = h.client.Do(req); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkStatus(resp)
+}
+
+/*
+OpenAppender is not supported by plain HTTP.
+*/
+func (h *HTTPFileSystem) OpenAppender(ctx context.Context, fileurl *url.URL) (
+	filesystem.WriteCloser, error) {
+	return nil, filesystem.EUNSUPP
+}
+
+/*
+ListEntries is not supported by plain HTTP.
+*/
+func (h *HTTPFileSystem) ListEntries(ctx context.Context, dirurl *url.URL) (
+	[]string, error) {
+	return nil, filesystem.EUNSUPP
+}
+
+/*
+WatchFile is not supported by plain HTTP.
+*/
+func (h *HTTPFileSystem) WatchFile(ctx context.Context, fileurl *url.URL,
+	watcher filesystem.FileWatchFunc) (
+	filesystem.CancelWatchFunc, chan error, error) {
+	return nil, nil, filesystem.EUNSUPP
+}
+
+/*
+Remove performs a DELETE request on the URL.
+*/
+func (h *HTTPFileSystem) Remove(ctx context.Context, fileurl *url.URL) error {
+	var resp, cancel, err = h.do(ctx, http.MethodDelete, fileurl, nil, nil)
+
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return resp.Body.Close()
+}
+
+/*
+Metadata about a HTTP resource, determined from the response headers.
+*/
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return false }
+func (fi *fileInfo) Mode() fs.FileMode  { return 0444 }
+
+/*
+Stat performs a HEAD request on the URL and reports the size and
+modification time indicated by the response headers. The size is -1 if the
+server did not send a Content-Length.
+*/
+func (h *HTTPFileSystem) Stat(ctx context.Context, fileurl *url.URL) (
+	filesystem.FileInfo, error) {
+	var resp, cancel, err = h.do(ctx, http.MethodHead, fileurl, nil, nil)
+	var fi = &fileInfo{name: path.Base(fileurl.Path)}
+
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	fi.size = resp.ContentLength
+	if lm := resp.Header.Get("Last-Modified"); lm != "" {
+		if fi.modTime, err = http.ParseTime(lm); err != nil {
+			fi.modTime = time.Time{}
+		}
+	}
+
+	return fi, nil
+}
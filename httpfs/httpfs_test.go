@@ -0,0 +1,260 @@
+package httpfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, map[string][]byte) {
+	files := map[string][]byte{"/hello.txt": []byte("hello world")}
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				if r.URL.Path == "/loop" {
+					http.Redirect(w, r, "/loop", http.StatusFound)
+					return
+				}
+				data, ok := files[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("ETag", `"v1"`)
+				http.ServeContent(w, r, r.URL.Path,
+					time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+					bytes.NewReader(data))
+			case http.MethodPut:
+				data, _ := io.ReadAll(r.Body)
+				files[r.URL.Path] = data
+				w.WriteHeader(http.StatusCreated)
+			}
+		}))
+	t.Cleanup(srv.Close)
+
+	return srv, files
+}
+
+func mustParse(t *testing.T, rawurl string) *url.URL {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		t.Fatalf("Cannot parse URL %s: %v", rawurl, err)
+	}
+	return u
+}
+
+func TestOpenReader(t *testing.T) {
+	srv, _ := newTestServer(t)
+	h := New(WithClient(srv.Client()))
+
+	rc, err := h.OpenReader(context.Background(),
+		mustParse(t, srv.URL+"/hello.txt"))
+	if err != nil {
+		t.Fatalf("Error reported from OpenReader: %v", err)
+	}
+	defer rc.Close(context.Background())
+
+	data, err := io.ReadAll(filesystem.ToIoReadCloser(rc))
+	if err != nil {
+		t.Errorf("Error reported from read: %v", err)
+	}
+	if string(data) != "hello world" {
+		t.Errorf("Unexpected contents %q", string(data))
+	}
+}
+
+func TestOpenReaderWithETag(t *testing.T) {
+	srv, _ := newTestServer(t)
+	h := New(WithClient(srv.Client()))
+
+	rc, etag, err := h.OpenReaderWithETag(context.Background(),
+		mustParse(t, srv.URL+"/hello.txt"))
+	if err != nil {
+		t.Fatalf("Error reported from OpenReaderWithETag: %v", err)
+	}
+	rc.Close(context.Background())
+
+	if etag != `"v1"` {
+		t.Errorf("Unexpected ETag %q", etag)
+	}
+}
+
+func TestOpenReaderWithLength(t *testing.T) {
+	srv, _ := newTestServer(t)
+	filesystem.AddImplementation("http", New(WithClient(srv.Client())))
+
+	rc, size, err := filesystem.OpenReaderWithLength(context.Background(),
+		mustParse(t, srv.URL+"/hello.txt"))
+	if err != nil {
+		t.Fatalf("Error reported from OpenReaderWithLength: %v", err)
+	}
+	defer rc.Close(context.Background())
+
+	if size != 11 {
+		t.Errorf("Unexpected length %d", size)
+	}
+	if data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc)); string(data) != "hello world" {
+		t.Errorf("Unexpected contents %q", string(data))
+	}
+}
+
+func TestOpenReaderNotFound(t *testing.T) {
+	srv, _ := newTestServer(t)
+	h := New(WithClient(srv.Client()))
+
+	_, err := h.OpenReader(context.Background(),
+		mustParse(t, srv.URL+"/missing"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error from OpenReader: %v", err)
+	}
+}
+
+func TestOpenReaderTooManyRedirects(t *testing.T) {
+	srv, _ := newTestServer(t)
+	h := New(WithClient(srv.Client()), WithMaxRedirects(2))
+
+	_, err := h.OpenReader(context.Background(), mustParse(t, srv.URL+"/loop"))
+	if !errors.Is(err, ErrTooManyRedirects) {
+		t.Errorf("Unexpected error from OpenReader: %v", err)
+	}
+}
+
+func TestOpenWriter(t *testing.T) {
+	srv, files := newTestServer(t)
+	h := New(WithClient(srv.Client()))
+
+	wc, err := h.OpenWriter(context.Background(),
+		mustParse(t, srv.URL+"/new.txt"))
+	if err != nil {
+		t.Fatalf("Error reported from OpenWriter: %v", err)
+	}
+	if _, err = wc.Write(context.Background(), []byte("new data")); err != nil {
+		t.Errorf("Error reported from Write: %v", err)
+	}
+	if err = wc.Close(context.Background()); err != nil {
+		t.Errorf("Error reported from Close: %v", err)
+	}
+
+	if string(files["/new.txt"]) != "new data" {
+		t.Errorf("Unexpected uploaded contents %q", string(files["/new.txt"]))
+	}
+}
+
+type stringReadCloser struct {
+	*strings.Reader
+	closed bool
+}
+
+func (s *stringReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	return s.Reader.Read(p)
+}
+
+func (s *stringReadCloser) Close(ctx context.Context) error {
+	s.closed = true
+	return nil
+}
+
+func TestOpenWriterFromReader(t *testing.T) {
+	srv, files := newTestServer(t)
+	h := New(WithClient(srv.Client()))
+
+	for _, length := range []int64{8, -1} {
+		r := &stringReadCloser{Reader: strings.NewReader("streamed")}
+		if err := h.OpenWriterFromReader(context.Background(),
+			mustParse(t, srv.URL+"/streamed.txt"), r, length); err != nil {
+			t.Errorf("Error reported from OpenWriterFromReader: %v", err)
+		}
+		if string(files["/streamed.txt"]) != "streamed" {
+			t.Errorf("Unexpected uploaded contents %q", string(files["/streamed.txt"]))
+		}
+		if !r.closed {
+			t.Error("Reader was not closed")
+		}
+	}
+}
+
+func TestStat(t *testing.T) {
+	srv, _ := newTestServer(t)
+	h := New(WithClient(srv.Client()))
+
+	fi, err := h.Stat(context.Background(), mustParse(t, srv.URL+"/hello.txt"))
+	if err != nil {
+		t.Fatalf("Error reported from Stat: %v", err)
+	}
+	if fi.Name() != "hello.txt" {
+		t.Errorf("Unexpected name %q", fi.Name())
+	}
+	if fi.Size() != 11 {
+		t.Errorf("Unexpected size %d", fi.Size())
+	}
+	if fi.ModTime().Year() != 2006 {
+		t.Errorf("Unexpected modification time %v", fi.ModTime())
+	}
+}
+
+func TestOpenReaderRange(t *testing.T) {
+	srv, _ := newTestServer(t)
+	filesystem.AddImplementation("http", New(WithClient(srv.Client())))
+	u := mustParse(t, srv.URL+"/hello.txt")
+
+	for _, tc := range []struct {
+		start, end int64
+		expected   string
+	}{
+		{6, -1, "world"},
+		{0, 5, "hello"},
+		{4, 7, "o w"},
+		{5, 5, ""},
+		{11, -1, ""},
+		{20, 30, ""},
+	} {
+		rc, err := filesystem.OpenReaderRange(context.Background(), u,
+			tc.start, tc.end)
+		if err != nil {
+			t.Errorf("Error reported from OpenReaderRange(%d, %d): %v",
+				tc.start, tc.end, err)
+			continue
+		}
+		data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc))
+		rc.Close(context.Background())
+		if string(data) != tc.expected {
+			t.Errorf("Unexpected data for range %d-%d: %q", tc.start, tc.end, data)
+		}
+	}
+}
+
+func TestOpenReaderIfModifiedSince(t *testing.T) {
+	srv, _ := newTestServer(t)
+	filesystem.AddImplementation("http", New(WithClient(srv.Client())))
+	u := mustParse(t, srv.URL+"/hello.txt")
+	modTime := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	rc, modified, err := filesystem.OpenReaderIfModifiedSince(
+		context.Background(), u, modTime)
+	if err != nil || modified || rc != nil {
+		t.Errorf("Unexpected result for unmodified file: %v, %v", modified, err)
+	}
+
+	rc, modified, err = filesystem.OpenReaderIfModifiedSince(
+		context.Background(), u, modTime.Add(-time.Hour))
+	if err != nil || !modified {
+		t.Fatalf("Unexpected result for modified file: %v, %v", modified, err)
+	}
+	data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc))
+	rc.Close(context.Background())
+	if string(data) != "hello world" {
+		t.Errorf("Unexpected data %q", data)
+	}
+}
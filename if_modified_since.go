@@ -0,0 +1,61 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+/*
+ConditionalReadingFileSystem is implemented by file systems which can check
+the modification time of a file and open it in a single request, like HTTP
+servers supporting If-Modified-Since.
+*/
+type ConditionalReadingFileSystem interface {
+	// Open the file for reading if it was modified after the specified
+	// time, or return nil, false, nil otherwise.
+	OpenReaderIfModifiedSince(context.Context, *url.URL, time.Time) (
+		ReadCloser, bool, error)
+}
+
+/*
+OpenReaderIfModifiedSince opens the referenced file for reading only if it
+was modified after since, and returns nil, false, nil otherwise. Callers
+polling a file can pass the ModTime from the previous read to avoid
+downloading unchanged contents.
+
+File systems not implementing ConditionalReadingFileSystem are asked for the
+modification time using Stat first. If they do not support Stat either, the
+file is always opened.
+*/
+func OpenReaderIfModifiedSince(ctx context.Context, fileurl *url.URL,
+	since time.Time) (ReadCloser, bool, error) {
+	var fs = GetImplementation(fileurl)
+	var rc ReadCloser
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return nil, false, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if cfs, ok := fs.(ConditionalReadingFileSystem); ok {
+		return cfs.OpenReaderIfModifiedSince(ctx, fileurl, since)
+	}
+
+	if fi, err := fs.Stat(ctx, fileurl); err == nil {
+		if !fi.ModTime().After(since) {
+			return nil, false, nil
+		}
+	} else if err != EUNSUPP {
+		return nil, false, err
+	}
+
+	if rc, err = fs.OpenReader(ctx, fileurl); err != nil {
+		return nil, false, err
+	}
+	return rc, true, nil
+}
@@ -0,0 +1,40 @@
+package filesystem_test
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestOpenReaderIfModifiedSinceFallback(t *testing.T) {
+	filesystem.AddImplementation("ifmodified", virtualfs.NewVirtualFileSystem())
+	u := &url.URL{Scheme: "ifmodified", Path: "/file"}
+	ctx := context.Background()
+	modTime := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	writeTestFile(t, u, "contents")
+	if err := filesystem.TouchWithTime(ctx, u, modTime); err != nil {
+		t.Fatalf("Error reported from TouchWithTime: %v", err)
+	}
+
+	rc, modified, err := filesystem.OpenReaderIfModifiedSince(ctx, u, modTime)
+	if err != nil || modified || rc != nil {
+		t.Errorf("Unexpected result for unmodified file: %v, %v", modified, err)
+	}
+
+	rc, modified, err = filesystem.OpenReaderIfModifiedSince(ctx, u,
+		modTime.Add(-time.Hour))
+	if err != nil || !modified {
+		t.Fatalf("Unexpected result for modified file: %v, %v", modified, err)
+	}
+	data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc))
+	rc.Close(ctx)
+	if string(data) != "contents" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+}
@@ -0,0 +1,163 @@
+package filesystem
+
+import (
+	"context"
+	iofs "io/fs"
+	"net/url"
+	"path"
+	"strings"
+)
+
+/*
+Implementation of a reader for a file opened from an fs.FS.
+*/
+type ioFSReadCloser struct {
+	f iofs.File
+}
+
+/*
+Read reads from the file, returning early if ctx is already done. fs.File
+has no notion of contexts, so reads in progress cannot be cancelled.
+*/
+func (r *ioFSReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.f.Read(p)
+}
+
+/*
+Close closes the file.
+*/
+func (r *ioFSReadCloser) Close(ctx context.Context) error {
+	return r.f.Close()
+}
+
+/*
+Read-only FileSystem adapter for an fs.FS.
+*/
+type ioFSFileSystem struct {
+	fsys   iofs.FS
+	scheme string
+}
+
+/*
+FromIoFS adapts fsys to the FileSystem API, e.g. to serve files embedded
+using embed.FS, or os.DirFS. The path of a URL, including its host if set,
+is used as the path within fsys. URLs with schemes other than scheme are
+rejected with fs.ErrInvalid.
+
+The file system is read-only: writing, watching and removing files returns
+EUNSUPP.
+*/
+func FromIoFS(fsys iofs.FS, scheme string) FileSystem {
+	return &ioFSFileSystem{fsys: fsys, scheme: scheme}
+}
+
+/*
+name converts the URL to a path suitable for fs.FS.
+*/
+func (f *ioFSFileSystem) name(op string, u *url.URL) (string, error) {
+	var name = strings.TrimPrefix(path.Join("/", u.Host, u.Path), "/")
+
+	if u.Scheme != f.scheme {
+		return "", &iofs.PathError{Op: op, Path: u.String(), Err: iofs.ErrInvalid}
+	}
+	if name == "" {
+		return ".", nil
+	}
+	return name, nil
+}
+
+/*
+OpenReader opens the file in the fs.FS.
+*/
+func (f *ioFSFileSystem) OpenReader(ctx context.Context, u *url.URL) (ReadCloser, error) {
+	var name string
+	var file iofs.File
+	var err error
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	if name, err = f.name("open", u); err != nil {
+		return nil, err
+	}
+	if file, err = f.fsys.Open(name); err != nil {
+		return nil, err
+	}
+
+	return &ioFSReadCloser{f: file}, nil
+}
+
+/*
+OpenWriter is not supported, since fs.FS is read-only.
+*/
+func (f *ioFSFileSystem) OpenWriter(ctx context.Context, u *url.URL) (WriteCloser, error) {
+	return nil, EUNSUPP
+}
+
+/*
+OpenAppender is not supported, since fs.FS is read-only.
+*/
+func (f *ioFSFileSystem) OpenAppender(ctx context.Context, u *url.URL) (WriteCloser, error) {
+	return nil, EUNSUPP
+}
+
+/*
+ListEntries lists the names of all entries of the directory in the fs.FS.
+*/
+func (f *ioFSFileSystem) ListEntries(ctx context.Context, u *url.URL) ([]string, error) {
+	var name string
+	var entries []iofs.DirEntry
+	var names []string
+	var err error
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	if name, err = f.name("readdir", u); err != nil {
+		return nil, err
+	}
+	if entries, err = iofs.ReadDir(f.fsys, name); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+/*
+WatchFile is not supported, since fs.FS has no change notifications.
+*/
+func (f *ioFSFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	return nil, nil, EUNSUPP
+}
+
+/*
+Remove is not supported, since fs.FS is read-only.
+*/
+func (f *ioFSFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	return EUNSUPP
+}
+
+/*
+Stat retrieves information about the file from the fs.FS. fs.FileInfo
+satisfies FileInfo as is.
+*/
+func (f *ioFSFileSystem) Stat(ctx context.Context, u *url.URL) (FileInfo, error) {
+	var name string
+	var err error
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	if name, err = f.name("stat", u); err != nil {
+		return nil, err
+	}
+
+	return iofs.Stat(f.fsys, name)
+}
@@ -0,0 +1,43 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	iofstest "testing/fstest"
+
+	"github.com/childoftheuniverse/filesystem"
+)
+
+func TestAddFsImplementation(t *testing.T) {
+	filesystem.AddFsImplementation("assets", iofstest.MapFS{
+		"index.html":    {Data: []byte("<html>")},
+		"css/style.css": {Data: []byte("body {}")},
+		"css/print.css": {Data: []byte("@media print {}")},
+	})
+
+	data, err := readTestFile(t, &url.URL{Scheme: "assets", Path: "/css/style.css"})
+	if err != nil || data != "body {}" {
+		t.Errorf("Unexpected contents %q, error %v", data, err)
+	}
+
+	entries, err := filesystem.ListEntries(context.Background(),
+		&url.URL{Scheme: "assets", Path: "/css"})
+	if err != nil {
+		t.Fatalf("Error reported from ListEntries: %v", err)
+	}
+	if strings.Join(entries, ",") != "print.css,style.css" {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+
+	fi, err := filesystem.Stat(context.Background(), &url.URL{Scheme: "assets", Path: "/"})
+	if err != nil || !fi.IsDir() {
+		t.Errorf("Root not reported as directory: %v, %v", fi, err)
+	}
+
+	if _, err = filesystem.OpenWriter(context.Background(),
+		&url.URL{Scheme: "assets", Path: "/new"}); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP from OpenWriter, got %v", err)
+	}
+}
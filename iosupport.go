@@ -2,9 +2,19 @@ package filesystem
 
 import (
 	"context"
+	"errors"
 	"io"
 )
 
+/*
+Errors returned by the Seek method of the io compatibility wrappers, like
+the ones returned by os.File.
+*/
+var (
+	errInvalidWhence  = errors.New("Seek: invalid whence")
+	errNegativeOffset = errors.New("Seek: negative position")
+)
+
 /*
 Implementation of a wrapper for ReadCloser which ignores deadlines and
 cancellations to make it compatible with io.ReadCloser.
@@ -29,6 +39,19 @@ func (rc *ioCompatReadCloser) Close() error {
 	return rc.readCloser.Close(ctx)
 }
 
+/*
+WriteTo writes the contents of the underlying ReadCloser to w. If it
+implements io.WriterTo, the copy is delegated to it so that wrappers such as
+LimitedReadCloser can avoid intermediate buffers in io.Copy.
+*/
+func (rc *ioCompatReadCloser) WriteTo(w io.Writer) (int64, error) {
+	if wt, ok := rc.readCloser.(io.WriterTo); ok {
+		return wt.WriteTo(w)
+	}
+	// Hide the WriteTo method from io.Copy to avoid recursion.
+	return io.Copy(w, struct{ io.Reader }{rc})
+}
+
 /*
 ReadCloser is a context-aware variant of the good old io.ReadCloser.
 */
@@ -85,6 +108,54 @@ func ToIoWriteCloser(wc WriteCloser) io.WriteCloser {
 	return &ioCompatWriteCloser{writeCloser: wc}
 }
 
+/*
+ReadWriteCloser is a context-aware variant of the good old io.ReadWriteCloser.
+*/
+type ReadWriteCloser interface {
+	ReadCloser
+	WriteCloser
+}
+
+/*
+Implementation of a wrapper for ReadWriteCloser which ignores deadlines and
+cancellations to make it compatible with io.ReadWriteCloser.
+*/
+type ioCompatReadWriteCloser struct {
+	readWriteCloser ReadWriteCloser
+}
+
+/*
+See io.ReadWriteCloser#Read
+*/
+func (rwc *ioCompatReadWriteCloser) Read(p []byte) (int, error) {
+	var ctx = context.Background()
+	return rwc.readWriteCloser.Read(ctx, p)
+}
+
+/*
+See io.ReadWriteCloser#Write
+*/
+func (rwc *ioCompatReadWriteCloser) Write(p []byte) (int, error) {
+	var ctx = context.Background()
+	return rwc.readWriteCloser.Write(ctx, p)
+}
+
+/*
+See io.ReadWriteCloser#Close
+*/
+func (rwc *ioCompatReadWriteCloser) Close() error {
+	var ctx = context.Background()
+	return rwc.readWriteCloser.Close(ctx)
+}
+
+/*
+ToIoReadWriter creates a context-ignorant object for providing an
+io.ReadWriteCloser compatible API.
+*/
+func ToIoReadWriter(rwc ReadWriteCloser) io.ReadWriteCloser {
+	return &ioCompatReadWriteCloser{readWriteCloser: rwc}
+}
+
 /*
 Seeker is a context-aware variant of the good old io.Seeker.
 */
@@ -92,3 +163,165 @@ type Seeker interface {
 	Tell(context.Context) (int64, error)
 	Seek(context.Context, int64, int) (int64, error)
 }
+
+/*
+Implementation of a ReadCloser which uses a fixed context for all
+operations, regardless of the context passed by the caller.
+*/
+type fixedContextReadCloser struct {
+	readCloser ReadCloser
+	ctx        context.Context
+}
+
+/*
+Read reads from the underlying ReadCloser using the fixed context.
+*/
+func (rc *fixedContextReadCloser) Read(_ context.Context, p []byte) (int, error) {
+	return rc.readCloser.Read(rc.ctx, p)
+}
+
+/*
+Close closes the underlying ReadCloser using the fixed context.
+*/
+func (rc *fixedContextReadCloser) Close(_ context.Context) error {
+	return rc.readCloser.Close(rc.ctx)
+}
+
+/*
+NewContextReadCloser creates a ReadCloser which ignores the contexts passed
+to Read and Close, and uses ctx instead. This allows the deadline of ctx to
+apply even when the ReadCloser is handed to code passing
+context.Background().
+*/
+func NewContextReadCloser(r ReadCloser, ctx context.Context) ReadCloser {
+	return &fixedContextReadCloser{readCloser: r, ctx: ctx}
+}
+
+/*
+Implementation of a WriteCloser which uses a fixed context for all
+operations, regardless of the context passed by the caller.
+*/
+type fixedContextWriteCloser struct {
+	writeCloser WriteCloser
+	ctx         context.Context
+}
+
+/*
+Write writes to the underlying WriteCloser using the fixed context.
+*/
+func (wc *fixedContextWriteCloser) Write(_ context.Context, p []byte) (int, error) {
+	return wc.writeCloser.Write(wc.ctx, p)
+}
+
+/*
+Close closes the underlying WriteCloser using the fixed context.
+*/
+func (wc *fixedContextWriteCloser) Close(_ context.Context) error {
+	return wc.writeCloser.Close(wc.ctx)
+}
+
+/*
+NewContextWriteCloser creates a WriteCloser which ignores the contexts
+passed to Write and Close, and uses ctx instead.
+*/
+func NewContextWriteCloser(w WriteCloser, ctx context.Context) WriteCloser {
+	return &fixedContextWriteCloser{writeCloser: w, ctx: ctx}
+}
+
+/*
+ReaderAt is a context-aware variant of the good old io.ReaderAt.
+*/
+type ReaderAt interface {
+	ReadAt(context.Context, []byte, int64) (int, error)
+}
+
+/*
+WriterAt is a context-aware variant of the good old io.WriterAt.
+*/
+type WriterAt interface {
+	WriteAt(context.Context, []byte, int64) (int, error)
+}
+
+/*
+Implementation of a wrapper for ReadWriteAtCloser which keeps track of the
+current offset itself to provide an io.ReadWriteSeeker compatible API.
+*/
+type ioCompatReadWriteSeekCloser struct {
+	rwc    ReadWriteAtCloser
+	offset int64
+}
+
+/*
+See io.Reader#Read
+*/
+func (s *ioCompatReadWriteSeekCloser) Read(p []byte) (int, error) {
+	var n, err = s.rwc.ReadAt(context.Background(), p, s.offset)
+
+	s.offset += int64(n)
+	if n > 0 && err == io.EOF {
+		// ReadAt reports EOF for short reads; Read reports it only once no
+		// more data is available.
+		err = nil
+	}
+	return n, err
+}
+
+/*
+See io.Writer#Write
+*/
+func (s *ioCompatReadWriteSeekCloser) Write(p []byte) (int, error) {
+	var n, err = s.rwc.WriteAt(context.Background(), p, s.offset)
+
+	s.offset += int64(n)
+	return n, err
+}
+
+/*
+See io.Seeker#Seek. Seeking relative to the end of the file requires the
+underlying ReadWriteAtCloser to implement Seeker.
+*/
+func (s *ioCompatReadWriteSeekCloser) Seek(offset int64, whence int) (int64, error) {
+	var base int64
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		base = s.offset
+	case io.SeekEnd:
+		var seeker, ok = s.rwc.(Seeker)
+		var err error
+
+		if !ok {
+			return s.offset, EUNSUPP
+		}
+		if base, err = seeker.Seek(context.Background(), 0, io.SeekEnd); err != nil {
+			return s.offset, err
+		}
+	default:
+		return s.offset, errInvalidWhence
+	}
+
+	if base+offset < 0 {
+		return s.offset, errNegativeOffset
+	}
+
+	s.offset = base + offset
+	return s.offset, nil
+}
+
+/*
+See io.Closer#Close
+*/
+func (s *ioCompatReadWriteSeekCloser) Close() error {
+	return s.rwc.Close(context.Background())
+}
+
+/*
+ToIoReadWriteSeekCloser creates a context-ignorant object for providing an
+io.ReadWriteSeeker compatible API on top of a ReadWriteAtCloser, e.g. for
+handing files to database drivers. The returned object also implements
+io.Closer.
+*/
+func ToIoReadWriteSeekCloser(rwc ReadWriteAtCloser) io.ReadWriteSeeker {
+	return &ioCompatReadWriteSeekCloser{rwc: rwc}
+}
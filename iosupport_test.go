@@ -0,0 +1,130 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+type NullReadCloser struct{}
+
+func (r NullReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (r NullReadCloser) Close(ctx context.Context) error {
+	return nil
+}
+
+type NullWriteCloser struct{}
+
+func (w NullWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w NullWriteCloser) Close(ctx context.Context) error {
+	return nil
+}
+
+func BenchmarkToIoReadCloser(b *testing.B) {
+	buf := make([]byte, 4096)
+	r := ToIoReadCloser(NullReadCloser{})
+
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		if _, err := r.Read(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkToIoWriteCloser(b *testing.B) {
+	buf := make([]byte, 4096)
+	var w io.Writer = ToIoWriteCloser(NullWriteCloser{})
+
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		if _, err := w.Write(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCountingNullWriteCloser(t *testing.T) {
+	w := &CountingNullWriteCloser{}
+
+	w.Write(context.Background(), make([]byte, 10))
+	w.Write(context.Background(), make([]byte, 5))
+
+	if n := w.BytesDiscarded(); n != 15 {
+		t.Errorf("Unexpected number of bytes discarded: %d", n)
+	}
+}
+
+func BenchmarkCountingNullWriteCloser(b *testing.B) {
+	buf := make([]byte, 4096)
+	w := &CountingNullWriteCloser{}
+
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		w.Write(context.Background(), buf)
+	}
+}
+
+type ContextRecordingReadWriteCloser struct {
+	Contexts []context.Context
+}
+
+func (c *ContextRecordingReadWriteCloser) Read(ctx context.Context, p []byte) (int, error) {
+	c.Contexts = append(c.Contexts, ctx)
+	return 0, io.EOF
+}
+
+func (c *ContextRecordingReadWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	c.Contexts = append(c.Contexts, ctx)
+	return len(p), nil
+}
+
+func (c *ContextRecordingReadWriteCloser) Close(ctx context.Context) error {
+	c.Contexts = append(c.Contexts, ctx)
+	return nil
+}
+
+type contextKey struct{}
+
+func TestNewContextReadCloser(t *testing.T) {
+	fixed := context.WithValue(context.Background(), contextKey{}, "fixed")
+	inner := &ContextRecordingReadWriteCloser{}
+	rc := NewContextReadCloser(inner, fixed)
+
+	rc.Read(context.Background(), make([]byte, 1))
+	rc.Close(context.TODO())
+
+	if len(inner.Contexts) != 2 {
+		t.Fatalf("Expected 2 calls, got %d", len(inner.Contexts))
+	}
+	for i, ctx := range inner.Contexts {
+		if ctx != fixed {
+			t.Errorf("Call %d did not use the fixed context", i)
+		}
+	}
+}
+
+func TestNewContextWriteCloser(t *testing.T) {
+	fixed, cancel := context.WithCancel(context.Background())
+	inner := &ContextRecordingReadWriteCloser{}
+	wc := NewContextWriteCloser(inner, fixed)
+
+	cancel()
+	wc.Write(context.Background(), []byte("data"))
+	wc.Close(context.Background())
+
+	if len(inner.Contexts) != 2 {
+		t.Fatalf("Expected 2 calls, got %d", len(inner.Contexts))
+	}
+	for i, ctx := range inner.Contexts {
+		if ctx.Err() != context.Canceled {
+			t.Errorf("Call %d did not use the fixed context", i)
+		}
+	}
+}
@@ -2,9 +2,15 @@ package filesystem
 
 import (
 	"context"
+	"errors"
 	"io"
 )
 
+/*
+Internal marker error used to stop WriteTo once the limit has been reached.
+*/
+var errLimitReached = errors.New("Read limit reached")
+
 /*
 LimitedReadCloser is the equivalent to io.LimitedReader for the filesystem API.
 */
@@ -42,3 +48,114 @@ Close calls the close method of the underlying implementation.
 func (l *LimitedReadCloser) Close(ctx context.Context) error {
 	return l.R.Close(ctx)
 }
+
+/*
+Seek moves the position of the underlying reader, which has to implement
+Seeker, and adjusts N so that the end of the readable region stays in
+place. Positions are those of the underlying reader. With io.SeekEnd,
+offsets are relative to the limit rather than the end of the file; positive
+offsets are clamped to the limit. Seeking beyond the limit leaves nothing to
+read, and the end of the readable region moves to the new position.
+*/
+func (l *LimitedReadCloser) Seek(ctx context.Context, offset int64, whence int) (int64, error) {
+	var seeker, ok = l.R.(Seeker)
+	var cur, end, pos int64
+	var err error
+
+	if !ok {
+		return 0, EUNSUPP
+	}
+
+	if cur, err = seeker.Tell(ctx); err != nil {
+		return 0, err
+	}
+	end = cur + l.N
+
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = cur + offset
+	case io.SeekEnd:
+		if offset > 0 {
+			offset = 0
+		}
+		pos = end + offset
+	default:
+		return cur, errInvalidWhence
+	}
+
+	if pos, err = seeker.Seek(ctx, pos, io.SeekStart); err != nil {
+		return cur, err
+	}
+	if l.N = end - pos; l.N < 0 {
+		l.N = 0
+	}
+	return pos, nil
+}
+
+/*
+Writer which forwards at most n bytes to the underlying writer.
+*/
+type limitedWriter struct {
+	w io.Writer
+	n int64
+}
+
+/*
+Write forwards p to the underlying writer, truncating it to the remaining
+limit. Returns errLimitReached once the limit has been exhausted.
+*/
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	var n int
+	var err error
+
+	if l.n <= 0 {
+		return 0, errLimitReached
+	}
+	if int64(len(p)) > l.n {
+		n, err = l.w.Write(p[0:l.n])
+		l.n -= int64(n)
+		if err == nil {
+			err = errLimitReached
+		}
+		return n, err
+	}
+	n, err = l.w.Write(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+/*
+WriteTo writes up to N bytes from the underlying reader to w, ignoring
+deadlines and cancellations. This allows io.Copy to avoid an intermediate
+buffer.
+
+If R implements io.WriterTo, the copy is delegated to it. Note that in this
+case R may consume more than N bytes from its source, even though only N
+bytes are written to w.
+*/
+func (l *LimitedReadCloser) WriteTo(w io.Writer) (int64, error) {
+	var lw *limitedWriter
+	var n int64
+	var err error
+
+	if l.N <= 0 {
+		return 0, nil
+	}
+
+	if wt, ok := l.R.(io.WriterTo); ok {
+		lw = &limitedWriter{w: w, n: l.N}
+		n, err = wt.WriteTo(lw)
+		l.N = lw.n
+		if errors.Is(err, errLimitReached) {
+			err = nil
+		}
+		return n, err
+	}
+
+	n, err = io.Copy(w, io.LimitReader(
+		struct{ io.Reader }{ToIoReadCloser(l.R)}, l.N))
+	l.N -= n
+	return n, err
+}
@@ -1,8 +1,10 @@
 package filesystem
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"math/rand"
 	"testing"
@@ -112,3 +114,231 @@ func TestLimitedReadCloserForwardsCloseError(t *testing.T) {
 		t.Errorf("Unexpected error from Close: %v", err)
 	}
 }
+
+type SeekableMockReadCloser struct {
+	data []byte
+	pos  int64
+}
+
+func (r *SeekableMockReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	if r.pos >= int64(len(r.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data[r.pos:])
+	r.pos += int64(n)
+	return n, nil
+}
+
+func (r *SeekableMockReadCloser) Close(ctx context.Context) error {
+	return nil
+}
+
+func (r *SeekableMockReadCloser) Tell(ctx context.Context) (int64, error) {
+	return r.pos, nil
+}
+
+func (r *SeekableMockReadCloser) Seek(ctx context.Context, offset int64, whence int) (int64, error) {
+	r.pos = offset
+	return r.pos, nil
+}
+
+func TestLimitedReadCloserSeek(t *testing.T) {
+	ctx := context.Background()
+	r := &SeekableMockReadCloser{data: []byte("0123456789"), pos: 2}
+	l := &LimitedReadCloser{R: r, N: 5}
+	buf := make([]byte, 10)
+
+	if pos, err := l.Seek(ctx, -2, io.SeekEnd); err != nil || pos != 5 {
+		t.Fatalf("Seek from end returned %d, %v; expected 5", pos, err)
+	}
+	n, _ := l.Read(ctx, buf)
+	if string(buf[:n]) != "56" {
+		t.Errorf("Read %q after seeking from end, expected \"56\"", buf[:n])
+	}
+
+	if pos, err := l.Seek(ctx, 3, io.SeekEnd); err != nil || pos != 7 {
+		t.Errorf("Seek beyond end returned %d, %v; expected clamping to 7", pos, err)
+	}
+
+	if pos, err := l.Seek(ctx, 3, io.SeekStart); err != nil || pos != 3 {
+		t.Fatalf("Seek from start returned %d, %v; expected 3", pos, err)
+	}
+	n, _ = l.Read(ctx, buf)
+	if string(buf[:n]) != "3456" {
+		t.Errorf("Read %q after seeking from start, expected \"3456\"", buf[:n])
+	}
+
+	if pos, err := l.Seek(ctx, 9, io.SeekStart); err != nil || pos != 9 {
+		t.Fatalf("Seek beyond limit returned %d, %v; expected 9", pos, err)
+	}
+	if l.N != 0 {
+		t.Errorf("Limit is %d after seeking beyond it, expected 0", l.N)
+	}
+	if n, err := l.Read(ctx, buf); n != 0 || err != io.EOF {
+		t.Errorf("Read returned %d, %v after seeking beyond limit", n, err)
+	}
+
+	if _, err := (&LimitedReadCloser{R: &MockReadCloser{}, N: 5}).Seek(
+		ctx, 0, io.SeekStart); err != EUNSUPP {
+		t.Errorf("Seek on unseekable reader returned %v, expected EUNSUPP", err)
+	}
+}
+
+type MockWriterToReadCloser struct {
+	MockReadCloser
+	Data []byte
+}
+
+func (r *MockWriterToReadCloser) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(r.Data)
+	return int64(n), err
+}
+
+func TestLimitedReadCloserWriteToDelegates(t *testing.T) {
+	var buf bytes.Buffer
+	mockReadCloser := &MockWriterToReadCloser{Data: []byte("hello world")}
+
+	l := &LimitedReadCloser{R: mockReadCloser, N: 5}
+
+	n, err := io.Copy(&buf, ToIoReadCloser(l))
+	if err != nil {
+		t.Errorf("Error reported from copy: %s", err.Error())
+	}
+	if n != 5 || buf.String() != "hello" {
+		t.Errorf("Unexpected copy result %d, %q", n, buf.String())
+	}
+	if l.N != 0 {
+		t.Errorf("Remaining limit is %d, expected 0", l.N)
+	}
+}
+
+type WrappingWriterToReadCloser struct {
+	MockWriterToReadCloser
+}
+
+func (r *WrappingWriterToReadCloser) WriteTo(w io.Writer) (int64, error) {
+	n, err := r.MockWriterToReadCloser.WriteTo(w)
+	if err != nil {
+		err = fmt.Errorf("Writing data: %w", err)
+	}
+	return n, err
+}
+
+func TestLimitedReadCloserWriteToWrappedLimit(t *testing.T) {
+	var buf bytes.Buffer
+	l := &LimitedReadCloser{R: &WrappingWriterToReadCloser{
+		MockWriterToReadCloser{Data: []byte("hello world")}}, N: 5}
+
+	n, err := l.WriteTo(&buf)
+	if err != nil {
+		t.Errorf("Error reported from WriteTo: %v", err)
+	}
+	if n != 5 || buf.String() != "hello" {
+		t.Errorf("Unexpected WriteTo result %d, %q", n, buf.String())
+	}
+}
+
+func TestLimitedReadCloserWriteToWithoutWriterTo(t *testing.T) {
+	var buf bytes.Buffer
+	mockReadCloser := &MockReadCloser{}
+
+	l := &LimitedReadCloser{R: mockReadCloser, N: 125}
+
+	n, err := l.WriteTo(&buf)
+	if err != nil {
+		t.Errorf("Error reported from WriteTo: %s", err.Error())
+	}
+	if n != 125 || buf.Len() != 125 {
+		t.Errorf("Unexpected WriteTo result %d, %d", n, buf.Len())
+	}
+}
+
+func benchmarkLimitedReadCloser(b *testing.B, size int) {
+	buf := make([]byte, size)
+	ctx := context.Background()
+
+	b.SetBytes(int64(size))
+	for i := 0; i < b.N; i++ {
+		l := LimitedReadCloser{R: NullReadCloser{}, N: int64(size)}
+		if _, err := l.Read(ctx, buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLimitedReadCloserSmallReads(b *testing.B) {
+	benchmarkLimitedReadCloser(b, 16)
+}
+
+func BenchmarkLimitedReadCloserLargeReads(b *testing.B) {
+	benchmarkLimitedReadCloser(b, 1024*1024)
+}
+
+type FuzzReadCloser struct {
+	Data        []byte
+	EOFWithData bool
+}
+
+func (r *FuzzReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	if len(r.Data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.Data)
+	r.Data = r.Data[n:]
+	if r.EOFWithData && len(r.Data) == 0 {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *FuzzReadCloser) Close(ctx context.Context) error {
+	return nil
+}
+
+func FuzzLimitedReadCloser(f *testing.F) {
+	f.Add(int64(0), uint16(10), uint16(10), false)
+	f.Add(int64(-1), uint16(10), uint16(10), false)
+	f.Add(int64(10), uint16(10), uint16(10), true)
+	f.Add(int64(10), uint16(100), uint16(10), false)
+	f.Add(int64(100), uint16(10), uint16(7), true)
+	f.Add(int64(11), uint16(10), uint16(10), false)
+
+	f.Fuzz(func(t *testing.T, n int64, dataLen, bufLen uint16, eofWithData bool) {
+		var total int64
+		var err error
+
+		if bufLen == 0 {
+			bufLen = 1
+		}
+		buf := make([]byte, bufLen)
+		l := LimitedReadCloser{
+			R: &FuzzReadCloser{
+				Data:        make([]byte, dataLen),
+				EOFWithData: eofWithData,
+			},
+			N: n,
+		}
+
+		// Every read makes progress, so EOF must be reached after at most
+		// dataLen + 1 reads returning data plus one reporting EOF.
+		for i := 0; i <= int(dataLen)+2 && err == nil; i++ {
+			var read int
+
+			read, err = l.Read(context.Background(), buf)
+			if read < 0 || read > len(buf) {
+				t.Fatalf("Read returned invalid length %d", read)
+			}
+			total += int64(read)
+		}
+
+		if err != io.EOF {
+			t.Errorf("Expected EOF, got %v", err)
+		}
+		if total > n && total > 0 {
+			t.Errorf("Read %d bytes, exceeding limit %d", total, n)
+		}
+		if expected := min(max(n, 0), int64(dataLen)); total != expected {
+			t.Errorf("Read %d bytes, expected %d", total, expected)
+		}
+	})
+}
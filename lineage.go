@@ -0,0 +1,99 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+)
+
+/*
+LineageMetadataKey is the metadata key the inputs of a file are recorded
+under by WithLineage.
+*/
+const LineageMetadataKey = "lineage"
+
+/*
+WriteCloser which records the lineage of the file when it is closed.
+*/
+type lineageWriteCloser struct {
+	w       WriteCloser
+	fileurl *url.URL
+	inputs  []*url.URL
+}
+
+/*
+Write writes to the underlying WriteCloser.
+*/
+func (l *lineageWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	return l.w.Write(ctx, p)
+}
+
+/*
+Close closes the underlying WriteCloser, then records the lineage as
+metadata of the file.
+*/
+func (l *lineageWriteCloser) Close(ctx context.Context) error {
+	var inputs = make([]string, len(l.inputs))
+	var data []byte
+	var err error
+
+	if err = l.w.Close(ctx); err != nil {
+		return err
+	}
+
+	for i, input := range l.inputs {
+		inputs[i] = input.String()
+	}
+	if data, err = json.Marshal(inputs); err != nil {
+		return err
+	}
+
+	return SetMetadata(ctx, l.fileurl,
+		map[string]string{LineageMetadataKey: string(data)})
+}
+
+/*
+WithLineage wraps wc, which must be writing to fileurl, into a WriteCloser
+recording inputs as the files the output was produced from. The lineage is
+stored as metadata of the file when it is closed, so the file system must
+implement MetadataFileSystem; otherwise Close returns EUNSUPP after closing
+wc.
+
+The URL of the file has to be passed explicitly since it cannot be
+determined from a WriteCloser.
+*/
+func WithLineage(wc WriteCloser, fileurl *url.URL, inputs []*url.URL) WriteCloser {
+	return &lineageWriteCloser{w: wc, fileurl: fileurl, inputs: inputs}
+}
+
+/*
+GetLineage retrieves the inputs recorded for the referenced file by
+WithLineage. Files without recorded lineage have no inputs.
+*/
+func GetLineage(ctx context.Context, fileurl *url.URL) ([]*url.URL, error) {
+	var metadata map[string]string
+	var raw []string
+	var inputs []*url.URL
+	var err error
+
+	if metadata, err = GetMetadata(ctx, fileurl); err != nil {
+		return nil, err
+	}
+
+	if data, ok := metadata[LineageMetadataKey]; !ok {
+		return nil, nil
+	} else if err = json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, err
+	}
+
+	for _, r := range raw {
+		var u *url.URL
+
+		if u, err = ParseURL(r); err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, u)
+	}
+
+	return inputs, nil
+}
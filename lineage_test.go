@@ -0,0 +1,43 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestLineage(t *testing.T) {
+	filesystem.AddImplementation("lineage", virtualfs.NewVirtualFileSystem())
+	ctx := context.Background()
+	out := &url.URL{Scheme: "lineage", Path: "/output"}
+	inputs := []*url.URL{
+		{Scheme: "lineage", Path: "/input/a"},
+		{Scheme: "s3", Host: "bucket", Path: "/b", RawQuery: "versionId=3"},
+	}
+
+	wc, err := filesystem.OpenWriter(ctx, out)
+	if err != nil {
+		t.Fatalf("Error reported from OpenWriter: %v", err)
+	}
+	wc = filesystem.WithLineage(wc, out, inputs)
+	wc.Write(ctx, []byte("result"))
+	if err = wc.Close(ctx); err != nil {
+		t.Fatalf("Error reported from Close: %v", err)
+	}
+
+	lineage, err := filesystem.GetLineage(ctx, out)
+	if err != nil {
+		t.Fatalf("Error reported from GetLineage: %v", err)
+	}
+	if len(lineage) != len(inputs) {
+		t.Fatalf("Unexpected lineage %v", lineage)
+	}
+	for i := range inputs {
+		if lineage[i].String() != inputs[i].String() {
+			t.Errorf("Unexpected input %d: %v", i, lineage[i])
+		}
+	}
+}
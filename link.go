@@ -0,0 +1,51 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+LinkingFileSystem is implemented by file systems which can create logical
+references to files under a new name without duplicating their data, such
+as object stores supporting copy-as-link semantics.
+*/
+type LinkingFileSystem interface {
+	// Create the second URL as a reference to the first one. Reads of the
+	// link must return the contents of the source; writes to the link must
+	// not affect the source.
+	CreateLink(context.Context, *url.URL, *url.URL) error
+}
+
+/*
+CreateLink creates dst as a logical reference to src. Reading dst returns
+the contents of src, while writing to dst turns it into an independent copy
+without affecting src. This differs from a hard link, which shares writes,
+and from a symbolic link, which is resolved on every read.
+
+Both URLs must be handled by the same file system, which must implement
+LinkingFileSystem; otherwise EUNSUPP is returned.
+*/
+func CreateLink(ctx context.Context, src, dst *url.URL) error {
+	var fs = GetImplementation(src)
+	var lfs LinkingFileSystem
+	var cancel context.CancelFunc
+	var ok bool
+
+	if fs == nil || GetImplementation(dst) == nil {
+		return ENOFS
+	}
+
+	if src.Scheme != dst.Scheme {
+		return EUNSUPP
+	}
+
+	if lfs, ok = fs.(LinkingFileSystem); !ok {
+		return EUNSUPP
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return lfs.CreateLink(ctx, src, dst)
+}
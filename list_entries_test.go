@@ -0,0 +1,133 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestListEntriesEmpty(t *testing.T) {
+	filesystem.AddImplementation("listempty", virtualfs.NewVirtualFileSystem())
+
+	entries, err := filesystem.ListEntries(context.Background(),
+		&url.URL{Scheme: "listempty", Path: "/"})
+	if err != nil {
+		t.Errorf("Error reported from ListEntries: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+
+	_, err = filesystem.ListEntries(context.Background(),
+		&url.URL{Scheme: "listempty", Path: "/empty"})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error for missing directory: %v", err)
+	}
+
+	_, err = filesystem.ListEntries(context.Background(),
+		&url.URL{Scheme: "listunregistered", Path: "/empty"})
+	if err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+}
+
+func TestListEntriesLargeDirectory(t *testing.T) {
+	vfs := virtualfs.NewVirtualFileSystem()
+	filesystem.AddImplementation("listlarge", vfs)
+	ctx := context.Background()
+	dir := &url.URL{Scheme: "listlarge", Path: "/large"}
+
+	for i := 0; i < 10000; i++ {
+		wc, err := vfs.OpenWriter(ctx, &url.URL{Path: fmt.Sprintf("/large/%d", i)})
+		if err != nil {
+			t.Fatalf("Error reported from OpenWriter: %v", err)
+		}
+		wc.Close(ctx)
+	}
+
+	first, err := filesystem.ListEntries(ctx, dir)
+	if err != nil {
+		t.Fatalf("Error reported from ListEntries: %v", err)
+	}
+	if len(first) != 10000 {
+		t.Fatalf("Expected 10000 entries, got %d", len(first))
+	}
+	if !sort.StringsAreSorted(first) {
+		t.Error("Entries are not sorted")
+	}
+
+	second, _ := filesystem.ListEntries(ctx, dir)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Listings differ at %d: %q vs. %q", i, first[i], second[i])
+		}
+	}
+}
+
+func TestListEntriesContextCancellation(t *testing.T) {
+	filesystem.AddImplementation("listcancel", virtualfs.NewVirtualFileSystem())
+	writeTestFile(t, &url.URL{Scheme: "listcancel", Path: "/dir/a"}, "a")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := filesystem.ListEntries(ctx,
+		&url.URL{Scheme: "listcancel", Path: "/dir"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Unexpected error for cancelled context: %v", err)
+	}
+}
+
+func TestListEntriesAfterRemove(t *testing.T) {
+	filesystem.AddImplementation("listremove", virtualfs.NewVirtualFileSystem())
+	ctx := context.Background()
+	a := &url.URL{Scheme: "listremove", Path: "/dir/a"}
+
+	writeTestFile(t, a, "a")
+	writeTestFile(t, &url.URL{Scheme: "listremove", Path: "/dir/b"}, "b")
+
+	if err := filesystem.Remove(ctx, a); err != nil {
+		t.Fatalf("Error reported from Remove: %v", err)
+	}
+
+	entries, err := filesystem.ListEntries(ctx,
+		&url.URL{Scheme: "listremove", Path: "/dir"})
+	if err != nil {
+		t.Fatalf("Error reported from ListEntries: %v", err)
+	}
+	if len(entries) != 1 || entries[0] != "b" {
+		t.Errorf("Unexpected entries after removal: %v", entries)
+	}
+}
+
+func TestListEntriesStream(t *testing.T) {
+	filesystem.AddImplementation("liststream", virtualfs.NewVirtualFileSystem())
+	for _, name := range []string{"a", "b", "c"} {
+		writeTestFile(t, &url.URL{Scheme: "liststream", Path: "/dir/" + name}, name)
+	}
+
+	ch, err := filesystem.ListEntriesStream(context.Background(),
+		&url.URL{Scheme: "liststream", Path: "/dir"})
+	if err != nil {
+		t.Fatalf("Error reported from ListEntriesStream: %v", err)
+	}
+
+	var names []string
+	for entry := range ch {
+		if entry.Err != nil {
+			t.Errorf("Error reported in listing: %v", entry.Err)
+		}
+		names = append(names, entry.Name)
+	}
+	if strings.Join(names, ",") != "a,b,c" {
+		t.Errorf("Unexpected entries %v", names)
+	}
+}
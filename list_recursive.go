@@ -0,0 +1,85 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"path"
+)
+
+/*
+RecursiveListingFileSystem is implemented by file systems which can list
+all files beneath a directory efficiently, e.g. object stores listing by
+prefix.
+*/
+type RecursiveListingFileSystem interface {
+	// Retrieve the paths of all files beneath the directory, relative to
+	// it. Directories themselves are not included.
+	ListEntriesRecursive(context.Context, *url.URL) ([]string, error)
+}
+
+/*
+ListEntriesRecursive retrieves the paths of all files beneath the referenced
+directory and its subdirectories, relative to it and separated by slashes.
+Directories themselves are not included.
+
+File systems which do not implement RecursiveListingFileSystem are walked
+using ListEntries and must support Stat to tell files and directories
+apart; otherwise EUNSUPP is returned.
+*/
+func ListEntriesRecursive(ctx context.Context, dirurl *url.URL) ([]string, error) {
+	var fs = GetImplementation(dirurl)
+	var cancel context.CancelFunc
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if rfs, ok := fs.(RecursiveListingFileSystem); ok {
+		ctx, cancel = withDefaultTimeout(ctx)
+		defer cancel()
+
+		return rfs.ListEntriesRecursive(ctx, dirurl)
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return listRecursive(ctx, fs, dirurl, "")
+}
+
+/*
+listRecursive walks the directory, prefixing all paths found with prefix.
+*/
+func listRecursive(ctx context.Context, fs FileSystem, dirurl *url.URL,
+	prefix string) ([]string, error) {
+	var entries []string
+	var paths []string
+	var err error
+
+	if entries, err = fs.ListEntries(ctx, dirurl); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		var u = childURL(dirurl, entry)
+		var fi FileInfo
+
+		if fi, err = fs.Stat(ctx, u); err != nil {
+			return nil, err
+		}
+
+		if !fi.IsDir() {
+			paths = append(paths, path.Join(prefix, entry))
+			continue
+		}
+
+		var sub []string
+		if sub, err = listRecursive(ctx, fs, u,
+			path.Join(prefix, entry)); err != nil {
+			return nil, err
+		}
+		paths = append(paths, sub...)
+	}
+
+	return paths, nil
+}
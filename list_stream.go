@@ -0,0 +1,85 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+ListStreamBufferSize is the capacity of the channels returned by
+ListEntriesStream, which bounds the number of entries held in memory.
+*/
+const ListStreamBufferSize = 256
+
+/*
+ListEntry is a single result of ListEntriesStream. Either Name is set, or
+Err describes why the listing failed; no further entries follow an error.
+*/
+type ListEntry struct {
+	Name string
+	Err  error
+}
+
+/*
+StreamingListFileSystem is implemented by file systems which can produce
+directory listings incrementally, such as object stores returning results
+in pages or local directories read in batches.
+*/
+type StreamingListFileSystem interface {
+	// List the directory, sending the entries to the channel as they
+	// arrive. The channel must be closed when the listing is complete or
+	// the context is done.
+	ListEntriesStream(context.Context, *url.URL) (<-chan ListEntry, error)
+}
+
+/*
+ListEntriesStream lists the referenced directory like ListEntries, but
+sends the entries to the returned channel as they arrive instead of
+collecting them first, so that directories with millions of entries can be
+processed without holding all of them in memory. The channel is closed when
+the listing is complete. Cancel ctx to stop listing early; the caller should
+not stop reading from the channel before that. As the listing continues
+after ListEntriesStream returns, the default timeout is not applied.
+
+File systems not implementing StreamingListFileSystem are listed using
+ListEntries, so they do not benefit from the reduced memory usage.
+*/
+func ListEntriesStream(ctx context.Context, dirurl *url.URL) (
+	<-chan ListEntry, error) {
+	var fs = GetImplementation(dirurl)
+	var ch chan ListEntry
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if sfs, ok := fs.(StreamingListFileSystem); ok {
+		return sfs.ListEntriesStream(ctx, dirurl)
+	}
+
+	ch = make(chan ListEntry, ListStreamBufferSize)
+	go func() {
+		var names []string
+		var err error
+
+		defer close(ch)
+
+		if names, err = ListEntries(ctx, dirurl); err != nil {
+			select {
+			case ch <- ListEntry{Err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+
+		for _, name := range names {
+			select {
+			case ch <- ListEntry{Name: name}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
@@ -0,0 +1,668 @@
+/*
+Package localfs provides a file system adapter for the local file system,
+using URLs of the form file:///path/to/file.
+
+The adapter is registered for the file scheme on initialization, so an
+anonymous import of this package is sufficient to use it.
+*/
+package localfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/fsnotify/fsnotify"
+)
+
+/*
+LocalFileSystem implements the filesystem.FileSystem API using the os
+package.
+*/
+type LocalFileSystem struct {
+}
+
+/*
+New creates a new LocalFileSystem.
+*/
+func New() *LocalFileSystem {
+	return &LocalFileSystem{}
+}
+
+func init() {
+	filesystem.AddImplementation("file", New())
+}
+
+/*
+Determine the local path referenced by the URL.
+*/
+func localPath(u *url.URL) string {
+	return filepath.FromSlash(u.Path)
+}
+
+/*
+Wrapper for os.File which applies context deadlines to individual
+operations where the underlying file supports it.
+*/
+type file struct {
+	f *os.File
+}
+
+/*
+setDeadline applies the deadline of the context to the file using the
+specified setter. Files which do not support deadlines are left alone.
+*/
+func setDeadline(ctx context.Context, setter func(time.Time) error) error {
+	var deadline, _ = ctx.Deadline()
+	var err = setter(deadline)
+
+	if errors.Is(err, os.ErrNoDeadline) {
+		return nil
+	}
+	return err
+}
+
+/*
+Read reads from the file, honoring the deadline of the context if possible.
+*/
+func (f *file) Read(ctx context.Context, p []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := setDeadline(ctx, f.f.SetReadDeadline); err != nil {
+		return 0, err
+	}
+	return f.f.Read(p)
+}
+
+/*
+Write writes to the file, honoring the deadline of the context if possible.
+*/
+func (f *file) Write(ctx context.Context, p []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := setDeadline(ctx, f.f.SetWriteDeadline); err != nil {
+		return 0, err
+	}
+
+	var n, err = f.f.Write(p)
+	if err != nil && n > 0 {
+		err = &filesystem.PartialWriteError{BytesWritten: int64(n), Err: err}
+	}
+	return n, err
+}
+
+/*
+ReadAt reads from the file at the specified offset.
+*/
+func (f *file) ReadAt(ctx context.Context, p []byte, off int64) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return f.f.ReadAt(p, off)
+}
+
+/*
+WriteAt writes to the file at the specified offset.
+*/
+func (f *file) WriteAt(ctx context.Context, p []byte, off int64) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return f.f.WriteAt(p, off)
+}
+
+/*
+Truncate changes the size of the file.
+*/
+func (f *file) Truncate(ctx context.Context, size int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.f.Truncate(size)
+}
+
+/*
+WriteAtOffset writes p to the file and returns the offset at which it was
+written. For files opened for appending, the kernel moves the offset of the
+file descriptor to the end of the data written by this call, so the offset
+is exact even if other processes append to the file concurrently.
+*/
+func (f *file) WriteAtOffset(ctx context.Context, p []byte) (int64, int, error) {
+	var n, err = f.Write(ctx, p)
+	var end int64
+
+	if err != nil {
+		return -1, n, err
+	}
+	if end, err = f.f.Seek(0, io.SeekCurrent); err != nil {
+		return -1, n, err
+	}
+	return end - int64(n), n, nil
+}
+
+/*
+Tell returns the current offset in the file.
+*/
+func (f *file) Tell(ctx context.Context) (int64, error) {
+	return f.f.Seek(0, io.SeekCurrent)
+}
+
+/*
+Seek sets the offset for the next read or write on the file.
+*/
+func (f *file) Seek(ctx context.Context, offset int64, whence int) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return f.f.Seek(offset, whence)
+}
+
+/*
+Close closes the file.
+*/
+func (f *file) Close(ctx context.Context) error {
+	return f.f.Close()
+}
+
+/*
+openFile opens the file referenced by the URL using os.OpenFile.
+*/
+func openFile(ctx context.Context, u *url.URL, flags int) (*file, error) {
+	var f *os.File
+	var err error
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if f, err = os.OpenFile(localPath(u), flags, 0666); err != nil {
+		return nil, err
+	}
+
+	return &file{f: f}, nil
+}
+
+/*
+OpenReader opens the local file for reading.
+*/
+func (l *LocalFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	filesystem.ReadCloser, error) {
+	var f, err = openFile(ctx, u, os.O_RDONLY)
+
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+/*
+OpenWriter creates or truncates the local file and opens it for writing.
+*/
+func (l *LocalFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	filesystem.WriteCloser, error) {
+	var f, err = openFile(ctx, u, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+/*
+OpenAppender opens the local file for appending, creating it if necessary.
+*/
+func (l *LocalFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	filesystem.WriteCloser, error) {
+	var f, err = openFile(ctx, u, os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+/*
+OpenWriterExclusive creates the local file and opens it for writing, failing
+with filesystem.ErrAlreadyExists if it exists already.
+*/
+func (l *LocalFileSystem) OpenWriterExclusive(ctx context.Context, u *url.URL) (
+	filesystem.WriteCloser, error) {
+	var f, err = openFile(ctx, u, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+
+	if errors.Is(err, fs.ErrExist) {
+		return nil, filesystem.ErrAlreadyExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+/*
+OpenAppenderExclusive creates the local file and opens it for appending,
+failing with filesystem.ErrAlreadyExists if it exists already.
+*/
+func (l *LocalFileSystem) OpenAppenderExclusive(ctx context.Context, u *url.URL) (
+	filesystem.WriteCloser, error) {
+	var f, err = openFile(ctx, u, os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND)
+
+	if errors.Is(err, fs.ErrExist) {
+		return nil, filesystem.ErrAlreadyExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+/*
+OpenRandomAccess opens the local file for reading and writing at arbitrary
+offsets, creating it if necessary.
+*/
+func (l *LocalFileSystem) OpenRandomAccess(ctx context.Context, u *url.URL) (
+	filesystem.ReadWriteAtCloser, error) {
+	var f, err = openFile(ctx, u, os.O_RDWR|os.O_CREATE)
+
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+/*
+ListEntries lists the names of all entries of the local directory.
+*/
+func (l *LocalFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	var entries []os.DirEntry
+	var names []string
+	var err error
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if entries, err = os.ReadDir(localPath(u)); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names, nil
+}
+
+/*
+ListEntriesStream lists the local directory, reading and sending its entries
+in batches of filesystem.ListStreamBufferSize. Unlike with ListEntries, the
+entries are not sorted.
+*/
+func (l *LocalFileSystem) ListEntriesStream(ctx context.Context, u *url.URL) (
+	<-chan filesystem.ListEntry, error) {
+	var ch chan filesystem.ListEntry
+	var dir *os.File
+	var err error
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if dir, err = os.Open(localPath(u)); err != nil {
+		return nil, err
+	}
+
+	ch = make(chan filesystem.ListEntry, filesystem.ListStreamBufferSize)
+	go func() {
+		defer close(ch)
+		defer dir.Close()
+
+		for {
+			var entries, err = dir.ReadDir(filesystem.ListStreamBufferSize)
+
+			if err == io.EOF {
+				return
+			}
+
+			for _, e := range entries {
+				select {
+				case ch <- filesystem.ListEntry{Name: e.Name()}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if err != nil {
+				select {
+				case ch <- filesystem.ListEntry{Err: err}:
+				case <-ctx.Done():
+				}
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+/*
+reportError sends err to errChan, dropping it if the channel is full.
+*/
+func reportError(errChan chan error, err error) {
+	select {
+	case errChan <- err:
+	default:
+	}
+}
+
+/*
+runWatcher passes the events of w to handle until w is closed. Errors
+returned by handle or reported by w are sent to errChan using reportError.
+errChan is closed once w has been closed.
+*/
+func runWatcher(w *fsnotify.Watcher, errChan chan error,
+	handle func(fsnotify.Event) error) {
+	defer close(errChan)
+
+	for {
+		select {
+		case event, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if err := handle(event); err != nil {
+				reportError(errChan, err)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			reportError(errChan, err)
+		}
+	}
+}
+
+/*
+WatchFile watches the local file for modifications. See WatchFileV2; removals
+of the file are not reported.
+*/
+func (l *LocalFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher filesystem.FileWatchFunc) (
+	filesystem.CancelWatchFunc, chan error, error) {
+	return l.WatchFileV2(ctx, u,
+		func(changed *url.URL, event filesystem.ChangeEvent) {
+			if event.Reader != nil {
+				watcher(changed, event.Reader)
+			}
+		})
+}
+
+/*
+WatchFileV2 watches the local file for changes using fsnotify and invokes the
+watcher whenever it was created, written to or removed. Errors reported by
+fsnotify, or encountered when opening the changed file, are sent to the
+returned channel if it is not full.
+
+Since the parent directory is watched instead of the file itself, the watch
+persists if the file is deleted and recreated, e.g. due to log rotation.
+*/
+func (l *LocalFileSystem) WatchFileV2(ctx context.Context, u *url.URL,
+	watcher filesystem.FileWatchFuncV2) (
+	filesystem.CancelWatchFunc, chan error, error) {
+	var path = filepath.Clean(localPath(u))
+	var errChan = make(chan error, 1)
+	var w *fsnotify.Watcher
+	var err error
+
+	if err = ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if w, err = fsnotify.NewWatcher(); err != nil {
+		return nil, nil, err
+	}
+
+	if err = w.Add(filepath.Dir(path)); err != nil {
+		w.Close()
+		return nil, nil, err
+	}
+
+	go runWatcher(w, errChan, func(event fsnotify.Event) error {
+		var change filesystem.ChangeEvent
+		var f *file
+		var err error
+
+		if filepath.Clean(event.Name) != path {
+			return nil
+		}
+
+		switch {
+		case event.Has(fsnotify.Create):
+			change.Kind = filesystem.EventCreated
+		case event.Has(fsnotify.Write):
+			change.Kind = filesystem.EventModified
+		case event.Has(fsnotify.Remove | fsnotify.Rename):
+			watcher(u, filesystem.ChangeEvent{Kind: filesystem.EventRemoved})
+			return nil
+		default:
+			return nil
+		}
+
+		if f, err = openFile(context.Background(), u, os.O_RDONLY); err != nil {
+			return err
+		}
+		change.Reader = f
+		watcher(u, change)
+		return nil
+	})
+
+	// Closing the watcher closes its channels, which stops the goroutine.
+	return w.Close, errChan, nil
+}
+
+/*
+MultiWatch watches all local files referenced by urls with a single
+fsnotify watcher, adding each parent directory only once, and invokes the
+watcher whenever one of the files is created or written to.
+*/
+func (l *LocalFileSystem) MultiWatch(ctx context.Context, urls []*url.URL,
+	watcher filesystem.MultiWatchFunc) (
+	filesystem.CancelWatchFunc, chan error, error) {
+	var watched = make(map[string]*url.URL, len(urls))
+	var dirs = make(map[string]bool)
+	var errChan = make(chan error, 1)
+	var w *fsnotify.Watcher
+	var err error
+
+	if err = ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if w, err = fsnotify.NewWatcher(); err != nil {
+		return nil, nil, err
+	}
+
+	for _, u := range urls {
+		var path = filepath.Clean(localPath(u))
+		var dir = filepath.Dir(path)
+
+		watched[path] = u
+		if dirs[dir] {
+			continue
+		}
+		if err = w.Add(dir); err != nil {
+			w.Close()
+			return nil, nil, err
+		}
+		dirs[dir] = true
+	}
+
+	go runWatcher(w, errChan, func(event fsnotify.Event) error {
+		var u, found = watched[filepath.Clean(event.Name)]
+		var f *file
+		var err error
+
+		if !found || (!event.Has(fsnotify.Create) && !event.Has(fsnotify.Write)) {
+			return nil
+		}
+
+		if f, err = openFile(context.Background(), u, os.O_RDONLY); err != nil {
+			return err
+		}
+		watcher(u, f)
+		return nil
+	})
+
+	return w.Close, errChan, nil
+}
+
+/*
+addTree adds dir to w, and all of its subdirectories if recursive is set.
+If found is not nil, it is invoked for every file in the added directories.
+*/
+func addTree(w *fsnotify.Watcher, dir string, recursive bool,
+	found func(string) error) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && !recursive {
+				return fs.SkipDir
+			}
+			return w.Add(p)
+		}
+		if found != nil {
+			return found(p)
+		}
+		return nil
+	})
+}
+
+/*
+WatchDirectory watches the local directory for files being created or
+written to using fsnotify, and invokes the watcher with a reader for every
+changed file. With opts.Recursive, all subdirectories are watched as well,
+including those created later. Since files may be created in a new
+subdirectory before it is being watched, its contents are reported once it
+has been added, so such files may be reported twice.
+*/
+func (l *LocalFileSystem) WatchDirectory(ctx context.Context, u *url.URL,
+	opts filesystem.WatchOptions, watcher filesystem.FileWatchFunc) (
+	filesystem.CancelWatchFunc, chan error, error) {
+	var root = filepath.Clean(localPath(u))
+	var errChan = make(chan error, 1)
+	var w *fsnotify.Watcher
+	var report = func(p string) error {
+		var changed = &url.URL{Scheme: u.Scheme, Host: u.Host,
+			Path: filepath.ToSlash(p)}
+		var f, err = openFile(context.Background(), changed, os.O_RDONLY)
+
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		watcher(changed, f)
+		return nil
+	}
+	var err error
+
+	if err = ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if w, err = fsnotify.NewWatcher(); err != nil {
+		return nil, nil, err
+	}
+
+	if err = addTree(w, root, opts.Recursive, nil); err != nil {
+		w.Close()
+		return nil, nil, err
+	}
+
+	go runWatcher(w, errChan, func(event fsnotify.Event) error {
+		var fi os.FileInfo
+		var err error
+
+		if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+			return nil
+		}
+
+		if fi, err = os.Stat(event.Name); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if !fi.IsDir() {
+			return report(event.Name)
+		}
+		if opts.Recursive && event.Has(fsnotify.Create) {
+			return addTree(w, event.Name, true, report)
+		}
+		return nil
+	})
+
+	return w.Close, errChan, nil
+}
+
+/*
+Remove deletes the local file or empty directory.
+*/
+func (l *LocalFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return os.Remove(localPath(u))
+}
+
+/*
+Stat retrieves the metadata of the local file.
+*/
+func (l *LocalFileSystem) Stat(ctx context.Context, u *url.URL) (
+	filesystem.FileInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return os.Stat(localPath(u))
+}
+
+/*
+Touch creates the local file if it does not exist and sets its access and
+modification times to t.
+*/
+func (l *LocalFileSystem) Touch(ctx context.Context, u *url.URL, t time.Time) error {
+	var f *file
+	var err error
+
+	if f, err = openFile(ctx, u, os.O_WRONLY|os.O_CREATE); err != nil {
+		return err
+	}
+	if err = f.Close(ctx); err != nil {
+		return err
+	}
+
+	return os.Chtimes(localPath(u), t, t)
+}
+
+/*
+Move renames the local file using os.Rename, which is atomic if both paths
+are on the same device.
+*/
+func (l *LocalFileSystem) Move(ctx context.Context, src, dst *url.URL) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return os.Rename(localPath(src), localPath(dst))
+}
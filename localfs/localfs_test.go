@@ -0,0 +1,494 @@
+package localfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/fstest"
+)
+
+func fileURL(path string) *url.URL {
+	return &url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+}
+
+func writeFile(t *testing.T, u *url.URL, data string) {
+	wc, err := filesystem.OpenWriter(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenWriter: %v", err)
+	}
+	if _, err = wc.Write(context.Background(), []byte(data)); err != nil {
+		t.Errorf("Error reported from Write: %v", err)
+	}
+	if err = wc.Close(context.Background()); err != nil {
+		t.Errorf("Error reported from Close: %v", err)
+	}
+}
+
+func readFile(t *testing.T, u *url.URL) string {
+	rc, err := filesystem.OpenReader(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenReader: %v", err)
+	}
+	defer rc.Close(context.Background())
+
+	data, err := io.ReadAll(filesystem.ToIoReadCloser(rc))
+	if err != nil {
+		t.Errorf("Error reported from read: %v", err)
+	}
+	return string(data)
+}
+
+func TestWriteAppendRead(t *testing.T) {
+	u := fileURL(filepath.Join(t.TempDir(), "test.txt"))
+
+	writeFile(t, u, "hello")
+
+	wc, err := filesystem.OpenAppender(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenAppender: %v", err)
+	}
+	wc.Write(context.Background(), []byte(" world"))
+	wc.Close(context.Background())
+
+	if data := readFile(t, u); data != "hello world" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+}
+
+func TestListEntriesAndRemove(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, fileURL(filepath.Join(dir, "a")), "a")
+	writeFile(t, fileURL(filepath.Join(dir, "b")), "b")
+
+	entries, err := filesystem.ListEntries(context.Background(), fileURL(dir))
+	if err != nil {
+		t.Fatalf("Error reported from ListEntries: %v", err)
+	}
+	sort.Strings(entries)
+	if len(entries) != 2 || entries[0] != "a" || entries[1] != "b" {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+
+	if err = filesystem.Remove(context.Background(),
+		fileURL(filepath.Join(dir, "a"))); err != nil {
+		t.Errorf("Error reported from Remove: %v", err)
+	}
+
+	_, err = filesystem.OpenReader(context.Background(),
+		fileURL(filepath.Join(dir, "a")))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error reading removed file: %v", err)
+	}
+}
+
+func TestStat(t *testing.T) {
+	u := fileURL(filepath.Join(t.TempDir(), "test.txt"))
+	writeFile(t, u, "hello")
+
+	fi, err := filesystem.Stat(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from Stat: %v", err)
+	}
+	if fi.Name() != "test.txt" || fi.Size() != 5 || fi.IsDir() {
+		t.Errorf("Unexpected file info %s, %d, %v",
+			fi.Name(), fi.Size(), fi.IsDir())
+	}
+}
+
+func TestTouch(t *testing.T) {
+	u := fileURL(filepath.Join(t.TempDir(), "touched"))
+	when := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+
+	if err := filesystem.TouchWithTime(context.Background(), u, when); err != nil {
+		t.Fatalf("Error reported from TouchWithTime: %v", err)
+	}
+
+	fi, err := filesystem.Stat(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from Stat: %v", err)
+	}
+	if fi.Size() != 0 || !fi.ModTime().Equal(when) {
+		t.Errorf("Unexpected file info %d, %v", fi.Size(), fi.ModTime())
+	}
+}
+
+func TestOpenWriterExclusive(t *testing.T) {
+	u := fileURL(filepath.Join(t.TempDir(), "exclusive"))
+
+	wc, err := filesystem.OpenWriterExclusive(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenWriterExclusive: %v", err)
+	}
+	wc.Close(context.Background())
+
+	_, err = filesystem.OpenWriterExclusive(context.Background(), u)
+	if err != filesystem.ErrAlreadyExists {
+		t.Errorf("Unexpected error from second OpenWriterExclusive: %v", err)
+	}
+}
+
+func TestOpenAppenderExclusive(t *testing.T) {
+	u := fileURL(filepath.Join(t.TempDir(), "exclusive.log"))
+
+	wc, err := filesystem.OpenAppenderExclusive(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenAppenderExclusive: %v", err)
+	}
+	wc.Close(context.Background())
+
+	_, err = filesystem.OpenAppenderExclusive(context.Background(), u)
+	if err != filesystem.ErrAlreadyExists {
+		t.Errorf("Unexpected error from second OpenAppenderExclusive: %v", err)
+	}
+}
+
+func TestOpenRandomAccess(t *testing.T) {
+	ctx := context.Background()
+	u := fileURL(filepath.Join(t.TempDir(), "random.bin"))
+
+	writeFile(t, u, "hello world")
+
+	f, err := filesystem.OpenRandomAccess(ctx, u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenRandomAccess: %v", err)
+	}
+	if _, err = f.WriteAt(ctx, []byte("W"), 6); err != nil {
+		t.Errorf("Error reported from WriteAt: %v", err)
+	}
+	if err = f.Truncate(ctx, 9); err != nil {
+		t.Errorf("Error reported from Truncate: %v", err)
+	}
+
+	buf := make([]byte, 3)
+	if _, err = f.ReadAt(ctx, buf, 6); err != nil {
+		t.Errorf("Error reported from ReadAt: %v", err)
+	}
+	if string(buf) != "Wor" {
+		t.Errorf("Unexpected data %q", string(buf))
+	}
+	f.Close(ctx)
+
+	if data := readFile(t, u); data != "hello Wor" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	dir := t.TempDir()
+	u := fileURL(filepath.Join(dir, "watched.log"))
+	changes := make(chan string, 16)
+
+	writeFile(t, u, "first")
+
+	cancel, _, err := filesystem.WatchFile(context.Background(), u,
+		func(changed *url.URL, rc filesystem.ReadCloser) {
+			defer rc.Close(context.Background())
+			data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc))
+			changes <- string(data)
+		})
+	if err != nil {
+		t.Fatalf("Error reported from WatchFile: %v", err)
+	}
+	defer cancel()
+
+	// Simulate log rotation by removing and recreating the file.
+	if err = filesystem.Remove(context.Background(), u); err != nil {
+		t.Fatalf("Error reported from Remove: %v", err)
+	}
+	writeFile(t, u, "second")
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case data := <-changes:
+			if data == "second" {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Timed out waiting for change notification")
+		}
+	}
+}
+
+func TestWatchClosesErrorChannel(t *testing.T) {
+	u := fileURL(filepath.Join(t.TempDir(), "watched.log"))
+	l := New()
+
+	for name, watch := range map[string]func() (filesystem.CancelWatchFunc,
+		chan error, error){
+		"WatchFile": func() (filesystem.CancelWatchFunc, chan error, error) {
+			return l.WatchFile(context.Background(), u,
+				func(*url.URL, filesystem.ReadCloser) {})
+		},
+		"MultiWatch": func() (filesystem.CancelWatchFunc, chan error, error) {
+			return l.MultiWatch(context.Background(), []*url.URL{u},
+				func(*url.URL, filesystem.ReadCloser) {})
+		},
+	} {
+		cancel, errs, err := watch()
+		if err != nil {
+			t.Fatalf("Error reported from %s: %v", name, err)
+		}
+		cancel()
+
+		select {
+		case _, ok := <-errs:
+			if ok {
+				t.Errorf("Unexpected error from %s after cancellation", name)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("Error channel of %s not closed after cancellation", name)
+		}
+	}
+}
+
+func TestOpenMissingFileReturnsNil(t *testing.T) {
+	u := fileURL(filepath.Join(t.TempDir(), "nonexistent"))
+
+	rc, err := filesystem.OpenReader(context.Background(), u)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error from OpenReader: %v", err)
+	}
+	if rc != nil {
+		t.Errorf("OpenReader returned non-nil reader %#v on error", rc)
+	}
+
+	wc, err := filesystem.OpenWriter(context.Background(),
+		fileURL(filepath.Join(t.TempDir(), "missing", "file")))
+	if err == nil || wc != nil {
+		t.Errorf("OpenWriter returned %#v, %v for missing directory", wc, err)
+	}
+}
+
+func TestOpenRandomAccessMissingDirectoryReturnsNil(t *testing.T) {
+	f, err := filesystem.OpenRandomAccess(context.Background(),
+		fileURL(filepath.Join(t.TempDir(), "missing", "file")))
+	if err == nil || f != nil {
+		t.Errorf("OpenRandomAccess returned %#v, %v for missing directory", f, err)
+	}
+}
+
+func TestWatchFileV2(t *testing.T) {
+	u := fileURL(filepath.Join(t.TempDir(), "watched.txt"))
+	kinds := make(chan filesystem.EventKind, 16)
+
+	cancel, _, err := filesystem.WatchFileV2(context.Background(), u,
+		func(changed *url.URL, event filesystem.ChangeEvent) {
+			if event.Reader != nil {
+				event.Reader.Close(context.Background())
+			}
+			kinds <- event.Kind
+		})
+	if err != nil {
+		t.Fatalf("Error reported from WatchFileV2: %v", err)
+	}
+	defer cancel()
+
+	timeout := time.After(5 * time.Second)
+	waitFor := func(expected filesystem.EventKind) {
+		for {
+			select {
+			case kind := <-kinds:
+				if kind == expected {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("Timed out waiting for %v event", expected)
+			}
+		}
+	}
+
+	writeFile(t, u, "data")
+	waitFor(filesystem.EventCreated)
+
+	if err = filesystem.Remove(context.Background(), u); err != nil {
+		t.Fatalf("Error reported from Remove: %v", err)
+	}
+	waitFor(filesystem.EventRemoved)
+}
+
+func TestWatchDirectory(t *testing.T) {
+	dir := t.TempDir()
+	changes := make(chan string, 16)
+
+	cancel, _, err := filesystem.WatchDirectory(context.Background(), fileURL(dir),
+		filesystem.WatchOptions{Recursive: true, CoalesceInterval: 200 * time.Millisecond},
+		func(changed *url.URL, rc filesystem.ReadCloser) {
+			rc.Close(context.Background())
+			changes <- changed.Path
+		})
+	if err != nil {
+		t.Fatalf("Error reported from WatchDirectory: %v", err)
+	}
+	defer cancel()
+
+	sub := filepath.Join(dir, "sub")
+	if err = os.Mkdir(sub, 0o755); err != nil {
+		t.Fatalf("Error creating subdirectory: %v", err)
+	}
+
+	u := fileURL(filepath.Join(sub, "nested.txt"))
+	writeFile(t, u, "one")
+	writeFile(t, u, "two")
+
+	select {
+	case p := <-changes:
+		if p != u.Path {
+			t.Errorf("Unexpected change of %s, expected %s", p, u.Path)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for change in subdirectory")
+	}
+
+	select {
+	case p := <-changes:
+		t.Errorf("Changes were not coalesced, got another change of %s", p)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestMultiWatch(t *testing.T) {
+	var urls []*url.URL
+	for _, dir := range []string{t.TempDir(), t.TempDir()} {
+		for _, name := range []string{"a.conf", "b.conf"} {
+			urls = append(urls, fileURL(filepath.Join(dir, name)))
+		}
+	}
+	changes := make(chan string, 16)
+
+	cancel, _, err := filesystem.MultiWatch(context.Background(), urls,
+		func(changed *url.URL, rc filesystem.ReadCloser) {
+			rc.Close(context.Background())
+			changes <- changed.Path
+		}, filesystem.MultiWatchOptions{})
+	if err != nil {
+		t.Fatalf("Error reported from MultiWatch: %v", err)
+	}
+	defer cancel()
+
+	writeFile(t, fileURL(filepath.Join(filepath.Dir(urls[0].Path), "unwatched")), "x")
+	writeFile(t, urls[3], "data")
+
+	select {
+	case p := <-changes:
+		if p != urls[3].Path {
+			t.Errorf("Unexpected change of %s, expected %s", p, urls[3].Path)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for change")
+	}
+}
+
+func TestToIoReadWriteSeekCloser(t *testing.T) {
+	u := fileURL(filepath.Join(t.TempDir(), "db.bin"))
+
+	f, err := filesystem.OpenRandomAccess(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenRandomAccess: %v", err)
+	}
+	rws := filesystem.ToIoReadWriteSeekCloser(f)
+	defer rws.(io.Closer).Close()
+
+	io.WriteString(rws, "hello world")
+	if pos, err := rws.Seek(-5, io.SeekEnd); err != nil || pos != 6 {
+		t.Fatalf("Unexpected result of Seek: %d (%v)", pos, err)
+	}
+
+	data, err := io.ReadAll(rws)
+	if err != nil {
+		t.Errorf("Error reported from read: %v", err)
+	}
+	if string(data) != "world" {
+		t.Errorf("Unexpected data %q", string(data))
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	fstest.TestFileSystemConcurrency(t, func() filesystem.FileSystem {
+		return New()
+	}, fileURL(t.TempDir()))
+}
+
+func TestOpenAppenderReturningOffset(t *testing.T) {
+	ctx := context.Background()
+	u := fileURL(filepath.Join(t.TempDir(), "log"))
+	writeFile(t, u, "header\n")
+
+	wc, err := filesystem.OpenAppenderReturningOffset(ctx, u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenAppenderReturningOffset: %v", err)
+	}
+	defer wc.Close(ctx)
+
+	for _, expected := range []int64{7, 13} {
+		offset, n, err := wc.WriteAtOffset(ctx, []byte("entry\n"))
+		if err != nil || n != 6 {
+			t.Errorf("Unexpected result of WriteAtOffset: %d (%v)", n, err)
+		}
+		if offset != expected {
+			t.Errorf("Unexpected offset %d, expected %d", offset, expected)
+		}
+	}
+}
+
+func TestListEntriesStream(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		writeFile(t, fileURL(filepath.Join(dir, name)), name)
+	}
+
+	ch, err := filesystem.ListEntriesStream(context.Background(), fileURL(dir))
+	if err != nil {
+		t.Fatalf("Error reported from ListEntriesStream: %v", err)
+	}
+
+	var names []string
+	for entry := range ch {
+		if entry.Err != nil {
+			t.Errorf("Error reported in listing: %v", entry.Err)
+		}
+		names = append(names, entry.Name)
+	}
+	sort.Strings(names)
+	if len(names) != 3 || names[0] != "a" || names[2] != "c" {
+		t.Errorf("Unexpected entries %v", names)
+	}
+}
+
+func TestConcurrentCheckpoints(t *testing.T) {
+	u := fileURL(filepath.Join(t.TempDir(), "events"))
+	errs := make(chan error, 20)
+	var wg sync.WaitGroup
+
+	for i := int64(1); i <= 20; i++ {
+		wg.Add(1)
+		go func(offset int64) {
+			defer wg.Done()
+			errs <- filesystem.SetCheckpoint(context.Background(), "indexer",
+				u, offset*1000)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Error reported from SetCheckpoint: %v", err)
+		}
+	}
+
+	offset, err := filesystem.GetCheckpoint(context.Background(), "indexer", u)
+	if err != nil || offset < 1000 || offset > 20000 || offset%1000 != 0 {
+		t.Errorf("Unexpected checkpoint %d (%v)", offset, err)
+	}
+}
@@ -0,0 +1,50 @@
+//go:build unix
+
+package localfs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+)
+
+func TestPartialWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fifo")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		t.Fatalf("Error creating FIFO: %v", err)
+	}
+
+	// Keep a reader open which never reads, so the pipe buffer fills up.
+	r, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatalf("Error opening FIFO for reading: %v", err)
+	}
+	defer r.Close()
+
+	wc, err := New().OpenWriter(context.Background(), fileURL(path))
+	if err != nil {
+		t.Fatalf("Error reported from OpenWriter: %v", err)
+	}
+	defer wc.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	n, err := wc.Write(ctx, make([]byte, 1<<20))
+	var perr *filesystem.PartialWriteError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Expected a PartialWriteError, got %v", err)
+	}
+	if n == 0 || perr.BytesWritten != int64(n) {
+		t.Errorf("Unexpected byte counts %d and %d", n, perr.BytesWritten)
+	}
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Cause of partial write not unwrapped: %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+/*
+Package memfs registers an in-memory file system under the memory scheme,
+so that tests can use URLs such as memory:///testdir/file.txt without any
+further setup. Simply import the package anonymously.
+
+To start each test case from an empty file system, call Reset on the
+registered implementation:
+
+	filesystem.GetImplementation(u).(*virtualfs.VirtualFileSystem).Reset()
+*/
+package memfs
+
+import (
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+/*
+Scheme is the URL scheme the in-memory file system is registered under.
+*/
+const Scheme = "memory"
+
+func init() {
+	filesystem.AddImplementation(Scheme, virtualfs.NewVirtualFileSystem())
+}
@@ -0,0 +1,39 @@
+package memfs
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestRegistered(t *testing.T) {
+	ctx := context.Background()
+	u := &url.URL{Scheme: Scheme, Path: "/testdir/file.txt"}
+
+	wc, err := filesystem.OpenWriter(ctx, u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenWriter: %v", err)
+	}
+	wc.Write(ctx, []byte("hello"))
+	wc.Close(ctx)
+
+	rc, err := filesystem.OpenReader(ctx, u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenReader: %v", err)
+	}
+	data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc))
+	rc.Close(ctx)
+	if string(data) != "hello" {
+		t.Errorf("Unexpected contents %q", string(data))
+	}
+
+	filesystem.GetImplementation(u).(*virtualfs.VirtualFileSystem).Reset()
+
+	if _, err = filesystem.OpenReader(ctx, u); err == nil {
+		t.Error("File still exists after Reset")
+	}
+}
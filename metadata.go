@@ -0,0 +1,77 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+MetadataFileSystem is implemented by file systems which can store arbitrary
+key/value metadata along with files, like the user metadata of object
+stores or extended attributes.
+*/
+type MetadataFileSystem interface {
+	// Retrieve all metadata stored for the file.
+	GetMetadata(context.Context, *url.URL) (map[string]string, error)
+
+	// Store the specified metadata for the file. Keys which are not
+	// contained in the map are left unchanged.
+	SetMetadata(context.Context, *url.URL, map[string]string) error
+}
+
+/*
+getMetadataFileSystem determines the MetadataFileSystem responsible for the
+URL, or returns ENOFS or EUNSUPP if there is none.
+*/
+func getMetadataFileSystem(fileurl *url.URL) (MetadataFileSystem, error) {
+	var fs = GetImplementation(fileurl)
+	var mfs MetadataFileSystem
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if mfs, ok = fs.(MetadataFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	return mfs, nil
+}
+
+/*
+GetMetadata retrieves all metadata stored for the referenced file.
+*/
+func GetMetadata(ctx context.Context, fileurl *url.URL) (map[string]string, error) {
+	var mfs MetadataFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if mfs, err = getMetadataFileSystem(fileurl); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return mfs.GetMetadata(ctx, fileurl)
+}
+
+/*
+SetMetadata stores the specified metadata for the referenced file. Existing
+metadata with other keys is preserved.
+*/
+func SetMetadata(ctx context.Context, fileurl *url.URL, metadata map[string]string) error {
+	var mfs MetadataFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if mfs, err = getMetadataFileSystem(fileurl); err != nil {
+		return err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return mfs.SetMetadata(ctx, fileurl, metadata)
+}
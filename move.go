@@ -0,0 +1,156 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+/*
+ErrMoveFailed indicates that a move between file systems did not complete.
+The actual error is a *MoveError describing the state left behind.
+*/
+var ErrMoveFailed = errors.New("Move did not complete")
+
+/*
+MoveError is returned by Move if a move between different file systems
+failed part way. It matches ErrMoveFailed when compared using errors.Is.
+*/
+type MoveError struct {
+	Src, Dst *url.URL
+
+	// Whether the destination has been written completely. If true, the
+	// failure occurred removing the source, so both files exist.
+	Copied bool
+
+	// The underlying error.
+	Err error
+}
+
+/*
+Error describes the failed move.
+*/
+func (e *MoveError) Error() string {
+	if e.Copied {
+		return fmt.Sprintf("Moving %s to %s: copied, but source not removed: %v",
+			e.Src, e.Dst, e.Err)
+	}
+	return fmt.Sprintf("Moving %s to %s: copy failed: %v", e.Src, e.Dst, e.Err)
+}
+
+/*
+Is reports whether target is ErrMoveFailed.
+*/
+func (e *MoveError) Is(target error) bool {
+	return target == ErrMoveFailed
+}
+
+/*
+Unwrap returns the underlying error.
+*/
+func (e *MoveError) Unwrap() error {
+	return e.Err
+}
+
+/*
+MovingFileSystem is implemented by file systems which can move files within
+themselves on the server side.
+*/
+type MovingFileSystem interface {
+	// Move the file at the first URL to the second one.
+	Move(context.Context, *url.URL, *url.URL) error
+}
+
+/*
+Move relocates the file at src to dst, which may belong to different file
+systems.
+
+If both URLs have the same scheme and the file system implements
+MovingFileSystem, the move is performed on the server side and may be
+atomic, depending on the file system. Otherwise the file is copied using
+CopyFile and the source removed afterwards, which is not atomic. If such a
+move fails, a *MoveError matching ErrMoveFailed describes the state left
+behind: if the copy failed and the destination did not exist before, the
+partially written destination is removed (on a best effort basis); if
+removing the source failed, both files exist.
+*/
+func Move(ctx context.Context, src, dst *url.URL) error {
+	var fs = GetImplementation(src)
+	var dstfs = GetImplementation(dst)
+	var existed bool
+	var err error
+
+	if fs == nil || dstfs == nil {
+		return ENOFS
+	}
+
+	if src.Scheme == dst.Scheme {
+		if mfs, ok := fs.(MovingFileSystem); ok {
+			var cancel context.CancelFunc
+
+			ctx, cancel = withDefaultTimeout(ctx)
+			defer cancel()
+
+			return mfs.Move(ctx, src, dst)
+		}
+	}
+
+	if existed, err = fileExists(ctx, dstfs, dst); err != nil {
+		// Never remove a destination which might have existed before.
+		existed = true
+	}
+
+	if _, err = CopyFile(ctx, src, dst); err != nil {
+		if !existed {
+			Remove(ctx, dst)
+		}
+		return &MoveError{Src: src, Dst: dst, Err: err}
+	}
+
+	if err = Remove(ctx, src); err != nil {
+		return &MoveError{Src: src, Dst: dst, Copied: true, Err: err}
+	}
+
+	return nil
+}
+
+/*
+Suffix of the temporary file writeFileReplacing writes to.
+*/
+const replaceTempSuffix = ".tmp"
+
+/*
+writeFileReplacing writes data to a temporary file next to fileurl and
+moves it into place, so readers see either the old or the new contents,
+provided the file system supports atomic moves. Every call uses a temporary
+file of its own, so concurrent writers never mix their contents.
+*/
+func writeFileReplacing(ctx context.Context, fs FileSystem, fileurl *url.URL,
+	data []byte) error {
+	var generator = UUIDFilenameGenerator{Prefix: "."}
+	var temp = *fileurl
+	var name string
+	var wc WriteCloser
+	var err error
+
+	if name, err = generator.Generate(0); err != nil {
+		return err
+	}
+	temp.Path += name + replaceTempSuffix
+
+	if wc, err = fs.OpenWriter(ctx, &temp); err != nil {
+		return err
+	}
+	if _, err = wc.Write(ctx, data); err != nil {
+		wc.Close(ctx)
+		fs.Remove(ctx, &temp)
+		return err
+	}
+	if err = wc.Close(ctx); err != nil {
+		fs.Remove(ctx, &temp)
+		return err
+	}
+
+	return Move(ctx, &temp, fileurl)
+}
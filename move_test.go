@@ -0,0 +1,90 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func writeTestFile(t *testing.T, u *url.URL, data string) {
+	wc, err := filesystem.OpenWriter(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenWriter: %v", err)
+	}
+	wc.Write(context.Background(), []byte(data))
+	if err = wc.Close(context.Background()); err != nil {
+		t.Fatalf("Error reported from Close: %v", err)
+	}
+}
+
+func readTestFile(t *testing.T, u *url.URL) (string, error) {
+	rc, err := filesystem.OpenReader(context.Background(), u)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close(context.Background())
+
+	data, err := io.ReadAll(filesystem.ToIoReadCloser(rc))
+	return string(data), err
+}
+
+func TestMoveAcrossFileSystems(t *testing.T) {
+	filesystem.AddImplementation("movesrc", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("movedst", virtualfs.NewVirtualFileSystem())
+	src := &url.URL{Scheme: "movesrc", Path: "/file"}
+	dst := &url.URL{Scheme: "movedst", Path: "/file"}
+
+	writeTestFile(t, src, "contents")
+
+	if err := filesystem.Move(context.Background(), src, dst); err != nil {
+		t.Fatalf("Error reported from Move: %v", err)
+	}
+
+	if data, err := readTestFile(t, dst); err != nil || data != "contents" {
+		t.Errorf("Unexpected destination contents %q (%v)", data, err)
+	}
+	if _, err := readTestFile(t, src); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Source still exists after move: %v", err)
+	}
+}
+
+func TestMoveReportsFailedCopy(t *testing.T) {
+	filesystem.AddImplementation("movesrc", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("movedst", virtualfs.NewVirtualFileSystem())
+	src := &url.URL{Scheme: "movesrc", Path: "/missing"}
+	dst := &url.URL{Scheme: "movedst", Path: "/file"}
+
+	err := filesystem.Move(context.Background(), src, dst)
+	if !errors.Is(err, filesystem.ErrMoveFailed) {
+		t.Errorf("Unexpected error from Move: %v", err)
+	}
+
+	var merr *filesystem.MoveError
+	if !errors.As(err, &merr) || merr.Copied {
+		t.Errorf("Unexpected move state in %v", err)
+	}
+}
+
+func TestMoveKeepsExistingDestination(t *testing.T) {
+	filesystem.AddImplementation("movesrc", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("movedst", virtualfs.NewVirtualFileSystem())
+	src := &url.URL{Scheme: "movesrc", Path: "/missing"}
+	dst := &url.URL{Scheme: "movedst", Path: "/important"}
+
+	writeTestFile(t, dst, "important")
+
+	if err := filesystem.Move(context.Background(), src, dst); !errors.Is(
+		err, filesystem.ErrMoveFailed) {
+		t.Errorf("Unexpected error from Move: %v", err)
+	}
+
+	if data, err := readTestFile(t, dst); err != nil || data != "important" {
+		t.Errorf("Existing destination damaged by failed move: %q (%v)", data, err)
+	}
+}
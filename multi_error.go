@@ -0,0 +1,67 @@
+package filesystem
+
+import (
+	"errors"
+	"strings"
+)
+
+/*
+MultiError aggregates multiple errors which occurred during a single
+operation, e.g. while closing a number of files at once.
+*/
+type MultiError []error
+
+/*
+Error returns a description of all contained errors.
+*/
+func (m MultiError) Error() string {
+	var msgs []string
+
+	if len(m) == 1 {
+		return m[0].Error()
+	}
+
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+
+	return "Multiple errors occurred: " + strings.Join(msgs, "; ")
+}
+
+/*
+ErrorOrNil returns nil if the MultiError does not contain any errors, and the
+MultiError itself otherwise. This avoids returning a non-nil error interface
+holding an empty MultiError.
+*/
+func (m MultiError) ErrorOrNil() error {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+/*
+Is reports whether any of the contained errors matches target according to
+errors.Is, following the contract established by errors.Join.
+*/
+func (m MultiError) Is(target error) bool {
+	for _, err := range m {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+As finds the first contained error which matches target according to
+errors.As, and if one is found, sets target to it.
+*/
+func (m MultiError) As(target any) bool {
+	for _, err := range m {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+type FailingCloser struct {
+	Err error
+}
+
+func (c *FailingCloser) Close(ctx context.Context) error {
+	return c.Err
+}
+
+func TestMultiErrorIsAs(t *testing.T) {
+	_, statErr := os.Stat("/nonexistent/file")
+	err := CloseAll(context.Background(),
+		&FailingCloser{Err: errors.New("unrelated")},
+		&FailingCloser{Err: statErr},
+		&FailingCloser{})
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected %v to match fs.ErrNotExist", err)
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Expected %v not to match fs.ErrPermission", err)
+	}
+
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != "/nonexistent/file" {
+		t.Errorf("Expected %v to contain a PathError", err)
+	}
+}
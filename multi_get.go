@@ -0,0 +1,112 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"sync"
+)
+
+/*
+Maximum number of files read concurrently by MultiGet for file systems
+without native batch support.
+*/
+const multiGetConcurrency = 16
+
+/*
+MultiGetFileSystem is implemented by file systems which can fetch the
+contents of many files in a single request.
+*/
+type MultiGetFileSystem interface {
+	// Fetch the contents of all URLs. Returns the contents and errors keyed
+	// by URL string, and an error if no fetch could be started at all.
+	MultiGet(context.Context, []*url.URL) (map[string][]byte, map[string]error, error)
+}
+
+/*
+readFile reads the entire contents of the referenced file.
+*/
+func readFile(ctx context.Context, fileurl *url.URL) ([]byte, error) {
+	var rc ReadCloser
+	var data []byte
+	var err error
+
+	if rc, err = OpenReader(ctx, fileurl); err != nil {
+		return nil, err
+	}
+	defer rc.Close(ctx)
+
+	if data, err = io.ReadAll(ToIoReadCloser(NewContextReadCloser(rc, ctx))); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+/*
+MultiGet fetches the contents of all referenced files, which is the most
+efficient way to read many small files from file systems with a high
+latency per request. The contents of each file are returned keyed by the
+string representation of its URL; files which could not be read have an
+entry in the error map instead. The third return value reports problems
+which prevented any file from being fetched.
+
+If all URLs are handled by the same file system and it implements
+MultiGetFileSystem, a single batched request is made. Otherwise the files
+are read in parallel with bounded concurrency.
+*/
+func MultiGet(ctx context.Context, urls []*url.URL) (
+	map[string][]byte, map[string]error, error) {
+	var contents = make(map[string][]byte)
+	var errs = make(map[string]error)
+	var sem = make(chan struct{}, multiGetConcurrency)
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(urls) > 0 {
+		var fs = GetImplementation(urls[0])
+		var same = true
+
+		for _, u := range urls {
+			same = same && u.Scheme == urls[0].Scheme
+		}
+
+		if mfs, ok := fs.(MultiGetFileSystem); ok && same {
+			var cancel context.CancelFunc
+
+			ctx, cancel = withDefaultTimeout(ctx)
+			defer cancel()
+
+			return mfs.MultiGet(ctx, urls)
+		}
+	}
+
+	for _, u := range urls {
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(u *url.URL) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			var data, err = readFile(ctx, u)
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err != nil {
+				errs[u.String()] = err
+			} else {
+				contents[u.String()] = data
+			}
+		}(u)
+	}
+
+	wg.Wait()
+
+	return contents, errs, nil
+}
@@ -0,0 +1,39 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestMultiGet(t *testing.T) {
+	filesystem.AddImplementation("multiget", virtualfs.NewVirtualFileSystem())
+	a := &url.URL{Scheme: "multiget", Path: "/a.conf"}
+	b := &url.URL{Scheme: "multiget", Path: "/b.conf"}
+	missing := &url.URL{Scheme: "multiget", Path: "/missing.conf"}
+
+	writeTestFile(t, a, "alpha")
+	writeTestFile(t, b, "beta")
+
+	contents, errs, err := filesystem.MultiGet(context.Background(),
+		[]*url.URL{a, b, missing})
+	if err != nil {
+		t.Fatalf("Error reported from MultiGet: %v", err)
+	}
+
+	if string(contents[a.String()]) != "alpha" ||
+		string(contents[b.String()]) != "beta" {
+		t.Errorf("Unexpected contents %q", contents)
+	}
+	if !errors.Is(errs[missing.String()], fs.ErrNotExist) {
+		t.Errorf("Unexpected error for missing file: %v", errs[missing.String()])
+	}
+	if len(errs) != 1 {
+		t.Errorf("Unexpected errors %v", errs)
+	}
+}
@@ -0,0 +1,183 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"sync"
+)
+
+/*
+Number of goroutines MultiWatch uses to invoke the watcher if no number is
+specified in the MultiWatchOptions.
+*/
+const defaultMultiWatchWorkers = 8
+
+/*
+MultiWatchOptions controls the behavior of MultiWatch.
+*/
+type MultiWatchOptions struct {
+	// Number of goroutines invoking the watcher when falling back to
+	// individual WatchFile calls. At most this many callbacks run at the
+	// same time. Values below 1 select a default of 8.
+	Workers int
+}
+
+/*
+MultiWatchFunc is invoked by MultiWatch for every change of one of the
+watched files.
+*/
+type MultiWatchFunc func(changed *url.URL, rc ReadCloser)
+
+/*
+MultiWatchingFileSystem is implemented by file systems which can watch
+large numbers of files at once more efficiently than with individual
+WatchFile calls, e.g. using a single inotify instance.
+*/
+type MultiWatchingFileSystem interface {
+	MultiWatch(context.Context, []*url.URL, MultiWatchFunc) (
+		CancelWatchFunc, chan error, error)
+}
+
+/*
+A change reported by one of the watches of multiWatch.
+*/
+type multiWatchChange struct {
+	fileurl *url.URL
+	reader  ReadCloser
+}
+
+/*
+MultiWatch watches all files referenced by urls and invokes the watcher
+whenever one of them changes, like WatchFile.
+
+If all URLs are handled by the same file system and it implements
+MultiWatchingFileSystem, the files are watched natively. Otherwise every
+file is watched using WatchFile, and the changes are handed to a pool of
+opts.Workers goroutines; the errors of all watches are merged into the
+returned channel by a single goroutine, which closes it once the watch is
+cancelled. If any watch cannot be started, those already started are
+cancelled again.
+*/
+func MultiWatch(ctx context.Context, urls []*url.URL, watcher MultiWatchFunc,
+	opts MultiWatchOptions) (CancelWatchFunc, chan error, error) {
+	if len(urls) > 0 {
+		var fs = GetImplementation(urls[0])
+		var same = true
+
+		for _, u := range urls {
+			same = same && u.Scheme == urls[0].Scheme
+		}
+
+		if mfs, ok := fs.(MultiWatchingFileSystem); ok && same {
+			return mfs.MultiWatch(ctx, urls, watcher)
+		}
+	}
+
+	if opts.Workers < 1 {
+		opts.Workers = defaultMultiWatchWorkers
+	}
+
+	return multiWatch(ctx, urls, watcher, opts.Workers)
+}
+
+/*
+multiWatch implements MultiWatch on top of WatchFile, invoking the watcher
+from the specified number of goroutines.
+*/
+func multiWatch(ctx context.Context, urls []*url.URL, watcher MultiWatchFunc,
+	workers int) (CancelWatchFunc, chan error, error) {
+	var changes = make(chan multiWatchChange)
+	var merged = make(chan error, 1)
+	var done = make(chan struct{})
+	var cancels = make([]CancelWatchFunc, 0, len(urls))
+	var cases = make([]reflect.SelectCase, 1, len(urls)+1)
+	var wg sync.WaitGroup
+	var once sync.Once
+
+	var cancelAll = func() error {
+		var errs MultiError
+
+		once.Do(func() {
+			close(done)
+			for _, cancel := range cancels {
+				if err := cancel(); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		})
+
+		return errs.ErrorOrNil()
+	}
+
+	var deliver = func(changed *url.URL, rc ReadCloser) {
+		select {
+		case changes <- multiWatchChange{fileurl: changed, reader: rc}:
+		case <-done:
+			rc.Close(context.Background())
+		}
+	}
+
+	cases[0] = reflect.SelectCase{
+		Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)}
+
+	for _, u := range urls {
+		var cancel, errChan, err = WatchFile(ctx, u, deliver)
+
+		if err != nil {
+			cancelAll()
+			return nil, nil, err
+		}
+		cancels = append(cancels, cancel)
+		if errChan != nil {
+			cases = append(cases, reflect.SelectCase{
+				Dir: reflect.SelectRecv, Chan: reflect.ValueOf(errChan)})
+		}
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case change := <-changes:
+					watcher(change.fileurl, change.reader)
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(merged)
+
+		for len(cases) > 1 {
+			var chosen, value, ok = reflect.Select(cases)
+			var err error
+
+			if chosen == 0 {
+				return
+			}
+			if !ok {
+				cases = append(cases[:chosen], cases[chosen+1:]...)
+				continue
+			}
+			if err, ok = value.Interface().(error); !ok || err == nil {
+				continue
+			}
+			select {
+			case merged <- err:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() error {
+		var err = cancelAll()
+		wg.Wait()
+		return err
+	}, merged, nil
+}
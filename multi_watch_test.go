@@ -0,0 +1,120 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type WatchRecordingFileSystem struct {
+	MockFileSystem
+	watchers map[string]FileWatchFunc
+	errs     []chan error
+}
+
+func (fs *WatchRecordingFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	f FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	var errs = make(chan error)
+
+	fs.watchers[u.String()] = f
+	fs.errs = append(fs.errs, errs)
+	return func() error { return nil }, errs, nil
+}
+
+func TestMultiWatchFallback(t *testing.T) {
+	fs := &WatchRecordingFileSystem{watchers: make(map[string]FileWatchFunc)}
+	AddImplementation("multiwatch", fs)
+
+	urls := []*url.URL{
+		mustParse(t, "multiwatch:///a"),
+		mustParse(t, "multiwatch:///b"),
+	}
+	changes := make(chan string, 2)
+
+	cancel, _, err := MultiWatch(context.Background(), urls,
+		func(changed *url.URL, rc ReadCloser) {
+			changes <- changed.String()
+		}, MultiWatchOptions{Workers: 2})
+	if err != nil {
+		t.Fatalf("Error reported from MultiWatch: %v", err)
+	}
+
+	if len(fs.watchers) != 2 {
+		t.Fatalf("Expected 2 watches, got %d", len(fs.watchers))
+	}
+	fs.watchers["multiwatch:///b"](urls[1], &MockReadCloser{})
+
+	select {
+	case changed := <-changes:
+		if changed != "multiwatch:///b" {
+			t.Errorf("Unexpected change of %s", changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for change")
+	}
+
+	if err = cancel(); err != nil {
+		t.Errorf("Error reported from cancel: %v", err)
+	}
+}
+
+func TestMultiWatchSkipsNilErrors(t *testing.T) {
+	fs := &WatchRecordingFileSystem{watchers: make(map[string]FileWatchFunc)}
+	AddImplementation("multiwatchnil", fs)
+
+	cancel, errs, err := MultiWatch(context.Background(), []*url.URL{
+		mustParse(t, "multiwatchnil:///a"),
+	}, func(changed *url.URL, rc ReadCloser) {}, MultiWatchOptions{})
+	if err != nil {
+		t.Fatalf("Error reported from MultiWatch: %v", err)
+	}
+
+	defer cancel()
+
+	fs.errs[0] <- nil
+	fs.errs[0] <- ErrExpected
+
+	select {
+	case err = <-errs:
+		if err != ErrExpected {
+			t.Errorf("Unexpected error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for error")
+	}
+}
+
+func TestMultiWatchClosesErrorChannel(t *testing.T) {
+	fs := &WatchRecordingFileSystem{watchers: make(map[string]FileWatchFunc)}
+	AddImplementation("multiwatchclose", fs)
+	changes := make(chan string, 1)
+
+	cancel, errs, err := MultiWatch(context.Background(), []*url.URL{
+		mustParse(t, "multiwatchclose:///a"),
+	}, func(changed *url.URL, rc ReadCloser) {
+		changes <- changed.String()
+	}, MultiWatchOptions{Workers: -1})
+	if err != nil {
+		t.Fatalf("Error reported from MultiWatch: %v", err)
+	}
+
+	// A negative number of workers must still deliver changes.
+	fs.watchers["multiwatchclose:///a"](mustParse(t, "multiwatchclose:///a"),
+		&MockReadCloser{})
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for change")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-errs:
+		if ok {
+			t.Error("Unexpected error after cancellation")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Error channel not closed after cancellation")
+	}
+}
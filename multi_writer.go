@@ -0,0 +1,87 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"net/url"
+)
+
+/*
+Implementation of a WriteCloser which duplicates all writes to a number of
+underlying WriteClosers.
+*/
+type multiWriteCloser struct {
+	writers []WriteCloser
+}
+
+/*
+Write writes p to all underlying writers. Fails with the first error
+reported by any of the writers.
+*/
+func (m *multiWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	for _, w := range m.writers {
+		var n int
+		var err error
+
+		if n, err = w.Write(ctx, p); err != nil {
+			return n, err
+		}
+		if n != len(p) {
+			return n, io.ErrShortWrite
+		}
+	}
+
+	return len(p), nil
+}
+
+/*
+Close closes all underlying writers, even if some of them fail. All errors
+are reported as a MultiError.
+*/
+func (m *multiWriteCloser) Close(ctx context.Context) error {
+	var closers = make([]ContextCloser, len(m.writers))
+
+	for i, w := range m.writers {
+		closers[i] = w
+	}
+
+	return CloseAll(ctx, closers...)
+}
+
+/*
+MultiWriteCloser creates a WriteCloser which duplicates its writes to all of
+the specified writers, similar to io.MultiWriter. Closing it will close all
+of the writers.
+*/
+func MultiWriteCloser(writers ...WriteCloser) WriteCloser {
+	var w = make([]WriteCloser, len(writers))
+	copy(w, writers)
+	return &multiWriteCloser{writers: w}
+}
+
+/*
+OpenMultiWriter opens all of the referenced files for writing and returns a
+single WriteCloser which fans out all data written to it to every file. The
+URLs may refer to different file systems.
+
+If opening any of the files fails, all files opened so far are closed again
+and the error is returned.
+*/
+func OpenMultiWriter(ctx context.Context, urls []*url.URL) (WriteCloser, error) {
+	var writers []WriteCloser
+
+	for _, fileurl := range urls {
+		var wc WriteCloser
+		var err error
+
+		if wc, err = OpenWriter(ctx, fileurl); err != nil {
+			for _, w := range writers {
+				w.Close(ctx)
+			}
+			return nil, err
+		}
+		writers = append(writers, wc)
+	}
+
+	return &multiWriteCloser{writers: writers}, nil
+}
@@ -0,0 +1,73 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+type MockWriteCloser struct {
+	Fail   bool
+	Buf    bytes.Buffer
+	Closed bool
+}
+
+func (w *MockWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	if w.Fail {
+		return 0, ErrExpected
+	}
+	return w.Buf.Write(p)
+}
+
+func (w *MockWriteCloser) Close(ctx context.Context) error {
+	w.Closed = true
+	if w.Fail {
+		return ErrExpected
+	}
+	return nil
+}
+
+func TestMultiWriteCloserDuplicatesWrites(t *testing.T) {
+	a := &MockWriteCloser{}
+	b := &MockWriteCloser{}
+
+	w := MultiWriteCloser(a, b)
+
+	n, err := w.Write(context.Background(), []byte("hello"))
+	if err != nil {
+		t.Errorf("Error reported from write call: %s", err.Error())
+	}
+	if n != 5 {
+		t.Errorf("Write reported wrong length %d (expected 5)", n)
+	}
+
+	if a.Buf.String() != "hello" || b.Buf.String() != "hello" {
+		t.Errorf("Unexpected contents %q and %q", a.Buf.String(), b.Buf.String())
+	}
+
+	if err = w.Close(context.Background()); err != nil {
+		t.Errorf("Error reported from close call: %s", err.Error())
+	}
+	if !a.Closed || !b.Closed {
+		t.Error("Not all writers were closed")
+	}
+}
+
+func TestMultiWriteCloserAggregatesCloseErrors(t *testing.T) {
+	a := &MockWriteCloser{Fail: true}
+	b := &MockWriteCloser{}
+	c := &MockWriteCloser{Fail: true}
+
+	err := MultiWriteCloser(a, b, c).Close(context.Background())
+
+	merr, ok := err.(MultiError)
+	if !ok {
+		t.Fatalf("Expected MultiError from Close, got %v", err)
+	}
+	if len(merr) != 2 {
+		t.Errorf("Expected 2 errors, got %d", len(merr))
+	}
+	if !a.Closed || !b.Closed || !c.Closed {
+		t.Error("Not all writers were closed")
+	}
+}
@@ -0,0 +1,47 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+NotifyingFileSystem is implemented by file systems which support backend
+specific operations that do not fit the rest of the API, such as restoring
+archived S3 objects or checking the consistency of HDFS.
+*/
+type NotifyingFileSystem interface {
+	// Send the message with the parameters to the backend and return its
+	// response. Must return EUNSUPP for unknown messages.
+	Notify(context.Context, *url.URL, string, map[string]string) (
+		map[string]string, error)
+}
+
+/*
+Notify sends a backend specific control message, such as "restore",
+"snapshot", "fsck" or "evict-cache", concerning the referenced file to the
+file system, and returns the response of the backend. The meaning of the
+message, its parameters and the response are defined by the file system
+implementation; this is an escape hatch for operations not covered by the
+API. Messages not understood by the file system result in EUNSUPP.
+*/
+func Notify(ctx context.Context, fileurl *url.URL, message string,
+	params map[string]string) (map[string]string, error) {
+	var fs = GetImplementation(fileurl)
+	var nfs NotifyingFileSystem
+	var cancel context.CancelFunc
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if nfs, ok = fs.(NotifyingFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return nfs.Notify(ctx, fileurl, message, params)
+}
@@ -0,0 +1,65 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+/*
+notifyingFileSystem understands a single "size" message, which reports the
+size of the file in the base passed as parameter.
+*/
+type notifyingFileSystem struct {
+	*virtualfs.VirtualFileSystem
+}
+
+func (n notifyingFileSystem) Notify(ctx context.Context, u *url.URL,
+	message string, params map[string]string) (map[string]string, error) {
+	if message != "size" {
+		return nil, filesystem.EUNSUPP
+	}
+	base, err := strconv.Atoi(params["base"])
+	if err != nil {
+		return nil, err
+	}
+	fi, err := n.Stat(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"size": strconv.FormatInt(fi.Size(), base)}, nil
+}
+
+func TestNotify(t *testing.T) {
+	filesystem.AddImplementation("notifyplain", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("notify", notifyingFileSystem{
+		virtualfs.NewVirtualFileSystem()})
+	u := &url.URL{Scheme: "notify", Path: "/file"}
+	ctx := context.Background()
+
+	writeTestFile(t, u, "seventeen bytes!!")
+
+	if _, err := filesystem.Notify(ctx, &url.URL{Scheme: "notifyunregistered",
+		Path: "/file"}, "size", nil); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+	if _, err := filesystem.Notify(ctx, &url.URL{Scheme: "notifyplain",
+		Path: "/file"}, "size", nil); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP without support, got %v", err)
+	}
+	if _, err := filesystem.Notify(ctx, u, "fsck", nil); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP for unknown message, got %v", err)
+	}
+
+	resp, err := filesystem.Notify(ctx, u, "size", map[string]string{"base": "16"})
+	if err != nil {
+		t.Fatalf("Error reported from Notify: %v", err)
+	}
+	if resp["size"] != "11" {
+		t.Errorf("Unexpected response %v", resp)
+	}
+}
@@ -0,0 +1,41 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+)
+
+/*
+CountingNullWriteCloser is a WriteCloser which discards all data written to
+it, like io.Discard, while counting the number of bytes discarded. This is
+useful for dry runs and for measuring the throughput of data producers. The
+zero value is ready to use, and it is safe for concurrent use.
+*/
+type CountingNullWriteCloser struct {
+	n atomic.Int64
+}
+
+/*
+Write discards p and adds its length to the count.
+*/
+func (w *CountingNullWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	var n, err = io.Discard.Write(p)
+
+	w.n.Add(int64(n))
+	return n, err
+}
+
+/*
+Close does nothing.
+*/
+func (w *CountingNullWriteCloser) Close(ctx context.Context) error {
+	return nil
+}
+
+/*
+BytesDiscarded returns the number of bytes written so far.
+*/
+func (w *CountingNullWriteCloser) BytesDiscarded() int64 {
+	return w.n.Load()
+}
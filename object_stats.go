@@ -0,0 +1,132 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	iofs "io/fs"
+	"net/url"
+)
+
+/*
+ObjectStatsFileSystem is implemented by file systems which can report the
+number and size of the objects beneath a directory without listing them,
+e.g. from bucket metrics.
+*/
+type ObjectStatsFileSystem interface {
+	// Count the files beneath the directory, including subdirectories.
+	ObjectCount(context.Context, *url.URL) (int64, error)
+
+	// Sum up the sizes of the files beneath the directory, including
+	// subdirectories.
+	TotalSize(context.Context, *url.URL) (int64, error)
+}
+
+/*
+statRecursive retrieves the URLs and metadata of all files beneath the
+directory using ListEntriesRecursive and BulkStat. Files removed in the
+meantime are skipped.
+*/
+func statRecursive(ctx context.Context, dirurl *url.URL) (
+	[]*url.URL, []FileInfo, error) {
+	var paths []string
+	var urls, foundURLs []*url.URL
+	var infos, found []FileInfo
+	var errs []error
+	var err error
+
+	if paths, err = ListEntriesRecursive(ctx, dirurl); err != nil {
+		return nil, nil, err
+	}
+
+	for _, p := range paths {
+		urls = append(urls, childURL(dirurl, p))
+	}
+
+	if infos, errs, err = BulkStat(ctx, urls); err != nil {
+		return nil, nil, err
+	}
+
+	for i, fi := range infos {
+		if errors.Is(errs[i], iofs.ErrNotExist) {
+			continue
+		}
+		if errs[i] != nil {
+			return nil, nil, errs[i]
+		}
+		foundURLs = append(foundURLs, urls[i])
+		found = append(found, fi)
+	}
+
+	return foundURLs, found, nil
+}
+
+/*
+ObjectCount returns the number of files beneath the referenced directory,
+including all subdirectories.
+
+File systems which do not implement ObjectStatsFileSystem are listed using
+ListEntriesRecursive and BulkStat, which is correct but slow for large
+directories.
+*/
+func ObjectCount(ctx context.Context, dirurl *url.URL) (int64, error) {
+	var fs = GetImplementation(dirurl)
+	var infos []FileInfo
+	var err error
+
+	if fs == nil {
+		return 0, ENOFS
+	}
+
+	if ofs, ok := fs.(ObjectStatsFileSystem); ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = withDefaultTimeout(ctx)
+		defer cancel()
+
+		return ofs.ObjectCount(ctx, dirurl)
+	}
+
+	if _, infos, err = statRecursive(ctx, dirurl); err != nil {
+		return 0, err
+	}
+
+	return int64(len(infos)), nil
+}
+
+/*
+TotalSize returns the combined size in bytes of all files beneath the
+referenced directory, including all subdirectories.
+
+File systems which do not implement ObjectStatsFileSystem are listed using
+ListEntriesRecursive and BulkStat, which is correct but slow for large
+directories.
+*/
+func TotalSize(ctx context.Context, dirurl *url.URL) (int64, error) {
+	var fs = GetImplementation(dirurl)
+	var infos []FileInfo
+	var total int64
+	var err error
+
+	if fs == nil {
+		return 0, ENOFS
+	}
+
+	if ofs, ok := fs.(ObjectStatsFileSystem); ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = withDefaultTimeout(ctx)
+		defer cancel()
+
+		return ofs.TotalSize(ctx, dirurl)
+	}
+
+	if _, infos, err = statRecursive(ctx, dirurl); err != nil {
+		return 0, err
+	}
+
+	for _, fi := range infos {
+		total += fi.Size()
+	}
+
+	return total, nil
+}
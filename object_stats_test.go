@@ -0,0 +1,62 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestObjectCountAndTotalSize(t *testing.T) {
+	filesystem.AddImplementation("objstats", virtualfs.NewVirtualFileSystem())
+	dir := &url.URL{Scheme: "objstats", Path: "/data"}
+	ctx := context.Background()
+
+	for path, contents := range map[string]string{
+		"/data/a":       "12345",
+		"/data/sub/b":   "123",
+		"/data/sub/x/c": "1",
+		"/other/d":      "1234567890",
+	} {
+		writeTestFile(t, &url.URL{Scheme: "objstats", Path: path}, contents)
+	}
+
+	paths, err := filesystem.ListEntriesRecursive(ctx, dir)
+	if err != nil {
+		t.Fatalf("Error reported from ListEntriesRecursive: %v", err)
+	}
+	if strings.Join(paths, ",") != "a,sub/b,sub/x/c" {
+		t.Errorf("Unexpected paths %v", paths)
+	}
+
+	if count, err := filesystem.ObjectCount(ctx, dir); err != nil || count != 3 {
+		t.Errorf("Unexpected object count %d (%v)", count, err)
+	}
+	if size, err := filesystem.TotalSize(ctx, dir); err != nil || size != 9 {
+		t.Errorf("Unexpected total size %d (%v)", size, err)
+	}
+}
+
+func TestGetStorageStats(t *testing.T) {
+	filesystem.AddImplementation("storagestats", virtualfs.NewVirtualFileSystem())
+	dir := &url.URL{Scheme: "storagestats", Path: "/data"}
+
+	writeTestFile(t, &url.URL{Scheme: "storagestats", Path: "/data/a"}, "12345")
+	writeTestFile(t, &url.URL{Scheme: "storagestats", Path: "/data/sub/b"}, "123")
+
+	stats, err := filesystem.GetStorageStats(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Error reported from GetStorageStats: %v", err)
+	}
+	if stats.TotalObjects != 2 || stats.TotalBytes != 8 {
+		t.Errorf("Unexpected totals %d objects, %d bytes", stats.TotalObjects,
+			stats.TotalBytes)
+	}
+	if stats.OldestModTime.IsZero() || stats.NewestModTime.Before(stats.OldestModTime) {
+		t.Errorf("Unexpected modification times %v, %v", stats.OldestModTime,
+			stats.NewestModTime)
+	}
+}
@@ -0,0 +1,82 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+OffsetWriteCloser is a WriteCloser which can report the offset in the file
+at which data was written.
+*/
+type OffsetWriteCloser interface {
+	WriteCloser
+
+	// Write p and return the offset at which it starts in the file, or -1
+	// if the offset is not known, along with the number of bytes written.
+	WriteAtOffset(ctx context.Context, p []byte) (int64, int, error)
+}
+
+/*
+OffsetAppendingFileSystem is implemented by file systems which can report
+the exact offsets of appended data, e.g. local files opened with O_APPEND
+or Azure append blobs.
+*/
+type OffsetAppendingFileSystem interface {
+	// Open the file for appending like OpenAppender, reporting offsets.
+	OpenAppenderReturningOffset(context.Context, *url.URL) (OffsetWriteCloser, error)
+}
+
+/*
+OffsetWriteCloser for file systems which cannot report offsets.
+*/
+type unknownOffsetWriteCloser struct {
+	WriteCloser
+}
+
+/*
+WriteAtOffset writes p and reports an offset of -1.
+*/
+func (u *unknownOffsetWriteCloser) WriteAtOffset(ctx context.Context, p []byte) (
+	int64, int, error) {
+	var n, err = u.Write(ctx, p)
+	return -1, n, err
+}
+
+/*
+OpenAppenderReturningOffset opens the referenced file for appending, like
+OpenAppender, and returns an OffsetWriteCloser which reports the offset at
+which each chunk of data was written. This allows append-only stores to
+hand out positions to consumers.
+
+For file systems which implement neither OffsetAppendingFileSystem nor
+return an OffsetWriteCloser from OpenAppender, all offsets are reported as
+-1.
+*/
+func OpenAppenderReturningOffset(ctx context.Context, fileurl *url.URL) (
+	OffsetWriteCloser, error) {
+	var fs = GetImplementation(fileurl)
+	var wc WriteCloser
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if ofs, ok := fs.(OffsetAppendingFileSystem); ok {
+		return ofs.OpenAppenderReturningOffset(ctx, fileurl)
+	}
+
+	if wc, err = fs.OpenAppender(ctx, fileurl); err != nil {
+		return nil, err
+	}
+
+	if owc, ok := wc.(OffsetWriteCloser); ok {
+		return owc, nil
+	}
+	return &unknownOffsetWriteCloser{wc}, nil
+}
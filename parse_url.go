@@ -0,0 +1,79 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+/*
+ErrInvalidURL is returned by ParseURL and ParseFileURL for URLs which do not
+pass validation. The error message describes the invalid part.
+*/
+var ErrInvalidURL = errors.New("Invalid URL")
+
+/*
+isSchemeChar determines whether c may be used in a URL scheme.
+*/
+func isSchemeChar(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+		c == '+' || c == '-' || c == '.'
+}
+
+/*
+ParseURL parses rawurl like url.Parse, but applies stricter validation: the
+scheme must be present and consist only of lower case letters, digits, "+",
+"-" and ".", and the URL must not contain unescaped spaces or control
+characters. The host name of the returned URL is converted to lower case.
+*/
+func ParseURL(rawurl string) (*url.URL, error) {
+	var scheme, _, found = strings.Cut(rawurl, ":")
+	var u *url.URL
+	var err error
+
+	if !found || scheme == "" {
+		return nil, fmt.Errorf("%w %q: missing scheme", ErrInvalidURL, rawurl)
+	}
+
+	for _, c := range scheme {
+		if !isSchemeChar(c) {
+			return nil, fmt.Errorf("%w %q: invalid character %q in scheme %q",
+				ErrInvalidURL, rawurl, c, scheme)
+		}
+	}
+
+	for i, c := range rawurl {
+		if c == ' ' || c < 0x20 || c == 0x7f {
+			return nil, fmt.Errorf("%w %q: unescaped character %q at offset %d",
+				ErrInvalidURL, rawurl, c, i)
+		}
+	}
+
+	if u, err = url.Parse(rawurl); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	u.Host = strings.ToLower(u.Host)
+
+	return u, nil
+}
+
+/*
+ParseFileURL parses and validates rawurl like ParseURL, and additionally
+requires a file system implementation to be registered for its scheme.
+*/
+func ParseFileURL(rawurl string) (*url.URL, error) {
+	var u, err = ParseURL(rawurl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasImplementation(u.Scheme) {
+		return nil, fmt.Errorf("%w %q: no file system registered for scheme %q",
+			ErrInvalidURL, rawurl, u.Scheme)
+	}
+
+	return u, nil
+}
@@ -0,0 +1,44 @@
+package filesystem
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseURLValid(t *testing.T) {
+	u, err := ParseURL("s3+compat://Bucket.Example.com/path/to%20file?x=1")
+	if err != nil {
+		t.Fatalf("Error reported from ParseURL: %v", err)
+	}
+	if u.Scheme != "s3+compat" || u.Host != "bucket.example.com" ||
+		u.Path != "/path/to file" {
+		t.Errorf("Unexpected parse result %#v", u)
+	}
+}
+
+func TestParseURLInvalid(t *testing.T) {
+	for _, rawurl := range []string{
+		"/no/scheme",
+		":empty",
+		"UPPER://host/path",
+		"sch_eme://host/path",
+		"file:///path with space",
+		"file:///path\twith\ttab",
+	} {
+		if _, err := ParseURL(rawurl); !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("Unexpected error parsing %q: %v", rawurl, err)
+		}
+	}
+}
+
+func TestParseFileURLRequiresImplementation(t *testing.T) {
+	AddImplementation("mock", &MockFileSystem{})
+
+	if _, err := ParseFileURL("mock:///file"); err != nil {
+		t.Errorf("Error reported from ParseFileURL: %v", err)
+	}
+	if _, err := ParseFileURL("unregistered:///file"); !errors.Is(
+		err, ErrInvalidURL) {
+		t.Errorf("Unexpected error from ParseFileURL: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package filesystem
+
+import (
+	"fmt"
+)
+
+/*
+PartialWriteError is returned by WriteCloser implementations which detected
+that a write failed after some, but not all, of the data was written. The
+file then contains BytesWritten bytes of the data passed to the failed
+write, and may need to be rolled back or rewritten by the caller.
+
+Use errors.As to check whether an error describes a partial write.
+*/
+type PartialWriteError struct {
+	// Number of bytes written before the failure occurred.
+	BytesWritten int64
+
+	// Error which caused the write to fail.
+	Err error
+}
+
+/*
+Error describes the partial write along with its cause.
+*/
+func (p *PartialWriteError) Error() string {
+	return fmt.Sprintf("Write failed after %d bytes: %v", p.BytesWritten, p.Err)
+}
+
+/*
+Unwrap returns the error which caused the write to fail.
+*/
+func (p *PartialWriteError) Unwrap() error {
+	return p.Err
+}
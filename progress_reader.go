@@ -0,0 +1,64 @@
+package filesystem
+
+import (
+	"context"
+)
+
+/*
+ProgressFunc describes a function which is invoked to report the progress of
+a transfer. The first parameter is the number of bytes transferred so far,
+the second one is the total number of bytes to be transferred, or -1 if the
+total is unknown.
+*/
+type ProgressFunc func(bytesCopied, total int64)
+
+/*
+ProgressReadCloser wraps a ReadCloser and reports the number of bytes read
+from it to a ProgressFunc after every successful Read.
+*/
+type ProgressReadCloser struct {
+	/* Underlying reader object */
+	R ReadCloser
+
+	/* Total number of bytes expected to be read, or -1 if unknown. */
+	Total int64
+
+	/* Function to report progress to. May be nil. */
+	Fn ProgressFunc
+
+	/* Number of bytes read so far. */
+	n int64
+}
+
+/*
+Read reads up to len(p) bytes from the underlying file system object and
+reports the accumulated number of bytes read to Fn.
+*/
+func (p *ProgressReadCloser) Read(ctx context.Context, buf []byte) (int, error) {
+	var n int
+	var err error
+
+	n, err = p.R.Read(ctx, buf)
+	if n > 0 {
+		p.n += int64(n)
+		if p.Fn != nil {
+			p.Fn(p.n, p.Total)
+		}
+	}
+	return n, err
+}
+
+/*
+BytesRead returns the number of bytes read through the ProgressReadCloser
+so far.
+*/
+func (p *ProgressReadCloser) BytesRead() int64 {
+	return p.n
+}
+
+/*
+Close calls the close method of the underlying implementation.
+*/
+func (p *ProgressReadCloser) Close(ctx context.Context) error {
+	return p.R.Close(ctx)
+}
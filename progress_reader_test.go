@@ -0,0 +1,53 @@
+package filesystem
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProgressReadCloserReportsProgress(t *testing.T) {
+	var reports []int64
+	buf := make([]byte, 10)
+
+	p := ProgressReadCloser{
+		R:     &LimitedReadCloser{R: &MockReadCloser{}, N: 25},
+		Total: 25,
+		Fn: func(copied, total int64) {
+			if total != 25 {
+				t.Errorf("Unexpected total %d, expected 25", total)
+			}
+			reports = append(reports, copied)
+		},
+	}
+
+	for i := 0; i < 4; i++ {
+		p.Read(context.Background(), buf)
+	}
+
+	if len(reports) != 3 {
+		t.Fatalf("Expected 3 progress reports, got %d", len(reports))
+	}
+	if reports[0] != 10 || reports[1] != 20 || reports[2] != 25 {
+		t.Errorf("Unexpected progress reports %v", reports)
+	}
+	if p.BytesRead() != 25 {
+		t.Errorf("BytesRead reported %d, expected 25", p.BytesRead())
+	}
+}
+
+func TestProgressReadCloserForwardsReadError(t *testing.T) {
+	buf := make([]byte, 10)
+	called := false
+
+	p := ProgressReadCloser{
+		R:  &MockReadCloser{Fail: true},
+		Fn: func(int64, int64) { called = true },
+	}
+
+	if _, err := p.Read(context.Background(), buf); err != ErrExpected {
+		t.Errorf("Unexpected error from Read: %v", err)
+	}
+	if called {
+		t.Error("Progress reported for failed read")
+	}
+}
@@ -0,0 +1,289 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+/*
+ResumeFunc re-enables writes which were quiesced using QuiesceWrites. It
+may be called any number of times.
+*/
+type ResumeFunc func()
+
+/*
+QuiescingFileSystem is implemented by file systems which can temporarily
+block modifications of a directory tree, e.g. while a backup is taken.
+*/
+type QuiescingFileSystem interface {
+	// Block new modifications beneath the URL, wait for those in progress
+	// to finish and return a function which unblocks them again.
+	QuiesceWrites(context.Context, *url.URL) (ResumeFunc, error)
+}
+
+/*
+QuiesceWrites blocks all new OpenWriter, OpenAppender and Remove calls for
+files beneath root, waits for writers opened previously to be closed and
+for removals in progress to finish, and returns a function re-enabling the
+blocked operations. This is the file system equivalent of FLUSH TABLES
+WITH READ LOCK in databases. If the operations in progress do not finish
+before ctx is done, writes are re-enabled and ctx.Err() is returned.
+
+Most file systems cannot do this on their own; wrap them using
+NewQuiescableFileSystem to add support. Other file systems return EUNSUPP.
+*/
+func QuiesceWrites(ctx context.Context, root *url.URL) (ResumeFunc, error) {
+	var fs = GetImplementation(root)
+	var qfs QuiescingFileSystem
+	var cancel context.CancelFunc
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if qfs, ok = fs.(QuiescingFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return qfs.QuiesceWrites(ctx, root)
+}
+
+/*
+A directory tree whose modification is currently blocked.
+*/
+type quiescedTree struct {
+	root    string
+	resumed chan struct{}
+}
+
+/*
+FileSystem wrapper which keeps track of modifications in progress so that
+they can be quiesced.
+*/
+type quiescableFileSystem struct {
+	inner    FileSystem
+	lock     sync.Mutex
+	quiesced map[*quiescedTree]struct{}
+	inflight map[*string]struct{}
+	finished chan struct{}
+}
+
+/*
+NewQuiescableFileSystem wraps inner into a FileSystem which supports
+QuiesceWrites. Only modifications made through the wrapper are taken into
+account.
+
+The wrapper only supports the FileSystem methods.
+*/
+func NewQuiescableFileSystem(inner FileSystem) FileSystem {
+	return &quiescableFileSystem{
+		inner:    inner,
+		quiesced: make(map[*quiescedTree]struct{}),
+		inflight: make(map[*string]struct{}),
+		finished: make(chan struct{}),
+	}
+}
+
+/*
+beneath determines whether the cleaned path p is root or inside it.
+*/
+func beneath(p, root string) bool {
+	return root == "/" || p == root || strings.HasPrefix(p, root+"/")
+}
+
+/*
+beginWrite waits until modifications of the file are not quiesced, and
+registers a modification in progress. The returned function must be called
+exactly once when the modification is complete.
+*/
+func (q *quiescableFileSystem) beginWrite(ctx context.Context, u *url.URL) (
+	func(), error) {
+	var p = path.Clean("/" + u.Path)
+
+	for {
+		var blocker *quiescedTree
+
+		q.lock.Lock()
+		for tree := range q.quiesced {
+			if beneath(p, tree.root) {
+				blocker = tree
+				break
+			}
+		}
+		if blocker == nil {
+			q.inflight[&p] = struct{}{}
+			q.lock.Unlock()
+			break
+		}
+		q.lock.Unlock()
+
+		select {
+		case <-blocker.resumed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return func() {
+		q.lock.Lock()
+		defer q.lock.Unlock()
+
+		delete(q.inflight, &p)
+		close(q.finished)
+		q.finished = make(chan struct{})
+	}, nil
+}
+
+/*
+QuiesceWrites blocks modifications beneath root. See QuiesceWrites.
+*/
+func (q *quiescableFileSystem) QuiesceWrites(ctx context.Context, root *url.URL) (
+	ResumeFunc, error) {
+	var tree = &quiescedTree{
+		root: path.Clean("/" + root.Path), resumed: make(chan struct{})}
+	var once sync.Once
+	var resume = func() {
+		once.Do(func() {
+			q.lock.Lock()
+			defer q.lock.Unlock()
+
+			delete(q.quiesced, tree)
+			close(tree.resumed)
+		})
+	}
+
+	q.lock.Lock()
+	q.quiesced[tree] = struct{}{}
+
+	for {
+		var busy bool
+		var finished = q.finished
+
+		for p := range q.inflight {
+			if beneath(*p, tree.root) {
+				busy = true
+				break
+			}
+		}
+		q.lock.Unlock()
+
+		if !busy {
+			return resume, nil
+		}
+
+		select {
+		case <-finished:
+		case <-ctx.Done():
+			resume()
+			return nil, ctx.Err()
+		}
+
+		q.lock.Lock()
+	}
+}
+
+/*
+WriteCloser which reports the end of a modification when it is closed.
+*/
+type quiescableWriteCloser struct {
+	WriteCloser
+	done func()
+	once sync.Once
+}
+
+/*
+Close closes the underlying WriteCloser and marks the modification as
+complete.
+*/
+func (w *quiescableWriteCloser) Close(ctx context.Context) error {
+	defer w.once.Do(w.done)
+	return w.WriteCloser.Close(ctx)
+}
+
+/*
+openWriter opens a file using open once modifications are not quiesced.
+*/
+func (q *quiescableFileSystem) openWriter(ctx context.Context, u *url.URL,
+	open func(context.Context, *url.URL) (WriteCloser, error)) (WriteCloser, error) {
+	var done, err = q.beginWrite(ctx, u)
+	var wc WriteCloser
+
+	if err != nil {
+		return nil, err
+	}
+	if wc, err = open(ctx, u); err != nil {
+		done()
+		return nil, err
+	}
+	return &quiescableWriteCloser{WriteCloser: wc, done: done}, nil
+}
+
+/*
+OpenReader opens the file for reading.
+*/
+func (q *quiescableFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	return q.inner.OpenReader(ctx, u)
+}
+
+/*
+OpenWriter opens the file for writing, waiting while writes are quiesced.
+*/
+func (q *quiescableFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return q.openWriter(ctx, u, q.inner.OpenWriter)
+}
+
+/*
+OpenAppender opens the file for appending, waiting while writes are
+quiesced.
+*/
+func (q *quiescableFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return q.openWriter(ctx, u, q.inner.OpenAppender)
+}
+
+/*
+ListEntries lists the directory.
+*/
+func (q *quiescableFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	return q.inner.ListEntries(ctx, u)
+}
+
+/*
+WatchFile watches the file.
+*/
+func (q *quiescableFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	return q.inner.WatchFile(ctx, u, watcher)
+}
+
+/*
+Remove deletes the file, waiting while writes are quiesced.
+*/
+func (q *quiescableFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	var done, err = q.beginWrite(ctx, u)
+
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	return q.inner.Remove(ctx, u)
+}
+
+/*
+Stat retrieves the metadata of the file from the wrapped file system.
+*/
+func (q *quiescableFileSystem) Stat(ctx context.Context, u *url.URL) (
+	FileInfo, error) {
+	return q.inner.Stat(ctx, u)
+}
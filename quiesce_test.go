@@ -0,0 +1,68 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestQuiesceWrites(t *testing.T) {
+	filesystem.AddImplementation("quiesce", filesystem.NewQuiescableFileSystem(
+		virtualfs.NewVirtualFileSystem()))
+	root := &url.URL{Scheme: "quiesce", Path: "/db"}
+	file := &url.URL{Scheme: "quiesce", Path: "/db/table"}
+	ctx := context.Background()
+
+	wc, err := filesystem.OpenWriter(ctx, file)
+	if err != nil {
+		t.Fatalf("Error reported from OpenWriter: %v", err)
+	}
+
+	shortCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err = filesystem.QuiesceWrites(shortCtx, root); err != context.DeadlineExceeded {
+		t.Errorf("Unexpected error with write in progress: %v", err)
+	}
+
+	wc.Close(ctx)
+	resume, err := filesystem.QuiesceWrites(ctx, root)
+	if err != nil {
+		t.Fatalf("Error reported from QuiesceWrites: %v", err)
+	}
+
+	writeTestFile(t, &url.URL{Scheme: "quiesce", Path: "/other"}, "unaffected")
+
+	opened := make(chan error, 1)
+	go func() {
+		wc, err := filesystem.OpenWriter(ctx, file)
+		if err != nil {
+			opened <- err
+			return
+		}
+		if _, err = wc.Write(ctx, []byte("after resume")); err != nil {
+			wc.Close(ctx)
+			opened <- err
+			return
+		}
+		opened <- wc.Close(ctx)
+	}()
+
+	select {
+	case <-opened:
+		t.Fatal("Write was not blocked while quiesced")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	resume()
+	resume()
+	if err = <-opened; err != nil {
+		t.Errorf("Error writing after resume: %v", err)
+	}
+	if data, _ := readTestFile(t, file); data != "after resume" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+}
@@ -0,0 +1,57 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+ReadWriteAtCloser provides full random access to a file: sequential and
+positional reads and writes as well as truncation.
+*/
+type ReadWriteAtCloser interface {
+	ReadCloser
+	WriteCloser
+	ReaderAt
+	WriterAt
+
+	// Change the size of the file to the specified number of bytes.
+	Truncate(context.Context, int64) error
+}
+
+/*
+RandomAccessFileSystem is implemented by file systems which can provide
+random access to files. Object stores usually cannot.
+*/
+type RandomAccessFileSystem interface {
+	// Open the specified file for random access, creating it if it did not
+	// exist. Context should be used to control the opening only.
+	OpenRandomAccess(context.Context, *url.URL) (ReadWriteAtCloser, error)
+}
+
+/*
+OpenRandomAccess opens the referenced file for reading and writing at
+arbitrary offsets. If the file does not exist, it will be created. File
+systems which do not implement RandomAccessFileSystem will cause EUNSUPP to
+be returned.
+*/
+func OpenRandomAccess(ctx context.Context, fileurl *url.URL) (
+	ReadWriteAtCloser, error) {
+	var fs = GetImplementation(fileurl)
+	var rafs RandomAccessFileSystem
+	var cancel context.CancelFunc
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if rafs, ok = fs.(RandomAccessFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return rafs.OpenRandomAccess(ctx, fileurl)
+}
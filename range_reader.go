@@ -0,0 +1,98 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+)
+
+/*
+ErrInvalidRange is returned by OpenReaderRange if the start of the range is
+negative or the end precedes the start.
+*/
+var ErrInvalidRange = errors.New("Invalid byte range")
+
+/*
+RangeReadingFileSystem is implemented by file systems which can read a byte
+range of a file natively, such as HTTP servers or S3 using Range requests,
+so that only the requested bytes are transferred.
+*/
+type RangeReadingFileSystem interface {
+	// Open the file for reading the bytes from start up to, but not
+	// including, end. An end of -1 means reading until the end of the
+	// file.
+	OpenReaderRange(context.Context, *url.URL, int64, int64) (ReadCloser, error)
+}
+
+/*
+skipBytes advances rc by n bytes, using Seek if rc supports it and reading
+and discarding the data otherwise. Reaching the end of the file early is
+not an error; subsequent reads will simply report io.EOF.
+*/
+func skipBytes(ctx context.Context, rc ReadCloser, n int64) error {
+	var err error
+
+	if n <= 0 {
+		return nil
+	}
+
+	if s, ok := rc.(Seeker); ok {
+		_, err = s.Seek(ctx, n, io.SeekStart)
+		return err
+	}
+
+	_, err = io.CopyN(io.Discard,
+		ToIoReadCloser(NewContextReadCloser(rc, ctx)), n)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+/*
+OpenReaderRange opens the referenced file for reading starting at byte
+offset start, and returns at most end - start bytes. If end is -1, the file
+is read until its end.
+
+File systems implementing RangeReadingFileSystem only transfer the
+requested bytes. For all other file systems, the file is opened using
+OpenReader, the reader is advanced to start by seeking or by discarding
+data, and wrapped in a LimitedReadCloser.
+*/
+func OpenReaderRange(ctx context.Context, fileurl *url.URL, start, end int64) (
+	ReadCloser, error) {
+	var fs = GetImplementation(fileurl)
+	var rc ReadCloser
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if start < 0 || (end >= 0 && end < start) {
+		return nil, ErrInvalidRange
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if rfs, ok := fs.(RangeReadingFileSystem); ok {
+		return rfs.OpenReaderRange(ctx, fileurl, start, end)
+	}
+
+	if rc, err = fs.OpenReader(ctx, fileurl); err != nil {
+		return nil, err
+	}
+
+	if err = skipBytes(ctx, rc, start); err != nil {
+		rc.Close(ctx)
+		return nil, err
+	}
+
+	if end < 0 {
+		return rc, nil
+	}
+	return &LimitedReadCloser{R: rc, N: end - start}, nil
+}
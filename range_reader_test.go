@@ -0,0 +1,32 @@
+package filesystem_test
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestOpenReaderRangeFallback(t *testing.T) {
+	filesystem.AddImplementation("range", virtualfs.NewVirtualFileSystem())
+	u := &url.URL{Scheme: "range", Path: "/file"}
+	writeTestFile(t, u, "0123456789")
+
+	rc, err := filesystem.OpenReaderRange(context.Background(), u, 3, 7)
+	if err != nil {
+		t.Fatalf("Error reported from OpenReaderRange: %v", err)
+	}
+	defer rc.Close(context.Background())
+
+	data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc))
+	if string(data) != "3456" {
+		t.Errorf("Unexpected data %q", data)
+	}
+
+	if _, err = filesystem.OpenReaderRange(context.Background(), u, 5, 2); err != filesystem.ErrInvalidRange {
+		t.Errorf("Unexpected error for invalid range: %v", err)
+	}
+}
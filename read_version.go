@@ -0,0 +1,256 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+VersionedFileSystem is a read-only view of a directory tree as it was at a
+specific point in time. Operations modifying the tree fail with
+ErrImmutable.
+*/
+type VersionedFileSystem interface {
+	FileSystem
+
+	// Identifier of the state of the tree presented by the view.
+	Version() string
+
+	// Release the resources held by the view.
+	Close(context.Context) error
+}
+
+/*
+SnapshotReadingFileSystem is implemented by file systems which can present
+a consistent view of a directory tree cheaply, e.g. object stores with
+versioning or file systems with native snapshots.
+*/
+type SnapshotReadingFileSystem interface {
+	// Create a read-only view of the tree beneath the URL.
+	ReadVersion(context.Context, *url.URL) (VersionedFileSystem, error)
+}
+
+/*
+ReadCloser returning the contents of a byte slice.
+*/
+type bytesReadCloser struct {
+	r *bytes.Reader
+}
+
+/*
+Read reads from the byte slice.
+*/
+func (b *bytesReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	return b.r.Read(p)
+}
+
+/*
+Close does nothing.
+*/
+func (b *bytesReadCloser) Close(ctx context.Context) error {
+	return nil
+}
+
+/*
+VersionedFileSystem holding copies of the metadata and contents of all files
+of a directory tree in memory.
+*/
+type snapshotFileSystem struct {
+	root    string
+	version string
+
+	lock  sync.Mutex
+	infos map[string]FileInfo
+	files map[string][]byte
+}
+
+/*
+key determines the path of the file relative to the root of the snapshot.
+*/
+func (s *snapshotFileSystem) key(u *url.URL) string {
+	return strings.TrimPrefix(path.Clean("/"+u.Path), s.root)
+}
+
+/*
+Version returns the time at which the snapshot was taken.
+*/
+func (s *snapshotFileSystem) Version() string {
+	return s.version
+}
+
+/*
+Close discards the copies of the files.
+*/
+func (s *snapshotFileSystem) Close(ctx context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.infos = nil
+	s.files = nil
+	return nil
+}
+
+/*
+OpenReader returns a reader for the copy of the file.
+*/
+func (s *snapshotFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	var data []byte
+	var ok bool
+
+	s.lock.Lock()
+	data, ok = s.files[s.key(u)]
+	s.lock.Unlock()
+
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: u.Path, Err: fs.ErrNotExist}
+	}
+	return &bytesReadCloser{r: bytes.NewReader(data)}, nil
+}
+
+/*
+OpenWriter fails with ErrImmutable.
+*/
+func (s *snapshotFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return nil, ErrImmutable
+}
+
+/*
+OpenAppender fails with ErrImmutable.
+*/
+func (s *snapshotFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return nil, ErrImmutable
+}
+
+/*
+ListEntries lists the files and directories contained in the snapshot
+directly beneath the URL.
+*/
+func (s *snapshotFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	var prefix = strings.TrimSuffix(s.key(u), "/") + "/"
+	var seen = make(map[string]bool)
+	var names []string
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for name := range s.infos {
+		var entry string
+
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		entry, _, _ = strings.Cut(name[len(prefix):], "/")
+		if !seen[entry] {
+			seen[entry] = true
+			names = append(names, entry)
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+/*
+WatchFile is not supported since the snapshot never changes.
+*/
+func (s *snapshotFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	return nil, nil, EUNSUPP
+}
+
+/*
+Remove fails with ErrImmutable.
+*/
+func (s *snapshotFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	return ErrImmutable
+}
+
+/*
+Stat returns the metadata the file had when the snapshot was taken.
+*/
+func (s *snapshotFileSystem) Stat(ctx context.Context, u *url.URL) (
+	FileInfo, error) {
+	var info FileInfo
+	var ok bool
+
+	s.lock.Lock()
+	info, ok = s.infos[s.key(u)]
+	s.lock.Unlock()
+
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: u.Path, Err: fs.ErrNotExist}
+	}
+	return info, nil
+}
+
+/*
+ReadVersion returns a read-only view of the directory tree beneath root
+which reflects its state at the time of the call, even if it is modified
+afterwards. The URLs passed to the view are the same as for the underlying
+file system. Close the view once it is no longer needed.
+
+For file systems not implementing SnapshotReadingFileSystem, the metadata
+and contents of all files are copied into memory using ListEntriesRecursive,
+Stat and OpenReader before ReadVersion returns, so this is only suitable for
+small trees such as configuration directories. Files modified while the
+copy is being made may be captured in either state. The version is the
+time at which copying started.
+*/
+func ReadVersion(ctx context.Context, root *url.URL) (VersionedFileSystem, error) {
+	var fs = GetImplementation(root)
+	var snapshot *snapshotFileSystem
+	var paths []string
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if sfs, ok := fs.(SnapshotReadingFileSystem); ok {
+		return sfs.ReadVersion(ctx, root)
+	}
+
+	snapshot = &snapshotFileSystem{
+		root:    strings.TrimSuffix(path.Clean("/"+root.Path), "/"),
+		version: time.Now().UTC().Format(time.RFC3339Nano),
+		infos:   make(map[string]FileInfo),
+		files:   make(map[string][]byte),
+	}
+
+	if paths, err = ListEntriesRecursive(ctx, root); err != nil {
+		return nil, err
+	}
+
+	for _, p := range paths {
+		var u = childURL(root, p)
+		var info FileInfo
+		var data []byte
+
+		if info, err = fs.Stat(ctx, u); err != nil {
+			return nil, err
+		}
+		if data, err = readFile(ctx, u); err != nil {
+			return nil, err
+		}
+		snapshot.infos["/"+p] = info
+		snapshot.files["/"+p] = data
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,66 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestReadVersion(t *testing.T) {
+	filesystem.AddImplementation("versioned", virtualfs.NewVirtualFileSystem())
+	root := &url.URL{Scheme: "versioned", Path: "/config"}
+	file := &url.URL{Scheme: "versioned", Path: "/config/app/settings"}
+
+	other := &url.URL{Scheme: "versioned", Path: "/config/app/other"}
+	ctx := context.Background()
+
+	writeTestFile(t, file, "old")
+	writeTestFile(t, other, "old")
+
+	view, err := filesystem.ReadVersion(ctx, root)
+	if err != nil {
+		t.Fatalf("Error reported from ReadVersion: %v", err)
+	}
+	defer view.Close(ctx)
+
+	// Rewrite both files with contents of the same size, one of them
+	// after it has already been read from the view.
+	rc, err := view.OpenReader(ctx, file)
+	if err != nil {
+		t.Fatalf("Error reported from OpenReader: %v", err)
+	}
+	rc.Close(ctx)
+
+	writeTestFile(t, file, "new")
+	writeTestFile(t, other, "new")
+	writeTestFile(t, &url.URL{Scheme: "versioned", Path: "/config/added"}, "new")
+
+	for _, u := range []*url.URL{file, other} {
+		if rc, err = view.OpenReader(ctx, u); err != nil {
+			t.Fatalf("Error reported from OpenReader: %v", err)
+		}
+		data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc))
+		if string(data) != "old" {
+			t.Errorf("Snapshot of %s reflects later write: %q", u, data)
+		}
+	}
+
+	entries, _ := view.ListEntries(ctx, root)
+	if strings.Join(entries, ",") != "app" {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+
+	if _, err = view.OpenWriter(ctx, file); !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Unexpected error from OpenWriter: %v", err)
+	}
+	if view.Version() == "" {
+		t.Error("Snapshot has no version")
+	}
+}
@@ -0,0 +1,88 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+ReindexProgress reports the progress of a ReindexWithProgress operation.
+The counters are cumulative.
+*/
+type ReindexProgress struct {
+	// Number of files scanned so far.
+	FilesScanned int64
+
+	// Combined size of the files scanned so far, in bytes.
+	BytesScanned int64
+
+	// Errors encountered since the previous progress report.
+	Errors []error
+}
+
+/*
+ReindexingFileSystem is implemented by file systems which maintain an index
+of their contents which can become stale, such as archive formats or
+content addressed stores.
+*/
+type ReindexingFileSystem interface {
+	// Rescan everything beneath the URL and rebuild the index.
+	Reindex(context.Context, *url.URL) error
+
+	// Like Reindex, but return immediately and report progress on the
+	// channel, which must be closed once the operation finished.
+	ReindexWithProgress(context.Context, *url.URL) (<-chan ReindexProgress, error)
+}
+
+/*
+getReindexingFileSystem determines the ReindexingFileSystem responsible for
+the URL, or returns ENOFS or EUNSUPP if there is none.
+*/
+func getReindexingFileSystem(fileurl *url.URL) (ReindexingFileSystem, error) {
+	var fs = GetImplementation(fileurl)
+	var rfs ReindexingFileSystem
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if rfs, ok = fs.(ReindexingFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	return rfs, nil
+}
+
+/*
+Reindex rescans everything beneath root and rebuilds the internal index of
+the file system, e.g. after disaster recovery or a migration. File systems
+which do not maintain an index return EUNSUPP.
+
+As a full rescan can take very long, the default timeout is not applied.
+*/
+func Reindex(ctx context.Context, root *url.URL) error {
+	var rfs, err = getReindexingFileSystem(root)
+
+	if err != nil {
+		return err
+	}
+
+	return rfs.Reindex(ctx, root)
+}
+
+/*
+ReindexWithProgress starts rebuilding the index like Reindex, and returns a
+channel on which progress is reported. The channel is closed once the
+operation finished; cancel ctx to abort it.
+*/
+func ReindexWithProgress(ctx context.Context, root *url.URL) (
+	<-chan ReindexProgress, error) {
+	var rfs, err = getReindexingFileSystem(root)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rfs.ReindexWithProgress(ctx, root)
+}
@@ -0,0 +1,101 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+/*
+reindexingFileSystem rescans the files beneath the root on every reindex,
+and remembers whether the context of the last Reindex had a deadline.
+*/
+type reindexingFileSystem struct {
+	*virtualfs.VirtualFileSystem
+	hadDeadline bool
+}
+
+func (r *reindexingFileSystem) scan(ctx context.Context, root *url.URL,
+	report func(filesystem.ReindexProgress)) error {
+	var progress filesystem.ReindexProgress
+
+	names, err := filesystem.ListEntriesRecursive(ctx, root)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		fi, err := r.Stat(ctx, &url.URL{Scheme: root.Scheme,
+			Path: path.Join(root.Path, name)})
+		if err != nil {
+			return err
+		}
+		progress.FilesScanned++
+		progress.BytesScanned += fi.Size()
+		report(progress)
+	}
+	return nil
+}
+
+func (r *reindexingFileSystem) Reindex(ctx context.Context, u *url.URL) error {
+	_, r.hadDeadline = ctx.Deadline()
+	return r.scan(ctx, u, func(filesystem.ReindexProgress) {})
+}
+
+func (r *reindexingFileSystem) ReindexWithProgress(ctx context.Context,
+	u *url.URL) (<-chan filesystem.ReindexProgress, error) {
+	progress := make(chan filesystem.ReindexProgress)
+
+	go func() {
+		defer close(progress)
+		r.scan(ctx, u, func(p filesystem.ReindexProgress) { progress <- p })
+	}()
+	return progress, nil
+}
+
+func TestReindex(t *testing.T) {
+	rfs := &reindexingFileSystem{VirtualFileSystem: virtualfs.NewVirtualFileSystem()}
+	filesystem.AddImplementation("reindexplain", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("reindex", rfs)
+	root := &url.URL{Scheme: "reindex", Path: "/index"}
+	ctx := context.Background()
+
+	filesystem.SetDefaultTimeout(time.Minute)
+	defer filesystem.SetDefaultTimeout(0)
+
+	if err := filesystem.Reindex(ctx, &url.URL{Scheme: "reindexunregistered",
+		Path: "/"}); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+	if _, err := filesystem.ReindexWithProgress(ctx, &url.URL{
+		Scheme: "reindexplain", Path: "/"}); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP without support, got %v", err)
+	}
+
+	writeTestFile(t, &url.URL{Scheme: "reindex", Path: "/index/a"}, "12345")
+	writeTestFile(t, &url.URL{Scheme: "reindex", Path: "/index/sub/b"}, "678")
+
+	if err := filesystem.Reindex(ctx, root); err != nil {
+		t.Errorf("Error reported from Reindex: %v", err)
+	}
+	if rfs.hadDeadline {
+		t.Error("Default timeout applied to Reindex")
+	}
+
+	progress, err := filesystem.ReindexWithProgress(ctx, root)
+	if err != nil {
+		t.Fatalf("Error reported from ReindexWithProgress: %v", err)
+	}
+	var reports []filesystem.ReindexProgress
+	for p := range progress {
+		reports = append(reports, p)
+	}
+	if len(reports) != 2 || reports[1].FilesScanned != 2 ||
+		reports[1].BytesScanned != 8 {
+		t.Errorf("Unexpected progress reports %+v", reports)
+	}
+}
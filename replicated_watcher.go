@@ -0,0 +1,235 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+/*
+FileSystem which keeps the same files on several backends and aggregates
+watches across all of them.
+*/
+type replicatedFileSystem struct {
+	backends    []FileSystem
+	dedupWindow time.Duration
+}
+
+/*
+NewReplicatedWatcher creates a FileSystem for a set of backends holding
+replicas of the same files. WatchFile watches the file on all backends and
+delivers the first notification of a change; further notifications for the
+same path arriving within dedupWindow of a delivered one are considered
+duplicates from other replicas and discarded.
+
+Reads and listings are served by the first backend which succeeds, writes
+and removals are applied to all backends.
+*/
+func NewReplicatedWatcher(backends []FileSystem, dedupWindow time.Duration) FileSystem {
+	return &replicatedFileSystem{backends: backends, dedupWindow: dedupWindow}
+}
+
+/*
+OpenReader opens the file on the first backend which succeeds.
+*/
+func (r *replicatedFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	var errs MultiError
+
+	for _, backend := range r.backends {
+		var rc, err = backend.OpenReader(ctx, u)
+		if err == nil {
+			return rc, nil
+		}
+		errs = append(errs, err)
+	}
+
+	return nil, errs.ErrorOrNil()
+}
+
+/*
+openWriters opens the file on all backends using open.
+*/
+func (r *replicatedFileSystem) openWriters(ctx context.Context, u *url.URL,
+	open func(FileSystem) (WriteCloser, error)) (WriteCloser, error) {
+	var writers []WriteCloser
+
+	for _, backend := range r.backends {
+		var wc, err = open(backend)
+		if err != nil {
+			for _, w := range writers {
+				w.Close(ctx)
+			}
+			return nil, err
+		}
+		writers = append(writers, wc)
+	}
+
+	return MultiWriteCloser(writers...), nil
+}
+
+/*
+OpenWriter opens the file for writing on all backends.
+*/
+func (r *replicatedFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return r.openWriters(ctx, u, func(fs FileSystem) (WriteCloser, error) {
+		return fs.OpenWriter(ctx, u)
+	})
+}
+
+/*
+OpenAppender opens the file for appending on all backends.
+*/
+func (r *replicatedFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return r.openWriters(ctx, u, func(fs FileSystem) (WriteCloser, error) {
+		return fs.OpenAppender(ctx, u)
+	})
+}
+
+/*
+ListEntries lists the directory on the first backend which succeeds.
+*/
+func (r *replicatedFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	var errs MultiError
+
+	for _, backend := range r.backends {
+		var entries, err = backend.ListEntries(ctx, u)
+		if err == nil {
+			return entries, nil
+		}
+		errs = append(errs, err)
+	}
+
+	return nil, errs.ErrorOrNil()
+}
+
+/*
+Stat retrieves the metadata of the file from the first backend which
+succeeds.
+*/
+func (r *replicatedFileSystem) Stat(ctx context.Context, u *url.URL) (
+	FileInfo, error) {
+	var errs MultiError
+
+	for _, backend := range r.backends {
+		var fi, err = backend.Stat(ctx, u)
+		if err == nil {
+			return fi, nil
+		}
+		errs = append(errs, err)
+	}
+
+	return nil, errs.ErrorOrNil()
+}
+
+/*
+Remove deletes the file from all backends. All errors are reported as a
+MultiError.
+*/
+func (r *replicatedFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	var errs MultiError
+
+	for _, backend := range r.backends {
+		if err := backend.Remove(ctx, u); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+/*
+WatchFile watches the file on all backends and invokes the watcher with the
+first notification of every change, discarding the notifications of other
+replicas for the same path arriving within the deduplication window.
+
+Errors of all backends are merged into the returned channel; the errors of
+backends whose watches could not be started are delivered there as well,
+as a single MultiError if no watch could be started at all.
+*/
+func (r *replicatedFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	var merged = make(chan error, len(r.backends))
+	var done = make(chan struct{})
+	var cancels []CancelWatchFunc
+	var startErrs MultiError
+	var lock sync.Mutex
+	var delivered = make(map[string]time.Time)
+	var once sync.Once
+	var deliver = func(changed *url.URL, rc ReadCloser) {
+		var now = time.Now()
+
+		lock.Lock()
+		for p, at := range delivered {
+			if now.Sub(at) >= r.dedupWindow {
+				delete(delivered, p)
+			}
+		}
+		if _, ok := delivered[changed.Path]; ok {
+			lock.Unlock()
+			rc.Close(context.Background())
+			return
+		}
+		delivered[changed.Path] = now
+		lock.Unlock()
+
+		watcher(changed, rc)
+	}
+
+	for _, backend := range r.backends {
+		var cancel, errChan, err = backend.WatchFile(ctx, u, deliver)
+
+		if err != nil {
+			startErrs = append(startErrs, err)
+			continue
+		}
+		cancels = append(cancels, cancel)
+
+		go func(errChan chan error) {
+			for {
+				select {
+				case err, ok := <-errChan:
+					if !ok {
+						return
+					}
+					select {
+					case merged <- err:
+					case <-done:
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		}(errChan)
+	}
+
+	if len(cancels) == 0 {
+		if len(startErrs) > 0 {
+			merged <- startErrs
+		}
+	} else {
+		for _, err := range startErrs {
+			merged <- err
+		}
+	}
+
+	return func() error {
+		var errs MultiError
+
+		once.Do(func() {
+			close(done)
+			for _, cancel := range cancels {
+				if err := cancel(); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		})
+
+		return errs.ErrorOrNil()
+	}, merged, nil
+}
@@ -0,0 +1,68 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestReplicatedWatcherDeduplicates(t *testing.T) {
+	fs := filesystem.NewReplicatedWatcher([]filesystem.FileSystem{
+		virtualfs.NewVirtualFileSystem(), virtualfs.NewVirtualFileSystem(),
+	}, time.Hour)
+	filesystem.AddImplementation("replwatch", fs)
+	u := &url.URL{Scheme: "replwatch", Path: "/file"}
+	notifications := make(chan struct{}, 4)
+
+	cancel, _, err := fs.WatchFile(context.Background(), u,
+		func(*url.URL, filesystem.ReadCloser) { notifications <- struct{}{} })
+	if err != nil {
+		t.Fatalf("Error reported from WatchFile: %v", err)
+	}
+	defer cancel()
+
+	// Writing goes to both replicas, which notify independently.
+	writeTestFile(t, u, "data")
+
+	select {
+	case <-notifications:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for notification")
+	}
+
+	select {
+	case <-notifications:
+		t.Error("Duplicate notification delivered")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if data, _ := readTestFile(t, u); data != "data" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+}
+
+func TestReplicatedWatcherReportsFailedStart(t *testing.T) {
+	fs := filesystem.NewReplicatedWatcher([]filesystem.FileSystem{
+		filesystem.FromIoFS(fstest.MapFS{}, "replwatchfail"),
+		filesystem.FromIoFS(fstest.MapFS{}, "replwatchfail"),
+	}, time.Hour)
+	u := &url.URL{Scheme: "replwatchfail", Path: "/file"}
+
+	cancel, errs, err := fs.WatchFile(context.Background(), u,
+		func(*url.URL, filesystem.ReadCloser) {})
+	if err != nil {
+		t.Fatalf("Error reported from WatchFile: %v", err)
+	}
+	defer cancel()
+
+	var merr filesystem.MultiError
+	if err = <-errs; !errors.As(err, &merr) || len(merr) != 2 {
+		t.Errorf("Unexpected error on the channel: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+ReplicatingFileSystem is implemented by distributed file systems which allow
+controlling the number of replicas kept of each file, such as HDFS or Ceph.
+*/
+type ReplicatingFileSystem interface {
+	// Set the number of replicas to keep of the file.
+	SetReplicationFactor(context.Context, *url.URL, int) error
+
+	// Retrieve the number of replicas kept of the file.
+	GetReplicationFactor(context.Context, *url.URL) (int, error)
+}
+
+/*
+getReplicatingFileSystem determines the ReplicatingFileSystem responsible
+for the URL, or returns ENOFS or EUNSUPP if there is none.
+*/
+func getReplicatingFileSystem(fileurl *url.URL) (ReplicatingFileSystem, error) {
+	var fs = GetImplementation(fileurl)
+	var rfs ReplicatingFileSystem
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if rfs, ok = fs.(ReplicatingFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	return rfs, nil
+}
+
+/*
+SetReplicationFactor sets the number of replicas the file system keeps of
+the referenced file, e.g. 3 for frequently accessed and 1 for cold data.
+*/
+func SetReplicationFactor(ctx context.Context, fileurl *url.URL, factor int) error {
+	var rfs ReplicatingFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if rfs, err = getReplicatingFileSystem(fileurl); err != nil {
+		return err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return rfs.SetReplicationFactor(ctx, fileurl, factor)
+}
+
+/*
+GetReplicationFactor retrieves the number of replicas the file system keeps
+of the referenced file.
+*/
+func GetReplicationFactor(ctx context.Context, fileurl *url.URL) (int, error) {
+	var rfs ReplicatingFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if rfs, err = getReplicatingFileSystem(fileurl); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return rfs.GetReplicationFactor(ctx, fileurl)
+}
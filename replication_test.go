@@ -0,0 +1,84 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+/*
+replicatingFileSystem keeps track of the replication factor of existing
+files, which defaults to 1.
+*/
+type replicatingFileSystem struct {
+	*virtualfs.VirtualFileSystem
+	lock    sync.Mutex
+	factors map[string]int
+}
+
+func (r *replicatingFileSystem) SetReplicationFactor(ctx context.Context,
+	u *url.URL, factor int) error {
+	if _, err := r.Stat(ctx, u); err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.factors[u.Path] = factor
+	return nil
+}
+
+func (r *replicatingFileSystem) GetReplicationFactor(ctx context.Context,
+	u *url.URL) (int, error) {
+	if _, err := r.Stat(ctx, u); err != nil {
+		return 0, err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if factor, ok := r.factors[u.Path]; ok {
+		return factor, nil
+	}
+	return 1, nil
+}
+
+func TestReplicationFactor(t *testing.T) {
+	filesystem.AddImplementation("replicationplain", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("replication", &replicatingFileSystem{
+		VirtualFileSystem: virtualfs.NewVirtualFileSystem(),
+		factors:           make(map[string]int),
+	})
+	u := &url.URL{Scheme: "replication", Path: "/file"}
+	ctx := context.Background()
+
+	if err := filesystem.SetReplicationFactor(ctx, &url.URL{
+		Scheme: "replicationunregistered", Path: "/file"}, 3); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+	if _, err := filesystem.GetReplicationFactor(ctx, &url.URL{
+		Scheme: "replicationplain", Path: "/file"}); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP without support, got %v", err)
+	}
+
+	if err := filesystem.SetReplicationFactor(ctx, u, 3); !errors.Is(
+		err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error for missing file: %v", err)
+	}
+
+	writeTestFile(t, u, "replicated")
+	if factor, err := filesystem.GetReplicationFactor(ctx, u); err != nil ||
+		factor != 1 {
+		t.Errorf("Unexpected initial replication factor %d (%v)", factor, err)
+	}
+	if err := filesystem.SetReplicationFactor(ctx, u, 3); err != nil {
+		t.Errorf("Error reported from SetReplicationFactor: %v", err)
+	}
+	if factor, err := filesystem.GetReplicationFactor(ctx, u); err != nil ||
+		factor != 3 {
+		t.Errorf("Unexpected replication factor %d (%v)", factor, err)
+	}
+}
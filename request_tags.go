@@ -0,0 +1,37 @@
+package filesystem
+
+import (
+	"context"
+	"maps"
+)
+
+/*
+Key under which RequestTag stores the tags in the context.
+*/
+type requestTagsKey struct{}
+
+/*
+RequestTag attaches tags to the context which file system implementations
+should pass on as request-level labels to the storage backend, e.g. for
+cost allocation in cloud environments (team, service, request ID). Tags
+already present in ctx are retained unless overridden by tags.
+*/
+func RequestTag(ctx context.Context, tags map[string]string) context.Context {
+	var merged = TagsFromContext(ctx)
+
+	if merged == nil {
+		merged = make(map[string]string, len(tags))
+	}
+	maps.Copy(merged, tags)
+
+	return context.WithValue(ctx, requestTagsKey{}, merged)
+}
+
+/*
+TagsFromContext returns a copy of the tags attached to the context using
+RequestTag, or nil if there are none.
+*/
+func TagsFromContext(ctx context.Context) map[string]string {
+	var tags, _ = ctx.Value(requestTagsKey{}).(map[string]string)
+	return maps.Clone(tags)
+}
@@ -0,0 +1,26 @@
+package filesystem
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRequestTag(t *testing.T) {
+	if tags := TagsFromContext(context.Background()); tags != nil {
+		t.Errorf("Unexpected tags in empty context: %v", tags)
+	}
+
+	ctx := RequestTag(context.Background(), map[string]string{
+		"team": "storage", "service": "indexer"})
+	ctx = RequestTag(ctx, map[string]string{"service": "compactor"})
+
+	tags := TagsFromContext(ctx)
+	if len(tags) != 2 || tags["team"] != "storage" || tags["service"] != "compactor" {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+
+	tags["team"] = "modified"
+	if TagsFromContext(ctx)["team"] != "storage" {
+		t.Error("Modifying returned tags affected the context")
+	}
+}
@@ -0,0 +1,244 @@
+package filesystem
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"path"
+)
+
+/*
+ErrInvalidUploadToken is returned by the resumable upload functions if the
+token was not created by InitiateUpload for the file system.
+*/
+var ErrInvalidUploadToken = errors.New("Invalid upload token")
+
+/*
+UploadToken is an opaque identifier for a resumable upload. Its contents are
+defined by the file system implementation which created it.
+*/
+type UploadToken string
+
+/*
+ResumableUploader is implemented by file systems which support uploads that
+can be resumed after an interruption, such as GCS or Azure Blob Storage.
+*/
+type ResumableUploader interface {
+	// Start a new resumable upload to the specified URL and return a token
+	// identifying it.
+	InitiateUpload(context.Context, *url.URL) (UploadToken, error)
+
+	// Open a writer which continues the upload identified by the token at
+	// the position returned by QueryProgress. Closing the writer completes
+	// the upload.
+	ResumeUpload(context.Context, *url.URL, UploadToken) (WriteCloser, error)
+
+	// Determine how many bytes of the upload have been persisted so far.
+	QueryProgress(context.Context, *url.URL, UploadToken) (int64, error)
+
+	// Discard the upload and everything uploaded for it so far.
+	AbortUpload(context.Context, *url.URL, UploadToken) error
+}
+
+/*
+uploadTempURL determines the location of the temporary file holding the data
+of the upload identified by token, for file systems not implementing
+ResumableUploader.
+*/
+func uploadTempURL(fileurl *url.URL, token UploadToken) (*url.URL, error) {
+	var temp = *fileurl
+
+	if _, err := hex.DecodeString(string(token)); err != nil || token == "" {
+		return nil, ErrInvalidUploadToken
+	}
+
+	temp.Path = path.Join(path.Dir(fileurl.Path),
+		"."+path.Base(fileurl.Path)+".upload-"+string(token))
+	return &temp, nil
+}
+
+/*
+WriteCloser appending to the temporary file of an upload, which is moved to
+its destination on Close unless a write failed.
+*/
+type resumedWriteCloser struct {
+	w         WriteCloser
+	temp, dst *url.URL
+	failed    bool
+}
+
+/*
+Write appends p to the temporary file.
+*/
+func (r *resumedWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	var n, err = r.w.Write(ctx, p)
+
+	if err != nil {
+		r.failed = true
+	}
+	return n, err
+}
+
+/*
+Close closes the temporary file and, if all writes succeeded, moves it to
+the destination. Otherwise the upload stays in place to be resumed.
+*/
+func (r *resumedWriteCloser) Close(ctx context.Context) error {
+	if err := r.w.Close(ctx); err != nil {
+		return err
+	}
+	if r.failed {
+		return nil
+	}
+	return Move(ctx, r.temp, r.dst)
+}
+
+/*
+InitiateUpload starts a resumable upload to the referenced file. The returned
+token can be used with ResumeUpload to write the data, and to continue the
+upload after an interruption.
+
+File systems not implementing ResumableUploader get the data written to a
+hidden temporary file next to the referenced file, which is moved into place
+once the upload is complete. Resuming an upload then requires support for
+Stat.
+*/
+func InitiateUpload(ctx context.Context, fileurl *url.URL) (UploadToken, error) {
+	var fs = GetImplementation(fileurl)
+	var id = make([]byte, 16)
+	var token UploadToken
+	var temp *url.URL
+	var wc WriteCloser
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return "", ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if ru, ok := fs.(ResumableUploader); ok {
+		return ru.InitiateUpload(ctx, fileurl)
+	}
+
+	if _, err = rand.Read(id); err != nil {
+		return "", err
+	}
+	token = UploadToken(hex.EncodeToString(id))
+	if temp, err = uploadTempURL(fileurl, token); err != nil {
+		return "", err
+	}
+
+	if wc, err = fs.OpenWriter(ctx, temp); err != nil {
+		return "", err
+	}
+	if err = wc.Close(ctx); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+/*
+ResumeUpload opens a WriteCloser which continues the upload identified by
+token. Data written to it is appended after the number of bytes reported by
+QueryProgress. The upload is complete once the WriteCloser is closed.
+
+For file systems not implementing ResumableUploader, closing the WriteCloser
+after a failed write keeps the upload in place, so it can be resumed again.
+*/
+func ResumeUpload(ctx context.Context, fileurl *url.URL, token UploadToken) (
+	WriteCloser, error) {
+	var fs = GetImplementation(fileurl)
+	var temp *url.URL
+	var w WriteCloser
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if ru, ok := fs.(ResumableUploader); ok {
+		return ru.ResumeUpload(ctx, fileurl, token)
+	}
+
+	if temp, err = uploadTempURL(fileurl, token); err != nil {
+		return nil, err
+	}
+	// Never recreate an upload which was completed or aborted.
+	if _, err = fs.Stat(ctx, temp); err != nil && err != EUNSUPP {
+		return nil, err
+	}
+	if w, err = fs.OpenAppender(ctx, temp); err != nil {
+		return nil, err
+	}
+
+	return &resumedWriteCloser{w: w, temp: temp, dst: fileurl}, nil
+}
+
+/*
+QueryProgress returns the number of bytes of the upload identified by token
+which have been persisted so far.
+*/
+func QueryProgress(ctx context.Context, fileurl *url.URL, token UploadToken) (
+	int64, error) {
+	var fs = GetImplementation(fileurl)
+	var temp *url.URL
+	var fi FileInfo
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return 0, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if ru, ok := fs.(ResumableUploader); ok {
+		return ru.QueryProgress(ctx, fileurl, token)
+	}
+
+	if temp, err = uploadTempURL(fileurl, token); err != nil {
+		return 0, err
+	}
+	if fi, err = fs.Stat(ctx, temp); err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+/*
+AbortUpload cancels the upload identified by token and discards any data
+uploaded for it.
+*/
+func AbortUpload(ctx context.Context, fileurl *url.URL, token UploadToken) error {
+	var fs = GetImplementation(fileurl)
+	var temp *url.URL
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if ru, ok := fs.(ResumableUploader); ok {
+		return ru.AbortUpload(ctx, fileurl, token)
+	}
+
+	if temp, err = uploadTempURL(fileurl, token); err != nil {
+		return err
+	}
+	return fs.Remove(ctx, temp)
+}
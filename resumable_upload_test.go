@@ -0,0 +1,83 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestResumableUploadFallback(t *testing.T) {
+	vfs := virtualfs.NewVirtualFileSystem()
+	filesystem.AddImplementation("resumable", vfs)
+	u := &url.URL{Scheme: "resumable", Path: "/dir/upload.bin"}
+	ctx := context.Background()
+
+	token, err := filesystem.InitiateUpload(ctx, u)
+	if err != nil {
+		t.Fatalf("Error reported from InitiateUpload: %v", err)
+	}
+
+	wc, err := filesystem.ResumeUpload(ctx, u, token)
+	if err != nil {
+		t.Fatalf("Error reported from ResumeUpload: %v", err)
+	}
+	wc.Write(ctx, []byte("hello "))
+	// Simulate an interruption.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = wc.Write(cancelled, []byte("lost")); err == nil {
+		t.Fatal("Write with cancelled context succeeded")
+	}
+	if err = wc.Close(ctx); err != nil {
+		t.Errorf("Error reported from Close: %v", err)
+	}
+	if _, err = readTestFile(t, u); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Interrupted upload was completed: %v", err)
+	}
+
+	if n, err := filesystem.QueryProgress(ctx, u, token); err != nil || n != 6 {
+		t.Errorf("Unexpected progress %d (%v)", n, err)
+	}
+
+	if wc, err = filesystem.ResumeUpload(ctx, u, token); err != nil {
+		t.Fatalf("Error reported from ResumeUpload: %v", err)
+	}
+	wc.Write(ctx, []byte("world"))
+	if err = wc.Close(ctx); err != nil {
+		t.Errorf("Error reported from Close: %v", err)
+	}
+
+	if data, _ := readTestFile(t, u); data != "hello world" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+	if entries, _ := vfs.ListEntries(ctx, &url.URL{Path: "/dir"}); len(entries) != 1 {
+		t.Errorf("Temporary file left behind: %v", entries)
+	}
+}
+
+func TestResumableUploadAbort(t *testing.T) {
+	filesystem.AddImplementation("resumableabort", virtualfs.NewVirtualFileSystem())
+	u := &url.URL{Scheme: "resumableabort", Path: "/upload.bin"}
+	ctx := context.Background()
+
+	token, err := filesystem.InitiateUpload(ctx, u)
+	if err != nil {
+		t.Fatalf("Error reported from InitiateUpload: %v", err)
+	}
+	if err = filesystem.AbortUpload(ctx, u, token); err != nil {
+		t.Errorf("Error reported from AbortUpload: %v", err)
+	}
+
+	if _, err = filesystem.ResumeUpload(ctx, u, token); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error resuming aborted upload: %v", err)
+	}
+	if _, err = filesystem.QueryProgress(ctx, u,
+		"../../etc/passwd"); err != filesystem.ErrInvalidUploadToken {
+		t.Errorf("Unexpected error for invalid token: %v", err)
+	}
+}
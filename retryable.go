@@ -0,0 +1,76 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+)
+
+/*
+RetryableError is implemented by errors which know whether the failed
+operation may succeed if it is retried, e.g. because they describe rate
+limiting or a temporary network problem.
+*/
+type RetryableError interface {
+	error
+
+	// Whether the failed operation may succeed if it is retried.
+	IsRetryable() bool
+}
+
+/*
+Error which marks the wrapped error as retryable.
+*/
+type retryableError struct {
+	err error
+}
+
+/*
+Error returns the message of the wrapped error.
+*/
+func (r *retryableError) Error() string {
+	return r.err.Error()
+}
+
+/*
+Unwrap returns the wrapped error.
+*/
+func (r *retryableError) Unwrap() error {
+	return r.err
+}
+
+/*
+IsRetryable always returns true.
+*/
+func (r *retryableError) IsRetryable() bool {
+	return true
+}
+
+/*
+WithRetryable wraps err so that IsRetryable reports it as retryable. The
+wrapped error remains accessible through errors.Is and errors.As. Returns
+nil if err is nil.
+*/
+func WithRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryableError{err: err}
+}
+
+/*
+IsRetryable determines whether the operation which failed with err may
+succeed if it is retried, i.e. whether err or any error it wraps is a
+RetryableError reporting so. Errors caused by an expired or cancelled
+context are never retryable, since retrying with the same context would
+fail again.
+*/
+func IsRetryable(err error) bool {
+	var rerr RetryableError
+
+	if err == nil || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	return errors.As(err, &rerr) && rerr.IsRetryable()
+}
@@ -0,0 +1,30 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{ErrExpected, false},
+		{WithRetryable(ErrExpected), true},
+		{fmt.Errorf("wrapped: %w", WithRetryable(ErrExpected)), true},
+		{MultiError{ErrExpected, WithRetryable(ErrExpected)}, true},
+		{WithRetryable(context.DeadlineExceeded), false},
+	} {
+		if IsRetryable(tc.err) != tc.expected {
+			t.Errorf("IsRetryable(%v) did not return %v", tc.err, tc.expected)
+		}
+	}
+
+	if !errors.Is(WithRetryable(ErrExpected), ErrExpected) {
+		t.Error("Retryable error does not match the wrapped error")
+	}
+}
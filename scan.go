@@ -0,0 +1,51 @@
+package filesystem
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/url"
+)
+
+/*
+ErrStopScan can be returned by the function passed to Scan to stop scanning
+without reporting an error.
+*/
+var ErrStopScan = errors.New("Stop scanning")
+
+/*
+Scan reads the referenced file token by token, as split by tokenizer (e.g.
+bufio.ScanLines), and calls fn for every token. The token is only valid
+until fn returns. If fn returns ErrStopScan, scanning stops and nil is
+returned; any other error is passed on. Scanning is aborted once ctx is
+done.
+
+Tokens are subject to the size limit of bufio.Scanner.
+*/
+func Scan(ctx context.Context, fileurl *url.URL, tokenizer bufio.SplitFunc,
+	fn func(token []byte) error) error {
+	var rc ReadCloser
+	var scanner *bufio.Scanner
+	var err error
+
+	if rc, err = OpenReader(ctx, fileurl); err != nil {
+		return err
+	}
+	defer rc.Close(ctx)
+
+	scanner = bufio.NewScanner(ToIoReadCloser(NewContextReadCloser(rc, ctx)))
+	scanner.Split(tokenizer)
+
+	for scanner.Scan() {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = fn(scanner.Bytes()); err == ErrStopScan {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,35 @@
+package filesystem_test
+
+import (
+	"bufio"
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestScan(t *testing.T) {
+	var lines []string
+
+	filesystem.AddImplementation("scan", virtualfs.NewVirtualFileSystem())
+	u := &url.URL{Scheme: "scan", Path: "/records"}
+	writeTestFile(t, u, "one\ntwo\nthree\nfour\n")
+
+	err := filesystem.Scan(context.Background(), u, bufio.ScanLines,
+		func(token []byte) error {
+			lines = append(lines, string(token))
+			if len(lines) == 3 {
+				return filesystem.ErrStopScan
+			}
+			return nil
+		})
+	if err != nil {
+		t.Errorf("Error reported from Scan: %v", err)
+	}
+	if strings.Join(lines, ",") != "one,two,three" {
+		t.Errorf("Unexpected tokens %v", lines)
+	}
+}
@@ -0,0 +1,119 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"sync"
+)
+
+/*
+ErrUnknownContentType is returned by SchemaRegistry.ReadAs if the content
+type of a file cannot be determined or no decoder is registered for it.
+*/
+var ErrUnknownContentType = errors.New("No decoder for content type")
+
+/*
+DecoderFunc decodes the contents read from the ReadCloser into the value
+pointed to by the second parameter.
+*/
+type DecoderFunc func(ReadCloser, any) error
+
+/*
+SchemaRegistry maps content types to functions decoding them, so files
+containing typed data can be read in a single call.
+*/
+type SchemaRegistry struct {
+	lock     sync.RWMutex
+	decoders map[string]DecoderFunc
+}
+
+/*
+decodeJSON decodes JSON data using encoding/json.
+*/
+func decodeJSON(rc ReadCloser, v any) error {
+	return json.NewDecoder(ToIoReadCloser(rc)).Decode(v)
+}
+
+/*
+NewSchemaRegistry creates a new SchemaRegistry with a decoder for
+application/json already registered.
+*/
+func NewSchemaRegistry() *SchemaRegistry {
+	var r = &SchemaRegistry{decoders: make(map[string]DecoderFunc)}
+	r.Register("application/json", decodeJSON)
+	return r
+}
+
+/*
+Register associates the content type with a decoder. Any previously
+registered decoder for the content type is replaced. Parameters of the
+content type, such as the charset, are ignored.
+*/
+func (r *SchemaRegistry) Register(contentType string, decoder DecoderFunc) {
+	var mediaType, _, err = mime.ParseMediaType(contentType)
+
+	if err != nil {
+		mediaType = contentType
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.decoders[mediaType] = decoder
+}
+
+/*
+contentType determines the content type of the file from the content-type
+query parameter of the URL, or from the extension of its path.
+*/
+func contentType(fileurl *url.URL) string {
+	var ct = fileurl.Query().Get("content-type")
+	var mediaType string
+	var err error
+
+	if ct == "" {
+		ct = mime.TypeByExtension(path.Ext(fileurl.Path))
+	}
+	if mediaType, _, err = mime.ParseMediaType(ct); err != nil {
+		return ct
+	}
+	return mediaType
+}
+
+/*
+ReadAs opens the referenced file and decodes its contents into v, using the
+decoder registered for its content type. The content type is taken from the
+content-type query parameter of the URL if present, and guessed from the
+file extension otherwise.
+*/
+func (r *SchemaRegistry) ReadAs(ctx context.Context, fileurl *url.URL, v any) error {
+	var ct = contentType(fileurl)
+	var decoder DecoderFunc
+	var rc ReadCloser
+	var ok bool
+	var err error
+
+	r.lock.RLock()
+	decoder, ok = r.decoders[ct]
+	r.lock.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w %q: %s", ErrUnknownContentType, ct, fileurl)
+	}
+
+	if rc, err = OpenReader(ctx, fileurl); err != nil {
+		return err
+	}
+
+	if err = decoder(rc, v); err != nil {
+		rc.Close(ctx)
+		return err
+	}
+
+	return rc.Close(ctx)
+}
@@ -0,0 +1,59 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestSchemaRegistryReadAsJSON(t *testing.T) {
+	var v struct {
+		Name string `json:"name"`
+	}
+
+	filesystem.AddImplementation("schema", virtualfs.NewVirtualFileSystem())
+	writeTestFile(t, &url.URL{Scheme: "schema", Path: "/data.json"},
+		`{"name": "test"}`)
+
+	r := filesystem.NewSchemaRegistry()
+	err := r.ReadAs(context.Background(),
+		&url.URL{Scheme: "schema", Path: "/data.json"}, &v)
+	if err != nil {
+		t.Fatalf("Error reported from ReadAs: %v", err)
+	}
+	if v.Name != "test" {
+		t.Errorf("Unexpected decoded value %q", v.Name)
+	}
+}
+
+func TestSchemaRegistryContentTypeParameter(t *testing.T) {
+	var decoded string
+
+	filesystem.AddImplementation("schema", virtualfs.NewVirtualFileSystem())
+	writeTestFile(t, &url.URL{Scheme: "schema", Path: "/data"}, "raw")
+
+	r := filesystem.NewSchemaRegistry()
+	r.Register("text/x-test", func(rc filesystem.ReadCloser, v any) error {
+		decoded = "called"
+		return nil
+	})
+
+	u := &url.URL{Scheme: "schema", Path: "/data",
+		RawQuery: "content-type=text/x-test"}
+	if err := r.ReadAs(context.Background(), u, nil); err != nil {
+		t.Errorf("Error reported from ReadAs: %v", err)
+	}
+	if decoded != "called" {
+		t.Error("Registered decoder was not called")
+	}
+
+	err := r.ReadAs(context.Background(),
+		&url.URL{Scheme: "schema", Path: "/data"}, nil)
+	if !errors.Is(err, filesystem.ErrUnknownContentType) {
+		t.Errorf("Unexpected error for unknown content type: %v", err)
+	}
+}
@@ -0,0 +1,316 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+)
+
+/*
+ErrFileSystemClosed is returned by SerializingFileSystem for operations
+invoked after Close. It matches os.ErrClosed when compared using errors.Is.
+*/
+var ErrFileSystemClosed = fmt.Errorf("File system has been closed: %w", os.ErrClosed)
+
+/*
+SerializingFileSystem is a FileSystem which executes all operations of the
+wrapped file system, including those on the ReadClosers and WriteClosers it
+returns, one at a time on a single goroutine. This allows using backends
+which are not safe for concurrent use, such as embedded databases.
+
+Create one using NewSerializingFileSystem and stop it using Close.
+*/
+type SerializingFileSystem struct {
+	inner    FileSystem
+	requests chan func()
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+/*
+NewSerializingFileSystem wraps inner into a SerializingFileSystem and starts
+the goroutine executing its operations.
+
+The wrapper only supports the FileSystem methods.
+*/
+func NewSerializingFileSystem(inner FileSystem) *SerializingFileSystem {
+	var s = &SerializingFileSystem{
+		inner:    inner,
+		requests: make(chan func()),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+
+	go s.run()
+	return s
+}
+
+/*
+run executes operations until the file system is closed.
+*/
+func (s *SerializingFileSystem) run() {
+	defer close(s.stopped)
+
+	for {
+		select {
+		case fn := <-s.requests:
+			fn()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+/*
+Close stops the goroutine executing the operations, after waiting for the
+operation currently in progress. Operations invoked afterwards fail with
+ErrFileSystemClosed. Calling Close again has no further effect.
+*/
+func (s *SerializingFileSystem) Close(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	select {
+	case <-s.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+do executes fn on the serializing goroutine and waits for it to finish.
+If ctx is done first, ctx.Err() is returned and the result of fn is
+discarded; fn may still be executed in that case, and is expected to
+observe ctx itself. If fn completes after the caller gave up, release is
+invoked on the serializing goroutine to free whatever fn produced, e.g. by
+closing an opened file. release may be nil.
+*/
+func (s *SerializingFileSystem) do(ctx context.Context, fn func(),
+	release func()) error {
+	var done = make(chan struct{})
+	var abandoned = make(chan struct{})
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case s.requests <- func() {
+		fn()
+		select {
+		case done <- struct{}{}:
+		case <-abandoned:
+			if release != nil {
+				release()
+			}
+		}
+	}:
+	case <-s.stop:
+		return ErrFileSystemClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		close(abandoned)
+		return ctx.Err()
+	}
+}
+
+/*
+ReadCloser whose operations are executed by a SerializingFileSystem.
+*/
+type serializedReadCloser struct {
+	s  *SerializingFileSystem
+	rc ReadCloser
+}
+
+/*
+Read reads from the underlying ReadCloser on the serializing goroutine.
+*/
+func (r *serializedReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	var n int
+	var err error
+
+	if doErr := r.s.do(ctx, func() { n, err = r.rc.Read(ctx, p) }, nil); doErr != nil {
+		return 0, doErr
+	}
+	return n, err
+}
+
+/*
+Close closes the underlying ReadCloser on the serializing goroutine.
+*/
+func (r *serializedReadCloser) Close(ctx context.Context) error {
+	var err error
+
+	if doErr := r.s.do(ctx, func() { err = r.rc.Close(ctx) }, nil); doErr != nil {
+		return doErr
+	}
+	return err
+}
+
+/*
+WriteCloser whose operations are executed by a SerializingFileSystem.
+*/
+type serializedWriteCloser struct {
+	s  *SerializingFileSystem
+	wc WriteCloser
+}
+
+/*
+Write writes to the underlying WriteCloser on the serializing goroutine.
+*/
+func (w *serializedWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	var n int
+	var err error
+
+	if doErr := w.s.do(ctx, func() { n, err = w.wc.Write(ctx, p) }, nil); doErr != nil {
+		return 0, doErr
+	}
+	return n, err
+}
+
+/*
+Close closes the underlying WriteCloser on the serializing goroutine.
+*/
+func (w *serializedWriteCloser) Close(ctx context.Context) error {
+	var err error
+
+	if doErr := w.s.do(ctx, func() { err = w.wc.Close(ctx) }, nil); doErr != nil {
+		return doErr
+	}
+	return err
+}
+
+/*
+OpenReader opens the file for reading on the serializing goroutine.
+*/
+func (s *SerializingFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	var rc ReadCloser
+	var err error
+
+	if doErr := s.do(ctx, func() { rc, err = s.inner.OpenReader(ctx, u) }, func() {
+		if err == nil {
+			rc.Close(context.Background())
+		}
+	}); doErr != nil {
+		return nil, doErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &serializedReadCloser{s: s, rc: rc}, nil
+}
+
+/*
+openWriter opens a file using open on the serializing goroutine.
+*/
+func (s *SerializingFileSystem) openWriter(ctx context.Context, u *url.URL,
+	open func(context.Context, *url.URL) (WriteCloser, error)) (WriteCloser, error) {
+	var wc WriteCloser
+	var err error
+
+	if doErr := s.do(ctx, func() { wc, err = open(ctx, u) }, func() {
+		if err == nil {
+			wc.Close(context.Background())
+		}
+	}); doErr != nil {
+		return nil, doErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &serializedWriteCloser{s: s, wc: wc}, nil
+}
+
+/*
+OpenWriter opens the file for writing on the serializing goroutine.
+*/
+func (s *SerializingFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return s.openWriter(ctx, u, s.inner.OpenWriter)
+}
+
+/*
+OpenAppender opens the file for appending on the serializing goroutine.
+*/
+func (s *SerializingFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	return s.openWriter(ctx, u, s.inner.OpenAppender)
+}
+
+/*
+ListEntries lists the directory on the serializing goroutine.
+*/
+func (s *SerializingFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	var entries []string
+	var err error
+
+	if doErr := s.do(ctx, func() { entries, err = s.inner.ListEntries(ctx, u) }, nil); doErr != nil {
+		return nil, doErr
+	}
+	return entries, err
+}
+
+/*
+WatchFile starts watching the file on the serializing goroutine. The watcher
+itself is invoked by the wrapped file system directly, but operations on the
+readers passed to it are serialized like all others. The wrapped file
+system must therefore not invoke the watcher synchronously from within one
+of its operations.
+*/
+func (s *SerializingFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	var cancel CancelWatchFunc
+	var errs chan error
+	var err error
+	var serialized = func(changed *url.URL, rc ReadCloser) {
+		watcher(changed, &serializedReadCloser{s: s, rc: rc})
+	}
+
+	if doErr := s.do(ctx, func() {
+		cancel, errs, err = s.inner.WatchFile(ctx, u, serialized)
+	}, func() {
+		if err == nil {
+			cancel()
+		}
+	}); doErr != nil {
+		return nil, nil, doErr
+	}
+	return cancel, errs, err
+}
+
+/*
+Remove deletes the file on the serializing goroutine.
+*/
+func (s *SerializingFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	var err error
+
+	if doErr := s.do(ctx, func() { err = s.inner.Remove(ctx, u) }, nil); doErr != nil {
+		return doErr
+	}
+	return err
+}
+
+/*
+Stat retrieves the metadata of the file on the serializing goroutine.
+*/
+func (s *SerializingFileSystem) Stat(ctx context.Context, u *url.URL) (
+	FileInfo, error) {
+	var fi FileInfo
+	var err error
+
+	if doErr := s.do(ctx, func() { fi, err = s.inner.Stat(ctx, u) }, nil); doErr != nil {
+		return nil, doErr
+	}
+	return fi, err
+}
@@ -0,0 +1,180 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type ConcurrencyDetectingFileSystem struct {
+	MockFileSystem
+	active, maxActive atomic.Int32
+}
+
+func (fs *ConcurrencyDetectingFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	n := fs.active.Add(1)
+	defer fs.active.Add(-1)
+	if n > fs.maxActive.Load() {
+		fs.maxActive.Store(n)
+	}
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+func TestSerializingFileSystem(t *testing.T) {
+	inner := &ConcurrencyDetectingFileSystem{}
+	s := NewSerializingFileSystem(inner)
+	u := mustParse(t, "serial:///file")
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Remove(context.Background(), u); err != nil {
+				t.Errorf("Error reported from Remove: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := inner.maxActive.Load(); n != 1 {
+		t.Errorf("%d operations executed concurrently", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Remove(ctx, u); err != context.Canceled {
+		t.Errorf("Unexpected error for cancelled context: %v", err)
+	}
+
+	s.Close(context.Background())
+	if err := s.Remove(context.Background(), u); !errors.Is(err, ErrFileSystemClosed) {
+		t.Errorf("Unexpected error after Close: %v", err)
+	}
+}
+
+type BlockingFileSystem struct {
+	MockFileSystem
+	started, release chan struct{}
+	closed           chan struct{}
+}
+
+func (fs *BlockingFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	close(fs.started)
+	<-fs.release
+	return nil
+}
+
+func (fs *BlockingFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	close(fs.started)
+	<-fs.release
+	return &CloseRecordingReadCloser{closed: fs.closed}, nil
+}
+
+type CloseRecordingReadCloser struct {
+	MockReadCloser
+	closed chan struct{}
+}
+
+func (r *CloseRecordingReadCloser) Close(ctx context.Context) error {
+	close(r.closed)
+	return nil
+}
+
+func TestSerializingFileSystemCancelHandedOffOperation(t *testing.T) {
+	inner := &BlockingFileSystem{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	s := NewSerializingFileSystem(inner)
+	defer s.Close(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+
+	go func() { result <- s.Remove(ctx, mustParse(t, "serial:///file")) }()
+
+	<-inner.started
+	cancel()
+	select {
+	case err := <-result:
+		if err != context.Canceled {
+			t.Errorf("Unexpected error from Remove: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Remove did not return after cancellation")
+	}
+	close(inner.release)
+}
+
+func TestSerializingFileSystemReleasesAbandonedReader(t *testing.T) {
+	inner := &BlockingFileSystem{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	s := NewSerializingFileSystem(inner)
+	defer s.Close(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+
+	go func() {
+		_, err := s.OpenReader(ctx, mustParse(t, "serial:///file"))
+		result <- err
+	}()
+
+	<-inner.started
+	cancel()
+	if err := <-result; err != context.Canceled {
+		t.Errorf("Unexpected error from OpenReader: %v", err)
+	}
+
+	close(inner.release)
+	select {
+	case <-inner.closed:
+	case <-time.After(5 * time.Second):
+		t.Error("Reader opened after cancellation was not closed")
+	}
+}
+
+func TestSerializingFileSystemCloseTwice(t *testing.T) {
+	s := NewSerializingFileSystem(&MockFileSystem{})
+
+	if err := s.Close(context.Background()); err != nil {
+		t.Errorf("Error reported from Close: %v", err)
+	}
+	if err := s.Close(context.Background()); err != nil {
+		t.Errorf("Error reported from second Close: %v", err)
+	}
+}
+
+type ImmediateWatchingFileSystem struct {
+	MockFileSystem
+}
+
+func (fs *ImmediateWatchingFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	f FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	go f(u, &MockReadCloser{})
+	return func() error { return nil }, make(chan error), nil
+}
+
+func TestSerializingFileSystemWatchReaders(t *testing.T) {
+	s := NewSerializingFileSystem(&ImmediateWatchingFileSystem{})
+	defer s.Close(context.Background())
+	readers := make(chan ReadCloser, 1)
+
+	_, _, err := s.WatchFile(context.Background(), mustParse(t, "serial:///file"),
+		func(u *url.URL, rc ReadCloser) { readers <- rc })
+	if err != nil {
+		t.Fatalf("Error reported from WatchFile: %v", err)
+	}
+
+	if _, ok := (<-readers).(*serializedReadCloser); !ok {
+		t.Error("Reader passed to the watcher is not serialized")
+	}
+}
@@ -0,0 +1,311 @@
+package sftpfs
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+/*
+serveSFTP serves the SFTP subsystem on all sessions of the SSH connection.
+*/
+func serveSFTP(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			newChan.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, requests, err := newChan.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				req.Reply(req.Type == "subsystem" &&
+					string(req.Payload[4:]) == "sftp", nil)
+			}
+		}()
+		go func() {
+			if server, err := sftp.NewServer(channel); err == nil {
+				server.Serve()
+				server.Close()
+			}
+		}()
+	}
+}
+
+/*
+newTestServer starts an in-process SFTP server serving a temporary directory
+and returns a file system connected to it and the URL of the directory.
+*/
+func newTestServer(t *testing.T) (*SFTPFileSystem, *url.URL) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating host key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("Error creating host key signer: %v", err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, password []byte) (
+			*ssh.Permissions, error) {
+			if c.User() == "test" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("Invalid credentials")
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, config)
+		}
+	}()
+
+	s, err := New(WithHostKeyCallback(ssh.FixedHostKey(signer.PublicKey())),
+		WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Error reported from New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return s, &url.URL{
+		Scheme: "sftp",
+		User:   url.UserPassword("test", "secret"),
+		Host:   listener.Addr().String(),
+		Path:   filepath.ToSlash(t.TempDir()),
+	}
+}
+
+func child(u *url.URL, name string) *url.URL {
+	var c = *u
+	c.Path = path.Join(u.Path, name)
+	return &c
+}
+
+func writeFile(t *testing.T, s *SFTPFileSystem, u *url.URL, data string) {
+	wc, err := s.OpenWriter(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenWriter: %v", err)
+	}
+	if _, err = wc.Write(context.Background(), []byte(data)); err != nil {
+		t.Errorf("Error reported from Write: %v", err)
+	}
+	if err = wc.Close(context.Background()); err != nil {
+		t.Errorf("Error reported from Close: %v", err)
+	}
+}
+
+func readFile(t *testing.T, s *SFTPFileSystem, u *url.URL) string {
+	rc, err := s.OpenReader(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenReader: %v", err)
+	}
+	defer rc.Close(context.Background())
+
+	data, err := io.ReadAll(filesystem.ToIoReadCloser(rc))
+	if err != nil {
+		t.Errorf("Error reported from read: %v", err)
+	}
+	return string(data)
+}
+
+func TestWriteAppendRead(t *testing.T) {
+	s, dir := newTestServer(t)
+	u := child(dir, "test.txt")
+
+	writeFile(t, s, u, "hello")
+
+	wc, err := s.OpenAppender(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenAppender: %v", err)
+	}
+	wc.Write(context.Background(), []byte(" world"))
+	wc.Close(context.Background())
+
+	if data := readFile(t, s, u); data != "hello world" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+}
+
+func TestListEntriesStatAndRemove(t *testing.T) {
+	s, dir := newTestServer(t)
+	writeFile(t, s, child(dir, "a"), "a")
+	writeFile(t, s, child(dir, "b"), "bb")
+
+	entries, err := s.ListEntries(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Error reported from ListEntries: %v", err)
+	}
+	sort.Strings(entries)
+	if len(entries) != 2 || entries[0] != "a" || entries[1] != "b" {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+
+	fi, err := s.Stat(context.Background(), child(dir, "b"))
+	if err != nil {
+		t.Fatalf("Error reported from Stat: %v", err)
+	}
+	if fi.Name() != "b" || fi.Size() != 2 || fi.IsDir() {
+		t.Errorf("Unexpected file info %s, %d, %v",
+			fi.Name(), fi.Size(), fi.IsDir())
+	}
+
+	if err = s.Remove(context.Background(), child(dir, "a")); err != nil {
+		t.Errorf("Error reported from Remove: %v", err)
+	}
+	if _, err = s.Stat(context.Background(), child(dir, "a")); !errors.Is(
+		err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error for removed file: %v", err)
+	}
+}
+
+func TestOpenMissingFileReturnsNil(t *testing.T) {
+	s, dir := newTestServer(t)
+
+	rc, err := s.OpenReader(context.Background(), child(dir, "missing"))
+	if err == nil {
+		t.Fatal("Expected error opening missing file")
+	}
+	if rc != nil {
+		t.Errorf("Non-nil reader %#v returned with error", rc)
+	}
+
+	wc, err := s.OpenAppender(context.Background(),
+		child(dir, "missing/file"))
+	if err == nil {
+		t.Fatal("Expected error opening file in missing directory")
+	}
+	if wc != nil {
+		t.Errorf("Non-nil writer %#v returned with error", wc)
+	}
+}
+
+func TestCancelledContext(t *testing.T) {
+	s, dir := newTestServer(t)
+	u := child(dir, "test.txt")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Establish the pooled connection first.
+	writeFile(t, s, u, "hello")
+	cancel()
+
+	if _, err := s.OpenReader(ctx, u); err != context.Canceled {
+		t.Errorf("Unexpected error from OpenReader: %v", err)
+	}
+	if _, err := s.ListEntries(ctx, dir); err != context.Canceled {
+		t.Errorf("Unexpected error from ListEntries: %v", err)
+	}
+	if _, err := s.Stat(ctx, u); err != context.Canceled {
+		t.Errorf("Unexpected error from Stat: %v", err)
+	}
+	if err := s.Remove(ctx, u); err != context.Canceled {
+		t.Errorf("Unexpected error from Remove: %v", err)
+	}
+	if data := readFile(t, s, u); data != "hello" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	s, dir := newTestServer(t)
+	u := child(dir, "watched.log")
+	changes := make(chan string, 16)
+
+	writeFile(t, s, u, "first")
+
+	cancel, errs, err := s.WatchFile(context.Background(), u,
+		func(changed *url.URL, rc filesystem.ReadCloser) {
+			defer rc.Close(context.Background())
+			data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc))
+			changes <- string(data)
+		})
+	if err != nil {
+		t.Fatalf("Error reported from WatchFile: %v", err)
+	}
+
+	writeFile(t, s, u, "second!")
+
+	select {
+	case data := <-changes:
+		if data != "second!" {
+			t.Errorf("Unexpected contents %q", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Timed out waiting for change")
+	}
+
+	cancel()
+	for {
+		select {
+		case _, ok := <-errs:
+			if !ok {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Error channel not closed after cancelling the watch")
+		}
+	}
+}
+
+func TestWatchFileStopsWhenContextDone(t *testing.T) {
+	s, dir := newTestServer(t)
+	u := child(dir, "watched.log")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	writeFile(t, s, u, "first")
+
+	_, errs, err := s.WatchFile(ctx, u,
+		func(changed *url.URL, rc filesystem.ReadCloser) {
+			rc.Close(context.Background())
+		})
+	if err != nil {
+		t.Fatalf("Error reported from WatchFile: %v", err)
+	}
+
+	cancel()
+	for {
+		select {
+		case _, ok := <-errs:
+			if !ok {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Error channel not closed after ctx was cancelled")
+		}
+	}
+}
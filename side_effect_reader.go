@@ -0,0 +1,48 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+)
+
+/*
+ReadCloser which invokes a function once the underlying reader is
+exhausted.
+*/
+type sideEffectReadCloser struct {
+	r    ReadCloser
+	fn   func()
+	done bool
+}
+
+/*
+Read reads from the underlying ReadCloser. When it first reports io.EOF, the
+side effect is invoked before the EOF is passed on.
+*/
+func (s *sideEffectReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	var n, err = s.r.Read(ctx, p)
+
+	if err == io.EOF && !s.done {
+		s.done = true
+		s.fn()
+	}
+	return n, err
+}
+
+/*
+Close closes the underlying ReadCloser. The side effect is not invoked for
+readers closed before reaching the end.
+*/
+func (s *sideEffectReadCloser) Close(ctx context.Context) error {
+	return s.r.Close(ctx)
+}
+
+/*
+NewReaderWithSideEffect wraps r into a ReadCloser which calls fn exactly
+once, when Read first returns io.EOF, and before that EOF is returned to the
+caller. Errors other than io.EOF do not trigger fn. This allows e.g.
+populating a cache or counting complete reads.
+*/
+func NewReaderWithSideEffect(r ReadCloser, fn func()) ReadCloser {
+	return &sideEffectReadCloser{r: r, fn: fn}
+}
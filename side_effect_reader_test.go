@@ -0,0 +1,45 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestReaderWithSideEffect(t *testing.T) {
+	calls := 0
+	buf := make([]byte, 10)
+	r := NewReaderWithSideEffect(
+		&LimitedReadCloser{R: &MockReadCloser{}, N: 15}, func() { calls++ })
+
+	if _, err := r.Read(context.Background(), buf); err != nil {
+		t.Errorf("Error reported from first read: %v", err)
+	}
+	if calls != 0 {
+		t.Error("Side effect called before reaching EOF")
+	}
+
+	for i := 0; i < 3; i++ {
+		r.Read(context.Background(), buf)
+	}
+	if calls != 1 {
+		t.Errorf("Side effect called %d times, expected once", calls)
+	}
+
+	if _, err := r.Read(context.Background(), buf); err != io.EOF {
+		t.Errorf("Expected EOF after side effect, got %v", err)
+	}
+}
+
+func TestReaderWithSideEffectError(t *testing.T) {
+	called := false
+	r := NewReaderWithSideEffect(&MockReadCloser{Fail: true},
+		func() { called = true })
+
+	if _, err := r.Read(context.Background(), make([]byte, 10)); err != ErrExpected {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if called {
+		t.Error("Side effect called on error other than EOF")
+	}
+}
@@ -0,0 +1,98 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+/*
+SignedURLOptions restricts the use of a signed URL.
+*/
+type SignedURLOptions struct {
+	// Duration for which the URL is valid.
+	ExpiresIn time.Duration
+
+	// IP address or CIDR range the URL may be used from. Any client may use
+	// the URL if this is empty.
+	IPRestriction string
+
+	// Headers which have to be sent along with requests using the URL, or
+	// which the CDN should set on the response, depending on the provider.
+	CustomHeaders map[string]string
+}
+
+/*
+SignedURLFileSystem is implemented by file systems which can hand out URLs
+that allow clients such as web browsers to access individual files without
+holding credentials, e.g. CDN signed URLs (CloudFront, Fastly, Cloudflare)
+or pre-signed object store URLs.
+*/
+type SignedURLFileSystem interface {
+	// Create a URL which can be used to download the file.
+	SignedReadURL(context.Context, *url.URL, SignedURLOptions) (string, error)
+
+	// Create a URL which can be used to upload the file.
+	SignedWriteURL(context.Context, *url.URL, SignedURLOptions) (string, error)
+}
+
+/*
+getSignedURLFileSystem determines the SignedURLFileSystem responsible for
+the URL, or returns ENOFS or EUNSUPP if there is none.
+*/
+func getSignedURLFileSystem(fileurl *url.URL) (SignedURLFileSystem, error) {
+	var fs = GetImplementation(fileurl)
+	var sfs SignedURLFileSystem
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if sfs, ok = fs.(SignedURLFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	return sfs, nil
+}
+
+/*
+SignedReadURL generates a URL from which clients can download the
+referenced file directly, without going through this process. The URL is
+only valid within the restrictions of opts.
+*/
+func SignedReadURL(ctx context.Context, fileurl *url.URL,
+	opts SignedURLOptions) (string, error) {
+	var sfs SignedURLFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if sfs, err = getSignedURLFileSystem(fileurl); err != nil {
+		return "", err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return sfs.SignedReadURL(ctx, fileurl, opts)
+}
+
+/*
+SignedWriteURL generates a URL to which clients can upload the referenced
+file directly. See SignedReadURL.
+*/
+func SignedWriteURL(ctx context.Context, fileurl *url.URL,
+	opts SignedURLOptions) (string, error) {
+	var sfs SignedURLFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if sfs, err = getSignedURLFileSystem(fileurl); err != nil {
+		return "", err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return sfs.SignedWriteURL(ctx, fileurl, opts)
+}
@@ -0,0 +1,68 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+/*
+signedURLFileSystem hands out URLs of a fictitious CDN. Read URLs are only
+handed out for existing files.
+*/
+type signedURLFileSystem struct {
+	*virtualfs.VirtualFileSystem
+}
+
+func (s signedURLFileSystem) SignedReadURL(ctx context.Context, u *url.URL,
+	opts filesystem.SignedURLOptions) (string, error) {
+	if _, err := s.Stat(ctx, u); err != nil {
+		return "", err
+	}
+	return "https://cdn.example.com" + u.Path + "?op=read", nil
+}
+
+func (s signedURLFileSystem) SignedWriteURL(ctx context.Context, u *url.URL,
+	opts filesystem.SignedURLOptions) (string, error) {
+	return "https://cdn.example.com" + u.Path + "?op=write", nil
+}
+
+func TestSignedURL(t *testing.T) {
+	filesystem.AddImplementation("signedurlplain", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("signedurl", signedURLFileSystem{
+		virtualfs.NewVirtualFileSystem()})
+	u := &url.URL{Scheme: "signedurl", Path: "/file"}
+	ctx := context.Background()
+	var opts filesystem.SignedURLOptions
+
+	if _, err := filesystem.SignedReadURL(ctx, &url.URL{
+		Scheme: "signedurlunregistered", Path: "/file"}, opts); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+	if _, err := filesystem.SignedWriteURL(ctx, &url.URL{
+		Scheme: "signedurlplain", Path: "/file"}, opts); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP without support, got %v", err)
+	}
+
+	if _, err := filesystem.SignedReadURL(ctx, u, opts); !errors.Is(
+		err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error for missing file: %v", err)
+	}
+	if signed, err := filesystem.SignedWriteURL(ctx, u, opts); err != nil {
+		t.Errorf("Error reported from SignedWriteURL: %v", err)
+	} else if signed != "https://cdn.example.com/file?op=write" {
+		t.Errorf("Unexpected write URL %s", signed)
+	}
+
+	writeTestFile(t, u, "signed")
+	if signed, err := filesystem.SignedReadURL(ctx, u, opts); err != nil {
+		t.Errorf("Error reported from SignedReadURL: %v", err)
+	} else if signed != "https://cdn.example.com/file?op=read" {
+		t.Errorf("Unexpected read URL %s", signed)
+	}
+}
@@ -0,0 +1,171 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"reflect"
+	"sync"
+	"time"
+)
+
+/*
+Number of files copied concurrently by SnapshotTo if no concurrency is
+specified in the SnapshotOptions.
+*/
+const defaultSnapshotConcurrency = 8
+
+/*
+SnapshotOptions controls the behavior of SnapshotTo.
+*/
+type SnapshotOptions struct {
+	// Maximum number of files copied at the same time. Defaults to 8.
+	Concurrency int
+
+	// Shell patterns as understood by path.Match. Files whose path
+	// relative to the root matches any of them are not copied.
+	ExcludePatterns []string
+
+	// Copy previous versions of files as well. This requires native
+	// support by the file system.
+	IncludeVersions bool
+}
+
+/*
+SnapshotResult summarizes the transfer performed by SnapshotTo.
+*/
+type SnapshotResult struct {
+	FilesTransferred int64
+	BytesTransferred int64
+	Duration         time.Duration
+}
+
+/*
+SnapshottingFileSystem is implemented by file systems which can copy entire
+directory trees efficiently, e.g. using bulk server-side copies when the
+destination is an instance of the same implementation.
+*/
+type SnapshottingFileSystem interface {
+	SnapshotTo(context.Context, *url.URL, FileSystem, *url.URL, SnapshotOptions) (
+		SnapshotResult, error)
+}
+
+/*
+excluded determines whether the relative path matches any of the patterns.
+*/
+func excluded(rel string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+sameFileSystem determines whether a and b are the same file system. Unlike
+comparing the interfaces directly, this does not panic if the dynamic type
+of the implementation is not comparable; such file systems are never
+considered to be the same.
+*/
+func sameFileSystem(a, b FileSystem) bool {
+	var t = reflect.TypeOf(a)
+
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
+
+/*
+SnapshotTo copies all files beneath root to the same relative paths beneath
+dstRoot on dst, which does not need to be registered. This is intended for
+the initial seeding of replicas, which are then kept up to date
+incrementally.
+
+If the file system of root implements SnapshottingFileSystem, the transfer
+is left to it. Otherwise, the files are listed using ListEntriesRecursive
+and copied with opts.Concurrency files in flight, server side if dst is the
+file system of root and it implements CopyingFileSystem. Errors copying
+individual files do not stop the snapshot; they are returned together as a
+MultiError. Once ctx is cancelled, no further copies are started.
+IncludeVersions is not supported by this fallback and causes EUNSUPP to be
+returned. As copying an entire tree can take very long, the default timeout
+is not applied.
+*/
+func SnapshotTo(ctx context.Context, root *url.URL, dst FileSystem, dstRoot *url.URL,
+	opts SnapshotOptions) (SnapshotResult, error) {
+	var srcfs = GetImplementation(root)
+	var start = time.Now()
+	var result SnapshotResult
+	var errs MultiError
+	var paths []string
+	var sem chan struct{}
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	var err error
+
+	if srcfs == nil {
+		return result, ENOFS
+	}
+
+	if sfs, ok := srcfs.(SnapshottingFileSystem); ok {
+		return sfs.SnapshotTo(ctx, root, dst, dstRoot, opts)
+	}
+
+	if opts.IncludeVersions {
+		return result, EUNSUPP
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultSnapshotConcurrency
+	}
+	sem = make(chan struct{}, opts.Concurrency)
+
+	if paths, err = ListEntriesRecursive(ctx, root); err != nil {
+		return result, err
+	}
+
+	for _, rel := range paths {
+		if excluded(rel, opts.ExcludePatterns) {
+			continue
+		}
+
+		if err = ctx.Err(); err != nil {
+			lock.Lock()
+			errs = append(errs, err)
+			lock.Unlock()
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(rel string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			var src, target = childURL(root, rel), childURL(dstRoot, rel)
+			var n int64
+			var err error
+
+			if cfs, ok := dst.(CopyingFileSystem); ok && sameFileSystem(dst, srcfs) {
+				n, err = cfs.Copy(ctx, src, target)
+			} else {
+				n, err = streamCopy(ctx, srcfs, dst, src, target, nil)
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			result.FilesTransferred++
+			result.BytesTransferred += n
+		}(rel)
+	}
+
+	wg.Wait()
+
+	result.Duration = time.Since(start)
+	return result, errs.ErrorOrNil()
+}
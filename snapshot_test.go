@@ -0,0 +1,126 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestSnapshotTo(t *testing.T) {
+	filesystem.AddImplementation("snapsrc", virtualfs.NewVirtualFileSystem())
+	replica := virtualfs.NewVirtualFileSystem()
+
+	for _, name := range []string{"a.txt", "sub/b.txt", "sub/c.tmp"} {
+		writeTestFile(t, &url.URL{Scheme: "snapsrc", Path: "/data/" + name}, name)
+	}
+
+	result, err := filesystem.SnapshotTo(context.Background(),
+		&url.URL{Scheme: "snapsrc", Path: "/data"}, replica,
+		&url.URL{Scheme: "replica", Path: "/seed"},
+		filesystem.SnapshotOptions{Concurrency: 2, ExcludePatterns: []string{"*/*.tmp"}})
+	if err != nil {
+		t.Fatalf("Error reported from SnapshotTo: %v", err)
+	}
+	if result.FilesTransferred != 2 || result.BytesTransferred != 14 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	entries, _ := replica.ListEntries(context.Background(),
+		&url.URL{Scheme: "replica", Path: "/seed/sub"})
+	if len(entries) != 1 || entries[0] != "b.txt" {
+		t.Errorf("Unexpected entries in replica %v", entries)
+	}
+
+	if _, err = filesystem.SnapshotTo(context.Background(),
+		&url.URL{Scheme: "snapsrc", Path: "/data"}, replica,
+		&url.URL{Scheme: "replica", Path: "/seed"},
+		filesystem.SnapshotOptions{IncludeVersions: true}); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP for IncludeVersions, got %v", err)
+	}
+}
+
+/*
+valueFileSystem is a file system whose dynamic type is not comparable.
+*/
+type valueFileSystem struct {
+	*virtualfs.VirtualFileSystem
+	labels map[string]string
+}
+
+func (v valueFileSystem) Copy(ctx context.Context, src, dst *url.URL) (
+	int64, error) {
+	return 0, filesystem.EUNSUPP
+}
+
+func TestSnapshotToIncomparableFileSystem(t *testing.T) {
+	fs := valueFileSystem{virtualfs.NewVirtualFileSystem(), map[string]string{}}
+	filesystem.AddImplementation("snapvalue", fs)
+
+	writeTestFile(t, &url.URL{Scheme: "snapvalue", Path: "/data/a.txt"}, "a")
+
+	result, err := filesystem.SnapshotTo(context.Background(),
+		&url.URL{Scheme: "snapvalue", Path: "/data"}, fs,
+		&url.URL{Scheme: "snapvalue", Path: "/seed"},
+		filesystem.SnapshotOptions{})
+	if err != nil {
+		t.Fatalf("Error reported from SnapshotTo: %v", err)
+	}
+	if result.FilesTransferred != 1 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if data, _ := readTestFile(t, &url.URL{Scheme: "snapvalue",
+		Path: "/seed/a.txt"}); data != "a" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+}
+
+/*
+cancellingFileSystem cancels a context as soon as a file is opened for
+reading.
+*/
+type cancellingFileSystem struct {
+	*virtualfs.VirtualFileSystem
+	cancel  context.CancelFunc
+	lock    sync.Mutex
+	readers int
+}
+
+func (c *cancellingFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	filesystem.ReadCloser, error) {
+	c.lock.Lock()
+	c.readers++
+	c.lock.Unlock()
+	c.cancel()
+	return c.VirtualFileSystem.OpenReader(ctx, u)
+}
+
+func TestSnapshotToCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := &cancellingFileSystem{
+		VirtualFileSystem: virtualfs.NewVirtualFileSystem(),
+		cancel:            cancel,
+	}
+	filesystem.AddImplementation("snapcancel", src)
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		writeTestFile(t, &url.URL{Scheme: "snapcancel", Path: "/data/" + name}, name)
+	}
+
+	_, err := filesystem.SnapshotTo(ctx,
+		&url.URL{Scheme: "snapcancel", Path: "/data"},
+		virtualfs.NewVirtualFileSystem(),
+		&url.URL{Scheme: "replica", Path: "/seed"},
+		filesystem.SnapshotOptions{Concurrency: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Unexpected error from SnapshotTo: %v", err)
+	}
+	// The copy started while the first one was running may still go ahead.
+	if src.readers > 2 {
+		t.Errorf("%d copies started after cancellation", src.readers-1)
+	}
+}
@@ -0,0 +1,123 @@
+package filesystem
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path"
+)
+
+/*
+SpoolWriteCloser is a WriteCloser which spools all data to a temporary file
+before uploading it to its destination on Close. Create one using
+NewSpoolWriteCloser.
+*/
+type SpoolWriteCloser struct {
+	spoolfs FileSystem
+	spool   *url.URL
+	dst     *url.URL
+	w       WriteCloser
+	closed  bool
+}
+
+/*
+NewSpoolWriteCloser creates a WriteCloser for dst which writes all data to a
+temporary file in spoolDir first. inner is the file system spoolDir resides
+on, usually a local one. On Close, the spool file is copied to dst and
+removed.
+
+If the upload fails, e.g. because ctx was cancelled, the spool file is
+preserved so that the upload can be completed later using
+ResumeSpooledUpload. Its location can be retrieved using SpoolURL.
+*/
+func NewSpoolWriteCloser(ctx context.Context, inner FileSystem, dst *url.URL,
+	spoolDir *url.URL) (WriteCloser, error) {
+	var id = make([]byte, 8)
+	var spool = *spoolDir
+	var w WriteCloser
+	var cancel context.CancelFunc
+	var err error
+
+	if _, err = rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+	spool.Path = path.Join(spoolDir.Path, "spool-"+hex.EncodeToString(id))
+
+	if w, err = inner.OpenWriter(ctx, &spool); err != nil {
+		return nil, err
+	}
+
+	return &SpoolWriteCloser{spoolfs: inner, spool: &spool, dst: dst, w: w}, nil
+}
+
+/*
+SpoolURL returns the location of the spool file.
+*/
+func (s *SpoolWriteCloser) SpoolURL() *url.URL {
+	return s.spool
+}
+
+/*
+Write appends p to the spool file.
+*/
+func (s *SpoolWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	return s.w.Write(ctx, p)
+}
+
+/*
+Close completes the spool file, uploads it to the destination and removes
+it. The spool file is preserved if the upload fails. Subsequent calls to
+Close return os.ErrClosed; use ResumeSpooledUpload to retry the upload.
+*/
+func (s *SpoolWriteCloser) Close(ctx context.Context) error {
+	var err error
+
+	if s.closed {
+		return os.ErrClosed
+	}
+	s.closed = true
+
+	if err = s.w.Close(ctx); err != nil {
+		return err
+	}
+
+	return uploadSpool(ctx, s.spoolfs, s.spool, s.dst)
+}
+
+/*
+uploadSpool copies the spool file from spoolfs to dst, then removes it.
+*/
+func uploadSpool(ctx context.Context, spoolfs FileSystem, spool, dst *url.URL) error {
+	var dstfs = GetImplementation(dst)
+	var cancel context.CancelFunc
+	var err error
+
+	if dstfs == nil {
+		return ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if _, err = streamCopy(ctx, spoolfs, dstfs, spool, dst, nil); err != nil {
+		return err
+	}
+
+	return spoolfs.Remove(ctx, spool)
+}
+
+/*
+ResumeSpooledUpload completes the upload of a spool file left behind by a
+SpoolWriteCloser whose upload failed. inner is the file system the spool
+file resides on, as passed to NewSpoolWriteCloser. The spool file is copied
+to dst, then removed.
+*/
+func ResumeSpooledUpload(ctx context.Context, inner FileSystem, spoolPath,
+	dst *url.URL) error {
+	return uploadSpool(ctx, inner, spoolPath, dst)
+}
@@ -0,0 +1,83 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestSpoolWriteCloser(t *testing.T) {
+	spoolfs := virtualfs.NewVirtualFileSystem()
+	filesystem.AddImplementation("spool", spoolfs)
+	filesystem.AddImplementation("spooldst", virtualfs.NewVirtualFileSystem())
+	spoolDir := &url.URL{Scheme: "spool", Path: "/tmp"}
+	dst := &url.URL{Scheme: "spooldst", Path: "/file"}
+	ctx := context.Background()
+
+	wc, err := filesystem.NewSpoolWriteCloser(ctx, spoolfs, dst, spoolDir)
+	if err != nil {
+		t.Fatalf("Error reported from NewSpoolWriteCloser: %v", err)
+	}
+	wc.Write(ctx, []byte("spooled"))
+
+	if entries, _ := spoolfs.ListEntries(ctx, spoolDir); len(entries) != 1 {
+		t.Errorf("Expected a single spool file, got %v", entries)
+	}
+	if _, err = readTestFile(t, dst); err == nil {
+		t.Error("Destination written before Close")
+	}
+
+	if err = wc.Close(ctx); err != nil {
+		t.Fatalf("Error reported from Close: %v", err)
+	}
+	if data, _ := readTestFile(t, dst); data != "spooled" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+	if entries, _ := spoolfs.ListEntries(ctx, spoolDir); len(entries) != 0 {
+		t.Errorf("Spool file not removed: %v", entries)
+	}
+}
+
+func TestSpoolWriteCloserCancelled(t *testing.T) {
+	// The spool file system is deliberately not registered.
+	spoolfs := virtualfs.NewVirtualFileSystem()
+	filesystem.AddImplementation("spoolresumedst", virtualfs.NewVirtualFileSystem())
+	spoolDir := &url.URL{Scheme: "spoolresume", Path: "/tmp"}
+	dst := &url.URL{Scheme: "spoolresumedst", Path: "/file"}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	wc, err := filesystem.NewSpoolWriteCloser(ctx, spoolfs, dst, spoolDir)
+	if err != nil {
+		t.Fatalf("Error reported from NewSpoolWriteCloser: %v", err)
+	}
+	wc.Write(ctx, []byte("spooled"))
+	cancel()
+
+	if err = wc.Close(ctx); err != context.Canceled {
+		t.Errorf("Unexpected error from Close: %v", err)
+	}
+	if _, err = readTestFile(t, dst); err == nil {
+		t.Error("Destination written despite cancellation")
+	}
+	if err = wc.Close(context.Background()); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Unexpected error from second Close: %v", err)
+	}
+
+	spool := wc.(*filesystem.SpoolWriteCloser).SpoolURL()
+	if err = filesystem.ResumeSpooledUpload(context.Background(), spoolfs,
+		spool, dst); err != nil {
+		t.Fatalf("Error reported from ResumeSpooledUpload: %v", err)
+	}
+	if data, _ := readTestFile(t, dst); data != "spooled" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+	if entries, _ := spoolfs.ListEntries(context.Background(),
+		spoolDir); len(entries) != 0 {
+		t.Errorf("Spool file not removed: %v", entries)
+	}
+}
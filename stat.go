@@ -0,0 +1,60 @@
+package filesystem
+
+import (
+	"context"
+	"io/fs"
+	"net/url"
+	"time"
+)
+
+/*
+FileInfo describes a file system object, as returned by Stat.
+*/
+type FileInfo interface {
+	// Base name of the object.
+	Name() string
+
+	// Length of the object in bytes, if known, or -1 otherwise.
+	Size() int64
+
+	// Time of the last modification of the object.
+	ModTime() time.Time
+
+	// Whether the object is something resembling a directory.
+	IsDir() bool
+
+	// Mode and permission bits of the object, if the file system has such
+	// a notion.
+	Mode() fs.FileMode
+}
+
+/*
+StatFileSystem is implemented by file systems which can retrieve metadata
+about an object without opening it.
+
+Deprecated: Stat is part of the FileSystem interface now, so every
+FileSystem implements StatFileSystem.
+*/
+type StatFileSystem interface {
+	// Retrieve metadata about the object described by the URL.
+	Stat(context.Context, *url.URL) (FileInfo, error)
+}
+
+/*
+Stat retrieves metadata about the referenced object, such as its size and
+modification time, without opening it. File systems which cannot provide
+this information return EUNSUPP.
+*/
+func Stat(ctx context.Context, fileurl *url.URL) (FileInfo, error) {
+	var fs = GetImplementation(fileurl)
+	var cancel context.CancelFunc
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return fs.Stat(ctx, fileurl)
+}
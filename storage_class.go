@@ -0,0 +1,100 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+StorageClassFileSystem is implemented by file systems offering multiple
+storage tiers, such as the storage classes of S3 or GCS. The names of the
+storage classes are defined by the implementations.
+*/
+type StorageClassFileSystem interface {
+	// Set the storage class of the file. Must take effect immediately.
+	SetStorageClass(context.Context, *url.URL, string) error
+
+	// Retrieve the current storage class of the file.
+	GetStorageClass(context.Context, *url.URL) (string, error)
+
+	// Initiate moving the file to another storage class. May return before
+	// the transition is complete, e.g. when restoring archived objects.
+	TransitionStorageClass(context.Context, *url.URL, string) error
+}
+
+/*
+getStorageClassFileSystem determines the StorageClassFileSystem responsible
+for the URL, or returns ENOFS or EUNSUPP if there is none.
+*/
+func getStorageClassFileSystem(fileurl *url.URL) (StorageClassFileSystem, error) {
+	var fs = GetImplementation(fileurl)
+	var sfs StorageClassFileSystem
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if sfs, ok = fs.(StorageClassFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	return sfs, nil
+}
+
+/*
+SetStorageClass sets the storage class of the referenced file.
+*/
+func SetStorageClass(ctx context.Context, fileurl *url.URL, class string) error {
+	var sfs StorageClassFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if sfs, err = getStorageClassFileSystem(fileurl); err != nil {
+		return err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return sfs.SetStorageClass(ctx, fileurl, class)
+}
+
+/*
+GetStorageClass retrieves the storage class of the referenced file. While a
+transition is in progress, the old storage class may be returned.
+*/
+func GetStorageClass(ctx context.Context, fileurl *url.URL) (string, error) {
+	var sfs StorageClassFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if sfs, err = getStorageClassFileSystem(fileurl); err != nil {
+		return "", err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return sfs.GetStorageClass(ctx, fileurl)
+}
+
+/*
+TransitionStorageClass initiates moving the referenced file to another
+storage class. Transitions from archival tiers may take hours to complete;
+use GetStorageClass to determine when the file has arrived.
+*/
+func TransitionStorageClass(ctx context.Context, fileurl *url.URL, class string) error {
+	var sfs StorageClassFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if sfs, err = getStorageClassFileSystem(fileurl); err != nil {
+		return err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return sfs.TransitionStorageClass(ctx, fileurl, class)
+}
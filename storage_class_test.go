@@ -0,0 +1,107 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+/*
+storageClassFileSystem keeps track of the storage classes of existing files.
+Transitions are completed by the next call to GetStorageClass, which still
+returns the old storage class.
+*/
+type storageClassFileSystem struct {
+	*virtualfs.VirtualFileSystem
+	lock    sync.Mutex
+	classes map[string]string
+	pending map[string]string
+}
+
+func (s *storageClassFileSystem) SetStorageClass(ctx context.Context,
+	u *url.URL, class string) error {
+	if _, err := s.Stat(ctx, u); err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.classes[u.Path] = class
+	return nil
+}
+
+func (s *storageClassFileSystem) GetStorageClass(ctx context.Context,
+	u *url.URL) (string, error) {
+	if _, err := s.Stat(ctx, u); err != nil {
+		return "", err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	class := s.classes[u.Path]
+	if pending, ok := s.pending[u.Path]; ok {
+		s.classes[u.Path] = pending
+		delete(s.pending, u.Path)
+	}
+	return class, nil
+}
+
+func (s *storageClassFileSystem) TransitionStorageClass(ctx context.Context,
+	u *url.URL, class string) error {
+	if _, err := s.Stat(ctx, u); err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.pending[u.Path] = class
+	return nil
+}
+
+func TestStorageClass(t *testing.T) {
+	filesystem.AddImplementation("storageclassplain", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("storageclass", &storageClassFileSystem{
+		VirtualFileSystem: virtualfs.NewVirtualFileSystem(),
+		classes:           make(map[string]string),
+		pending:           make(map[string]string),
+	})
+	u := &url.URL{Scheme: "storageclass", Path: "/file"}
+	plain := &url.URL{Scheme: "storageclassplain", Path: "/file"}
+	ctx := context.Background()
+
+	if err := filesystem.SetStorageClass(ctx, &url.URL{
+		Scheme: "storageclassunregistered", Path: "/file"}, "COLD"); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+	if _, err := filesystem.GetStorageClass(ctx, plain); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP from GetStorageClass, got %v", err)
+	}
+	if err := filesystem.TransitionStorageClass(ctx, plain,
+		"ARCHIVE"); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP from TransitionStorageClass, got %v", err)
+	}
+
+	if err := filesystem.SetStorageClass(ctx, u, "COLD"); !errors.Is(
+		err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error for missing file: %v", err)
+	}
+
+	writeTestFile(t, u, "tiered")
+	if err := filesystem.SetStorageClass(ctx, u, "COLD"); err != nil {
+		t.Errorf("Error reported from SetStorageClass: %v", err)
+	}
+	if err := filesystem.TransitionStorageClass(ctx, u, "ARCHIVE"); err != nil {
+		t.Errorf("Error reported from TransitionStorageClass: %v", err)
+	}
+	if class, err := filesystem.GetStorageClass(ctx, u); err != nil ||
+		class != "COLD" {
+		t.Errorf("Unexpected storage class %q during transition (%v)", class, err)
+	}
+	if class, err := filesystem.GetStorageClass(ctx, u); err != nil ||
+		class != "ARCHIVE" {
+		t.Errorf("Unexpected storage class %q after transition (%v)", class, err)
+	}
+}
@@ -0,0 +1,101 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+/*
+StorageStats summarizes the files beneath a directory.
+*/
+type StorageStats struct {
+	// Number of files beneath the directory.
+	TotalObjects int64
+
+	// Combined size of all files in bytes.
+	TotalBytes int64
+
+	// Modification times of the least and most recently modified files.
+	OldestModTime time.Time
+	NewestModTime time.Time
+
+	// Combined size of the files in bytes, by storage class. Only set by
+	// file systems which implement StorageClassFileSystem.
+	ByStorageClass map[string]int64
+}
+
+/*
+StorageStatsFileSystem is implemented by file systems which can summarize
+the contents of a directory in a single call, e.g. from a storage metrics
+API.
+*/
+type StorageStatsFileSystem interface {
+	// Summarize the files beneath the directory, including subdirectories.
+	GetStorageStats(context.Context, *url.URL) (StorageStats, error)
+}
+
+/*
+GetStorageStats returns statistics about all files beneath the referenced
+directory, including all subdirectories. This is a richer variant of
+ObjectCount and TotalSize.
+
+File systems which do not implement StorageStatsFileSystem are listed using
+ListEntriesRecursive and BulkStat. If they implement StorageClassFileSystem,
+the storage class of every file is looked up as well.
+*/
+func GetStorageStats(ctx context.Context, dirurl *url.URL) (StorageStats, error) {
+	var fs = GetImplementation(dirurl)
+	var stats StorageStats
+	var scfs StorageClassFileSystem
+	var urls []*url.URL
+	var infos []FileInfo
+	var hasClasses bool
+	var err error
+
+	if fs == nil {
+		return StorageStats{}, ENOFS
+	}
+
+	if sfs, ok := fs.(StorageStatsFileSystem); ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = withDefaultTimeout(ctx)
+		defer cancel()
+
+		return sfs.GetStorageStats(ctx, dirurl)
+	}
+
+	if urls, infos, err = statRecursive(ctx, dirurl); err != nil {
+		return StorageStats{}, err
+	}
+
+	if scfs, hasClasses = fs.(StorageClassFileSystem); hasClasses {
+		stats.ByStorageClass = make(map[string]int64)
+	}
+
+	for i, fi := range infos {
+		var modTime = fi.ModTime()
+
+		stats.TotalObjects++
+		stats.TotalBytes += fi.Size()
+
+		if stats.OldestModTime.IsZero() || modTime.Before(stats.OldestModTime) {
+			stats.OldestModTime = modTime
+		}
+		if modTime.After(stats.NewestModTime) {
+			stats.NewestModTime = modTime
+		}
+
+		if hasClasses {
+			var class string
+
+			if class, err = scfs.GetStorageClass(ctx, urls[i]); err != nil {
+				return StorageStats{}, err
+			}
+			stats.ByStorageClass[class] += fi.Size()
+		}
+	}
+
+	return stats, nil
+}
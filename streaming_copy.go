@@ -0,0 +1,222 @@
+package filesystem
+
+import (
+	"context"
+	"hash"
+	"net/url"
+	"time"
+)
+
+/*
+TransformReadCloser describes a pipeline stage of a StreamingCopyBuilder,
+e.g. compression or encryption. It wraps the output of the previous stage
+into a ReadCloser producing the transformed data. Closing the returned
+ReadCloser must close the wrapped one.
+*/
+type TransformReadCloser func(ReadCloser) ReadCloser
+
+/*
+StreamingCopyResult describes the outcome of a streaming copy.
+*/
+type StreamingCopyResult struct {
+	// Number of bytes written to the destination.
+	BytesTransferred int64
+
+	// Time taken by the entire copy.
+	Duration time.Duration
+
+	// Checksum of the data written to the destination, if requested using
+	// WithChecksum.
+	Checksum []byte
+}
+
+/*
+StreamingCopyBuilder configures a copy which streams the data from one URL
+to another through a pipeline of stages. Create one using NewStreamingCopy.
+*/
+type StreamingCopyBuilder struct {
+	src, dst   *url.URL
+	transforms []TransformReadCloser
+	bps        int64
+	progress   ProgressFunc
+	algorithm  string
+}
+
+/*
+NewStreamingCopy starts building a copy of the file at src to dst.
+*/
+func NewStreamingCopy(src, dst *url.URL) *StreamingCopyBuilder {
+	return &StreamingCopyBuilder{src: src, dst: dst}
+}
+
+/*
+WithTransform adds a transformation stage to the pipeline. Stages are
+applied in the order they were added.
+*/
+func (b *StreamingCopyBuilder) WithTransform(t TransformReadCloser) *StreamingCopyBuilder {
+	b.transforms = append(b.transforms, t)
+	return b
+}
+
+/*
+WithRateLimit limits the copy to bps bytes per second. A limit of 0 or less
+disables rate limiting.
+*/
+func (b *StreamingCopyBuilder) WithRateLimit(bps int64) *StreamingCopyBuilder {
+	b.bps = bps
+	return b
+}
+
+/*
+WithProgress reports the progress of the copy to fn. The total is the size
+of the source file if it can be determined using Stat, or -1 otherwise. It
+is also -1 if transformation stages are used, since they may change the
+length of the data.
+*/
+func (b *StreamingCopyBuilder) WithProgress(fn ProgressFunc) *StreamingCopyBuilder {
+	b.progress = fn
+	return b
+}
+
+/*
+WithChecksum computes a checksum of the data written to the destination,
+i.e. after all transformation stages, using the named algorithm.
+*/
+func (b *StreamingCopyBuilder) WithChecksum(algorithm string) *StreamingCopyBuilder {
+	b.algorithm = algorithm
+	return b
+}
+
+/*
+Run executes the copy. The source is read, passed through the
+transformation stages, rate limited, hashed and written to the destination.
+An error in any stage aborts the copy and is returned along with the
+result up to that point.
+*/
+func (b *StreamingCopyBuilder) Run(ctx context.Context) (StreamingCopyResult, error) {
+	var srcfs = GetImplementation(b.src)
+	var dstfs = GetImplementation(b.dst)
+	var result StreamingCopyResult
+	var start = time.Now()
+	var total int64 = -1
+	var h hash.Hash
+	var cancel context.CancelFunc
+	var err error
+
+	if srcfs == nil || dstfs == nil {
+		return result, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if b.algorithm != "" {
+		if h, err = newHash(b.algorithm); err != nil {
+			return result, err
+		}
+	}
+
+	if b.progress != nil && len(b.transforms) == 0 {
+		if fi, err := Stat(ctx, b.src); err == nil {
+			total = fi.Size()
+		}
+	}
+
+	result.BytesTransferred, err = streamCopy(ctx, srcfs, dstfs, b.src, b.dst,
+		func(rc ReadCloser) ReadCloser {
+			for _, transform := range b.transforms {
+				rc = transform(rc)
+			}
+			if b.bps > 0 {
+				rc = &rateLimitedReadCloser{r: rc, bps: b.bps, start: time.Now()}
+			}
+			if h != nil {
+				rc = &hashReadCloser{r: rc, h: h}
+			}
+			if b.progress != nil {
+				rc = &ProgressReadCloser{R: rc, Total: total, Fn: b.progress}
+			}
+			return rc
+		})
+	result.Duration = time.Since(start)
+
+	if err == nil && h != nil {
+		result.Checksum = h.Sum(nil)
+	}
+
+	return result, err
+}
+
+/*
+ReadCloser which feeds all data read through it into a hash.
+*/
+type hashReadCloser struct {
+	r ReadCloser
+	h hash.Hash
+}
+
+/*
+Read reads from the underlying ReadCloser and adds the data to the hash.
+*/
+func (r *hashReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	var n, err = r.r.Read(ctx, p)
+
+	r.h.Write(p[0:n])
+	return n, err
+}
+
+/*
+Close closes the underlying ReadCloser.
+*/
+func (r *hashReadCloser) Close(ctx context.Context) error {
+	return r.r.Close(ctx)
+}
+
+/*
+ReadCloser which limits the average rate data can be read at.
+*/
+type rateLimitedReadCloser struct {
+	r     ReadCloser
+	bps   int64
+	start time.Time
+	n     int64
+}
+
+/*
+Read reads at most bps bytes from the underlying ReadCloser, then waits
+until the average rate since the first read is within the limit.
+*/
+func (r *rateLimitedReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	var n int
+	var err error
+	var delay time.Duration
+
+	if int64(len(p)) > r.bps {
+		p = p[0:r.bps]
+	}
+
+	n, err = r.r.Read(ctx, p)
+	r.n += int64(n)
+
+	delay = time.Duration(float64(r.n)/float64(r.bps)*float64(time.Second)) -
+		time.Since(r.start)
+	if delay > 0 {
+		var timer = time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return n, ctx.Err()
+		}
+	}
+
+	return n, err
+}
+
+/*
+Close closes the underlying ReadCloser.
+*/
+func (r *rateLimitedReadCloser) Close(ctx context.Context) error {
+	return r.r.Close(ctx)
+}
@@ -0,0 +1,69 @@
+package filesystem_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+type upperReadCloser struct {
+	filesystem.ReadCloser
+	closed bool
+}
+
+func (u *upperReadCloser) Close(ctx context.Context) error {
+	u.closed = true
+	return u.ReadCloser.Close(ctx)
+}
+
+func (u *upperReadCloser) Read(ctx context.Context, p []byte) (int, error) {
+	n, err := u.ReadCloser.Read(ctx, p)
+	copy(p, bytes.ToUpper(p[0:n]))
+	return n, err
+}
+
+func TestStreamingCopy(t *testing.T) {
+	filesystem.AddImplementation("streamcopy", virtualfs.NewVirtualFileSystem())
+	src := &url.URL{Scheme: "streamcopy", Path: "/src"}
+	dst := &url.URL{Scheme: "streamcopy", Path: "/dst"}
+	var progress, progressTotal int64
+	var upper *upperReadCloser
+
+	writeTestFile(t, src, "hello world")
+
+	result, err := filesystem.NewStreamingCopy(src, dst).
+		WithTransform(func(rc filesystem.ReadCloser) filesystem.ReadCloser {
+			upper = &upperReadCloser{ReadCloser: rc}
+			return upper
+		}).
+		WithRateLimit(1 << 20).
+		WithProgress(func(n, total int64) { progress, progressTotal = n, total }).
+		WithChecksum(filesystem.ChecksumSHA256).
+		Run(context.Background())
+	if err != nil {
+		t.Fatalf("Error reported from Run: %v", err)
+	}
+
+	if data, _ := readTestFile(t, dst); data != "HELLO WORLD" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+	if result.BytesTransferred != 11 || progress != 11 {
+		t.Errorf("Unexpected byte counts %d and %d", result.BytesTransferred,
+			progress)
+	}
+	if progressTotal != -1 {
+		t.Errorf("Progress total %d reported despite transformation", progressTotal)
+	}
+	if !upper.closed {
+		t.Error("Transformation stage was not closed")
+	}
+	if sum := sha256.Sum256([]byte("HELLO WORLD")); !bytes.Equal(
+		result.Checksum, sum[:]) {
+		t.Errorf("Unexpected checksum %x", result.Checksum)
+	}
+}
@@ -3,7 +3,10 @@ package filesystem
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io/fs"
 	"net/url"
+	"sync"
 )
 
 /*
@@ -18,6 +21,13 @@ framework indicating that no file system is registered under that name.
 */
 var ENOFS = errors.New("No file system loaded for URL type")
 
+/*
+ErrAlreadyExists is returned when an operation which requires a file to not
+exist yet is invoked on an existing file. It matches fs.ErrExist when
+compared using errors.Is.
+*/
+var ErrAlreadyExists = fmt.Errorf("File already exists: %w", fs.ErrExist)
+
 /*
 FileWatchFunc describes the format of a function which can be used to watch
 for changes in a file in a supported file system. The function will be called
@@ -27,6 +37,9 @@ used to access the modified contents.
 
 Implementations must allow for the ReadCloser to be discarded without ever
 calling Read().
+
+Deprecated: FileWatchFunc cannot tell apart creations and modifications of
+files. Use FileWatchFuncV2 with WatchFileV2 instead.
 */
 type FileWatchFunc func(*url.URL, ReadCloser)
 
@@ -73,12 +86,30 @@ type FileSystem interface {
 	// Delete the specified file. Failures may or may not leave the file
 	// existing.
 	Remove(context.Context, *url.URL) error
+
+	// Retrieve metadata about the object described by the URL, such as its
+	// size and whether it is a directory, without opening it.
+	Stat(context.Context, *url.URL) (FileInfo, error)
+}
+
+/*
+MultiSchemeFileSystem can be implemented by file systems which are able to
+handle more than one URL scheme, e.g. an S3 compatible implementation which
+can also talk to other object stores through their compatible endpoints.
+*/
+type MultiSchemeFileSystem interface {
+	FileSystem
+
+	// Retrieve the list of additional schemes the file system can handle.
+	SupportedSchemes() []string
 }
 
 /*
-All file system implementation adapters will be registered in this map.
+All file system implementation adapters will be registered in this map,
+which is protected by registeredFileSystemsLock.
 */
 var registeredFileSystems = make(map[string]FileSystem)
+var registeredFileSystemsLock sync.RWMutex
 
 /*
 AddImplementation is used on initialization of individual file system modules
@@ -89,12 +120,46 @@ implementation.
 Subsequent invocations of AddImplementation will cause the association to be
 overwritten.
 
+If the file system implements MultiSchemeFileSystem, it is registered under
+all schemes returned from SupportedSchemes as well.
+
 This function may be called from init() for easy file systems, or may require
 a more involved setup procedure for file systems talking to a server node
 and/or requiring authentication.
 */
 func AddImplementation(scheme string, fs FileSystem) {
+	var extra []string
+
+	// Determine the schemes before taking the lock, so SupportedSchemes
+	// may consult the registry itself.
+	if mfs, ok := fs.(MultiSchemeFileSystem); ok {
+		extra = mfs.SupportedSchemes()
+	}
+
+	registeredFileSystemsLock.Lock()
+	defer registeredFileSystemsLock.Unlock()
+
 	registeredFileSystems[scheme] = fs
+
+	for _, s := range extra {
+		registeredFileSystems[s] = fs
+	}
+}
+
+/*
+AddFsImplementation registers the fs.FS under the specified scheme, using
+FromIoFS. Together with embed.FS, this allows registering embedded files
+from init():
+
+	//go:embed assets
+	var assetFiles embed.FS
+
+	func init() {
+		filesystem.AddFsImplementation("assets", assetFiles)
+	}
+*/
+func AddFsImplementation(scheme string, fsys fs.FS) {
+	AddImplementation(scheme, FromIoFS(fsys, scheme))
 }
 
 /*
@@ -105,13 +170,10 @@ the URL, this returns nil.
 Usually you will want to use one of the more specific functions.
 */
 func GetImplementation(fileurl *url.URL) FileSystem {
-	var found bool
-
-	if _, found = registeredFileSystems[fileurl.Scheme]; found {
-		return registeredFileSystems[fileurl.Scheme]
-	}
+	registeredFileSystemsLock.RLock()
+	defer registeredFileSystemsLock.RUnlock()
 
-	return nil
+	return registeredFileSystems[fileurl.Scheme]
 }
 
 /*
@@ -122,7 +184,9 @@ scheme.
 func HasImplementation(scheme string) bool {
 	var found bool
 
+	registeredFileSystemsLock.RLock()
 	_, found = registeredFileSystems[scheme]
+	registeredFileSystemsLock.RUnlock()
 
 	return found
 }
@@ -130,14 +194,27 @@ func HasImplementation(scheme string) bool {
 /*
 OpenReader opens the referenced file and returns a ReadCloser object which
 can be used to access the files contents.
+
+If the URL has an accept-encoding query parameter listing the content
+encodings the caller can handle (e.g. "?accept-encoding=gzip,br"), contents
+stored in any other encoding, as reported by GetContentEncoding, are
+decoded transparently.
 */
 func OpenReader(ctx context.Context, fileurl *url.URL) (ReadCloser, error) {
 	var fs = GetImplementation(fileurl)
+	var cancel context.CancelFunc
 
 	if fs == nil {
 		return nil, ENOFS
 	}
 
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if fileurl.Query().Has(acceptEncodingParam) {
+		return openDecodingReader(ctx, fs, fileurl)
+	}
+
 	return fs.OpenReader(ctx, fileurl)
 }
 
@@ -151,11 +228,15 @@ whatsoever.
 */
 func OpenWriter(ctx context.Context, fileurl *url.URL) (WriteCloser, error) {
 	var fs = GetImplementation(fileurl)
+	var cancel context.CancelFunc
 
 	if fs == nil {
 		return nil, ENOFS
 	}
 
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
 	return fs.OpenWriter(ctx, fileurl)
 }
 
@@ -169,11 +250,15 @@ whatsoever.
 */
 func OpenAppender(ctx context.Context, fileurl *url.URL) (WriteCloser, error) {
 	var fs = GetImplementation(fileurl)
+	var cancel context.CancelFunc
 
 	if fs == nil {
 		return nil, ENOFS
 	}
 
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
 	return fs.OpenAppender(ctx, fileurl)
 }
 
@@ -184,11 +269,15 @@ contain special entries such as the local and parent directory.
 */
 func ListEntries(ctx context.Context, dirurl *url.URL) ([]string, error) {
 	var fs = GetImplementation(dirurl)
+	var cancel context.CancelFunc
 
 	if fs == nil {
 		return nil, ENOFS
 	}
 
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
 	return fs.ListEntries(ctx, dirurl)
 }
 
@@ -196,6 +285,11 @@ func ListEntries(ctx context.Context, dirurl *url.URL) ([]string, error) {
 WatchFile waits for modifications of the file at the specified URL and invokes
 the watcher with any modified files. Some implementations may allow
 watching directories.
+
+As watches are long lived, the default timeout set by SetDefaultTimeout is
+not applied to the context passed to WatchFile.
+
+Deprecated: Use WatchFileV2, which reports the kind of change as well.
 */
 func WatchFile(ctx context.Context, fileurl *url.URL, watcher FileWatchFunc) (
 	CancelWatchFunc, chan error, error) {
@@ -215,10 +309,14 @@ not have succeeded.
 */
 func Remove(ctx context.Context, fileurl *url.URL) error {
 	var fs = GetImplementation(fileurl)
+	var cancel context.CancelFunc
 
 	if fs == nil {
 		return ENOFS
 	}
 
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
 	return fs.Remove(ctx, fileurl)
 }
@@ -0,0 +1,194 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+type MockFileSystem struct {
+	Entries map[string][]string
+	Fail    bool
+}
+
+func (fs *MockFileSystem) OpenReader(ctx context.Context, u *url.URL) (ReadCloser, error) {
+	if fs.Fail {
+		return nil, ErrExpected
+	}
+	return &MockReadCloser{}, nil
+}
+
+func (fs *MockFileSystem) OpenWriter(ctx context.Context, u *url.URL) (WriteCloser, error) {
+	if fs.Fail {
+		return nil, ErrExpected
+	}
+	return &MockWriteCloser{}, nil
+}
+
+func (fs *MockFileSystem) OpenAppender(ctx context.Context, u *url.URL) (WriteCloser, error) {
+	return fs.OpenWriter(ctx, u)
+}
+
+func (fs *MockFileSystem) ListEntries(ctx context.Context, u *url.URL) ([]string, error) {
+	var entries []string
+	var ok bool
+
+	if fs.Fail {
+		return nil, ErrExpected
+	}
+	if entries, ok = fs.Entries[u.Path]; !ok {
+		return nil, ErrExpected
+	}
+	return entries, nil
+}
+
+func (fs *MockFileSystem) WatchFile(ctx context.Context, u *url.URL, f FileWatchFunc) (
+	CancelWatchFunc, chan error, error) {
+	return nil, nil, EUNSUPP
+}
+
+func (fs *MockFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	if fs.Fail {
+		return ErrExpected
+	}
+	return nil
+}
+
+func (fs *MockFileSystem) Stat(ctx context.Context, u *url.URL) (FileInfo, error) {
+	return nil, EUNSUPP
+}
+
+func mustParse(t testing.TB, rawurl string) *url.URL {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		t.Fatalf("Cannot parse URL %s: %v", rawurl, err)
+	}
+	return u
+}
+
+func TestOpenReaderWithoutImplementation(t *testing.T) {
+	_, err := OpenReader(context.Background(), mustParse(t, "nonexistent:///foo"))
+	if err != ENOFS {
+		t.Errorf("Unexpected error from OpenReader: %v", err)
+	}
+}
+
+func TestListEntriesDispatch(t *testing.T) {
+	AddImplementation("mock", &MockFileSystem{
+		Entries: map[string][]string{"/dir": {"a", "b"}},
+	})
+
+	entries, err := ListEntries(context.Background(), mustParse(t, "mock:///dir"))
+	if err != nil {
+		t.Fatalf("Error reported from ListEntries: %v", err)
+	}
+	if len(entries) != 2 || entries[0] != "a" || entries[1] != "b" {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+}
+
+func TestStatDispatch(t *testing.T) {
+	AddImplementation("mock", &MockFileSystem{})
+
+	if _, err := Stat(context.Background(), mustParse(t, "nonexistent:///foo")); err != ENOFS {
+		t.Errorf("Unexpected error from Stat without implementation: %v", err)
+	}
+	if _, err := Stat(context.Background(), mustParse(t, "mock:///foo")); err != EUNSUPP {
+		t.Errorf("Expected EUNSUPP from Stat, got %v", err)
+	}
+}
+
+type MultiSchemeMockFileSystem struct {
+	MockFileSystem
+}
+
+func (fs *MultiSchemeMockFileSystem) SupportedSchemes() []string {
+	return []string{"mock-a", "mock-b"}
+}
+
+func TestAddImplementationSupportedSchemes(t *testing.T) {
+	fs := &MultiSchemeMockFileSystem{}
+	AddImplementation("mock-main", fs)
+
+	for _, scheme := range []string{"mock-main", "mock-a", "mock-b"} {
+		if GetImplementation(&url.URL{Scheme: scheme}) != fs {
+			t.Errorf("File system not registered for scheme %s", scheme)
+		}
+	}
+}
+
+type RegistryConsultingMockFileSystem struct {
+	MockFileSystem
+}
+
+func (fs *RegistryConsultingMockFileSystem) SupportedSchemes() []string {
+	// Consulting the registry must not deadlock within AddImplementation.
+	HasImplementation("mock-fallback")
+	return []string{"mock-fallback"}
+}
+
+func TestAddImplementationSupportedSchemesUsingRegistry(t *testing.T) {
+	var done = make(chan struct{})
+	var fs = &RegistryConsultingMockFileSystem{}
+
+	go func() {
+		AddImplementation("mock-consulting", fs)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("AddImplementation deadlocked")
+	}
+
+	if GetImplementation(&url.URL{Scheme: "mock-fallback"}) != fs {
+		t.Error("File system not registered for scheme mock-fallback")
+	}
+}
+
+func TestConcurrentRegistration(t *testing.T) {
+	var wg sync.WaitGroup
+	u := mustParse(t, "concurrent:///file")
+
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			AddImplementation("concurrent", &MockFileSystem{})
+		}()
+		go func() {
+			defer wg.Done()
+			GetImplementation(u)
+			HasImplementation("concurrent")
+		}()
+	}
+	wg.Wait()
+
+	if !HasImplementation("concurrent") {
+		t.Error("Implementation not registered")
+	}
+}
+
+type NullFileSystem struct {
+	MockFileSystem
+}
+
+func (fs *NullFileSystem) OpenReader(ctx context.Context, u *url.URL) (ReadCloser, error) {
+	return NullReadCloser{}, nil
+}
+
+func BenchmarkDispatch(b *testing.B) {
+	ctx := context.Background()
+	u := mustParse(b, "null:///file")
+	AddImplementation("null", &NullFileSystem{})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := OpenReader(ctx, u); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+TaggingFileSystem is implemented by file systems which support object tags,
+i.e. mutable key/value labels attached to files, like S3 object tagging or
+Azure blob index tags. Unlike metadata, tags can be changed at any time and
+are usually evaluated by lifecycle policies and billing.
+*/
+type TaggingFileSystem interface {
+	// Set the specified tags on the file. Tags with other keys are left
+	// unchanged.
+	Tag(context.Context, *url.URL, map[string]string) error
+
+	// Retrieve all tags of the file.
+	GetTags(context.Context, *url.URL) (map[string]string, error)
+
+	// Remove the tags with the specified keys from the file.
+	Untag(context.Context, *url.URL, []string) error
+}
+
+/*
+getTaggingFileSystem determines the TaggingFileSystem responsible for the
+URL, or returns ENOFS or EUNSUPP if there is none.
+*/
+func getTaggingFileSystem(fileurl *url.URL) (TaggingFileSystem, error) {
+	var fs = GetImplementation(fileurl)
+	var tfs TaggingFileSystem
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if tfs, ok = fs.(TaggingFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	return tfs, nil
+}
+
+/*
+Tag sets the specified tags on the referenced file, replacing the values of
+existing tags with the same keys.
+*/
+func Tag(ctx context.Context, fileurl *url.URL, tags map[string]string) error {
+	var tfs TaggingFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if tfs, err = getTaggingFileSystem(fileurl); err != nil {
+		return err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return tfs.Tag(ctx, fileurl, tags)
+}
+
+/*
+GetTags retrieves all tags of the referenced file. This does not include
+its metadata; see GetMetadata.
+*/
+func GetTags(ctx context.Context, fileurl *url.URL) (map[string]string, error) {
+	var tfs TaggingFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if tfs, err = getTaggingFileSystem(fileurl); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return tfs.GetTags(ctx, fileurl)
+}
+
+/*
+Untag removes the tags with the specified keys from the referenced file.
+Keys which are not set are ignored.
+*/
+func Untag(ctx context.Context, fileurl *url.URL, keys []string) error {
+	var tfs TaggingFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if tfs, err = getTaggingFileSystem(fileurl); err != nil {
+		return err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return tfs.Untag(ctx, fileurl, keys)
+}
+
+/*
+TagListingFileSystem is implemented by file systems which can find files by
+their tags without inspecting every file, e.g. using a tag index.
+*/
+type TagListingFileSystem interface {
+	// List the paths of all files beneath the URL carrying all of the
+	// specified tags, relative to the URL.
+	ListByTag(context.Context, *url.URL, map[string]string) ([]string, error)
+}
+
+/*
+hasTags determines whether tags contains all key/value pairs of wanted.
+*/
+func hasTags(tags, wanted map[string]string) bool {
+	for k, v := range wanted {
+		if value, ok := tags[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+ListByTag returns the paths of all files beneath scope which carry all of
+the specified tags with the specified values, relative to scope and
+separated by slashes.
+
+File systems which implement TaggingFileSystem but not TagListingFileSystem
+are searched by listing all files using ListEntriesRecursive and retrieving
+the tags of every single one, which can be very slow for large trees.
+*/
+func ListByTag(ctx context.Context, scope *url.URL, tags map[string]string) (
+	[]string, error) {
+	var fs = GetImplementation(scope)
+	var cancel context.CancelFunc
+	var paths, matches []string
+	var ok bool
+	var err error
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if lfs, ok := fs.(TagListingFileSystem); ok {
+		ctx, cancel = withDefaultTimeout(ctx)
+		defer cancel()
+
+		return lfs.ListByTag(ctx, scope, tags)
+	}
+
+	if _, ok = fs.(TaggingFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	if paths, err = ListEntriesRecursive(ctx, scope); err != nil {
+		return nil, err
+	}
+
+	for _, p := range paths {
+		var fileTags map[string]string
+
+		if fileTags, err = GetTags(ctx, childURL(scope, p)); err != nil {
+			return nil, err
+		}
+		if hasTags(fileTags, tags) {
+			matches = append(matches, p)
+		}
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,37 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestListByTag(t *testing.T) {
+	filesystem.AddImplementation("tagged", virtualfs.NewVirtualFileSystem())
+
+	for name, owner := range map[string]string{
+		"a": "tenant-x", "sub/b": "tenant-x", "sub/c": "tenant-y", "d": ""} {
+		u := &url.URL{Scheme: "tagged", Path: "/scope/" + name}
+		writeTestFile(t, u, name)
+		if owner != "" {
+			if err := filesystem.Tag(context.Background(), u,
+				map[string]string{"owner": owner, "name": name}); err != nil {
+				t.Fatalf("Error reported from Tag: %v", err)
+			}
+		}
+	}
+
+	paths, err := filesystem.ListByTag(context.Background(),
+		&url.URL{Scheme: "tagged", Path: "/scope"},
+		map[string]string{"owner": "tenant-x"})
+	if err != nil {
+		t.Fatalf("Error reported from ListByTag: %v", err)
+	}
+	if strings.Join(paths, ",") != "a,sub/b" {
+		t.Errorf("Unexpected matches %v", paths)
+	}
+}
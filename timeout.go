@@ -0,0 +1,50 @@
+package filesystem
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Process wide default timeout for file system operations, in nanoseconds.
+*/
+var defaultTimeout atomic.Int64
+
+/*
+SetDefaultTimeout sets a process wide timeout which is applied to all file
+system operations dispatched through this package. If the context passed to
+an operation already has a deadline, that deadline is used instead.
+
+A timeout of 0 disables the default timeout.
+*/
+func SetDefaultTimeout(d time.Duration) {
+	defaultTimeout.Store(int64(d))
+}
+
+/*
+GetDefaultTimeout returns the currently configured default timeout, or 0 if
+no default timeout is set.
+*/
+func GetDefaultTimeout() time.Duration {
+	return time.Duration(defaultTimeout.Load())
+}
+
+/*
+withDefaultTimeout derives a context from ctx which is subject to the
+default timeout, if one is set and ctx has no deadline of its own. The
+returned cancel function must always be called once the operation is
+complete.
+*/
+func withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	var timeout = GetDefaultTimeout()
+
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,69 @@
+package filesystem
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithDefaultTimeoutAppliesDefault(t *testing.T) {
+	SetDefaultTimeout(time.Minute)
+	defer SetDefaultTimeout(0)
+
+	ctx, cancel := withDefaultTimeout(context.Background())
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("No deadline set on context")
+	}
+	if time.Until(deadline) > time.Minute {
+		t.Errorf("Deadline %v is later than the default timeout", deadline)
+	}
+}
+
+func TestWithDefaultTimeoutKeepsSoonerDeadline(t *testing.T) {
+	SetDefaultTimeout(time.Hour)
+	defer SetDefaultTimeout(0)
+
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Second)
+	defer parentCancel()
+	expected, _ := parent.Deadline()
+
+	ctx, cancel := withDefaultTimeout(parent)
+	defer cancel()
+
+	deadline, _ := ctx.Deadline()
+	if !deadline.Equal(expected) {
+		t.Errorf("Deadline %v does not match existing deadline %v",
+			deadline, expected)
+	}
+}
+
+func TestWithDefaultTimeoutKeepsLaterDeadline(t *testing.T) {
+	SetDefaultTimeout(time.Second)
+	defer SetDefaultTimeout(0)
+
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Hour)
+	defer parentCancel()
+
+	ctx, cancel := withDefaultTimeout(parent)
+	defer cancel()
+
+	if ctx != parent {
+		t.Error("Context with an existing deadline was wrapped")
+	}
+}
+
+func TestWithDefaultTimeoutDisabled(t *testing.T) {
+	if GetDefaultTimeout() != 0 {
+		t.Fatalf("Unexpected default timeout %v", GetDefaultTimeout())
+	}
+
+	ctx, cancel := withDefaultTimeout(context.Background())
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Deadline set without a default timeout")
+	}
+}
@@ -0,0 +1,75 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+/*
+TouchingFileSystem is implemented by file systems which can set the
+modification time of a file without altering its contents.
+*/
+type TouchingFileSystem interface {
+	// Create the file if it does not exist, and set its modification time
+	// to the specified time.
+	Touch(context.Context, *url.URL, time.Time) error
+}
+
+/*
+Touch creates the referenced file with empty contents if it does not exist,
+or updates its modification time to the current time otherwise. The
+contents of existing files are not altered.
+
+File systems which do not implement TouchingFileSystem are touched by
+opening the file for appending and closing it again without writing any
+data, which may or may not update the modification time.
+*/
+func Touch(ctx context.Context, fileurl *url.URL) error {
+	var fs = GetImplementation(fileurl)
+	var wc WriteCloser
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return ENOFS
+	}
+
+	if tfs, ok := fs.(TouchingFileSystem); ok {
+		ctx, cancel = withDefaultTimeout(ctx)
+		defer cancel()
+
+		return tfs.Touch(ctx, fileurl, time.Now())
+	}
+
+	if wc, err = OpenAppender(ctx, fileurl); err != nil {
+		return err
+	}
+
+	return wc.Close(ctx)
+}
+
+/*
+TouchWithTime creates the referenced file with empty contents if it does
+not exist, and sets its modification time to t. File systems which do not
+implement TouchingFileSystem will cause EUNSUPP to be returned.
+*/
+func TouchWithTime(ctx context.Context, fileurl *url.URL, t time.Time) error {
+	var fs = GetImplementation(fileurl)
+	var tfs TouchingFileSystem
+	var cancel context.CancelFunc
+	var ok bool
+
+	if fs == nil {
+		return ENOFS
+	}
+
+	if tfs, ok = fs.(TouchingFileSystem); !ok {
+		return EUNSUPP
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return tfs.Touch(ctx, fileurl, t)
+}
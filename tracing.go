@@ -0,0 +1,29 @@
+package filesystem
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*
+WithSpanContext embeds the given trace span context into the context so that
+file system operations invoked with the resulting context can be correlated
+with the distributed trace they are a part of.
+
+File system implementations and wrappers can retrieve the span context using
+SpanContextFromContext.
+*/
+func WithSpanContext(ctx context.Context, spanCtx trace.SpanContext) context.Context {
+	return trace.ContextWithSpanContext(ctx, spanCtx)
+}
+
+/*
+SpanContextFromContext retrieves the trace span context embedded into the
+context by WithSpanContext (or any other OpenTelemetry instrumentation).
+If no span context is present, the returned span context will not be valid,
+which can be checked using its IsValid() method.
+*/
+func SpanContextFromContext(ctx context.Context) trace.SpanContext {
+	return trace.SpanContextFromContext(ctx)
+}
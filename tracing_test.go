@@ -0,0 +1,53 @@
+package filesystem_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func testSpanContext() trace.SpanContext {
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03},
+		SpanID:     trace.SpanID{0x04, 0x05},
+		TraceFlags: trace.FlagsSampled,
+	})
+}
+
+func TestSpanContextRoundTrip(t *testing.T) {
+	spanCtx := testSpanContext()
+
+	if filesystem.SpanContextFromContext(context.Background()).IsValid() {
+		t.Error("Valid span context found in empty context")
+	}
+
+	ctx := filesystem.WithSpanContext(context.Background(), spanCtx)
+	if got := filesystem.SpanContextFromContext(ctx); !got.Equal(spanCtx) {
+		t.Errorf("Unexpected span context %v, expected %v", got, spanCtx)
+	}
+}
+
+func TestDiagnosticsRecordsSpanContext(t *testing.T) {
+	var log bytes.Buffer
+	var rec filesystem.DiagnosticsRecord
+	spanCtx := testSpanContext()
+
+	fs := filesystem.NewDiagnosticsFileSystem(
+		virtualfs.NewVirtualFileSystem(), &log)
+	fs.Remove(filesystem.WithSpanContext(context.Background(), spanCtx),
+		&url.URL{Scheme: "memory", Path: "/file"})
+
+	if err := json.Unmarshal(log.Bytes(), &rec); err != nil {
+		t.Fatalf("Cannot decode record %q: %v", log.String(), err)
+	}
+	if rec.TraceID != spanCtx.TraceID().String() ||
+		rec.SpanID != spanCtx.SpanID().String() {
+		t.Errorf("Unexpected trace %s and span %s", rec.TraceID, rec.SpanID)
+	}
+}
@@ -0,0 +1,37 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+/*
+OpenTypedReader opens the referenced file, decodes its contents using
+decoder, closes the file and returns the decoded value. The decoder chooses
+the format, e.g. JSON or Protocol Buffers, which makes this a codec agnostic
+alternative to SchemaRegistry.ReadAs.
+
+Errors opening, decoding or closing the file mention its URL.
+*/
+func OpenTypedReader[T any](ctx context.Context, fileurl *url.URL,
+	decoder func(ReadCloser) (T, error)) (T, error) {
+	var zero, value T
+	var rc ReadCloser
+	var err error
+
+	if rc, err = OpenReader(ctx, fileurl); err != nil {
+		return zero, fmt.Errorf("Cannot open %s: %w", fileurl, err)
+	}
+
+	if value, err = decoder(NewContextReadCloser(rc, ctx)); err != nil {
+		rc.Close(ctx)
+		return zero, fmt.Errorf("Cannot decode %s: %w", fileurl, err)
+	}
+
+	if err = rc.Close(ctx); err != nil {
+		return zero, fmt.Errorf("Cannot close %s: %w", fileurl, err)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,41 @@
+package filesystem_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestOpenTypedReader(t *testing.T) {
+	type config struct {
+		Name string `json:"name"`
+	}
+	decode := func(rc filesystem.ReadCloser) (config, error) {
+		var c config
+		err := json.NewDecoder(filesystem.ToIoReadCloser(rc)).Decode(&c)
+		return c, err
+	}
+
+	filesystem.AddImplementation("typed", virtualfs.NewVirtualFileSystem())
+	u := &url.URL{Scheme: "typed", Path: "/config.json"}
+	writeTestFile(t, u, `{"name": "test"}`)
+
+	c, err := filesystem.OpenTypedReader(context.Background(), u, decode)
+	if err != nil {
+		t.Fatalf("Error reported from OpenTypedReader: %v", err)
+	}
+	if c.Name != "test" {
+		t.Errorf("Unexpected decoded value %q", c.Name)
+	}
+
+	writeTestFile(t, u, "not json")
+	_, err = filesystem.OpenTypedReader(context.Background(), u, decode)
+	if err == nil || !strings.Contains(err.Error(), u.String()) {
+		t.Errorf("Unexpected error for invalid data: %v", err)
+	}
+}
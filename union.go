@@ -0,0 +1,157 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/url"
+	"sort"
+)
+
+/*
+FileSystem which merges the namespaces of several file systems.
+*/
+type unionFileSystem struct {
+	primary  FileSystem
+	backends []FileSystem
+}
+
+/*
+NewUnionFileSystem creates a FileSystem presenting the merged contents of
+primary and all secondaries. Directory listings contain the entries of all
+file systems, and reads are served by the first file system (in the order
+given, primary first) which has the file. Writes, removals and watches
+always go to primary.
+
+Unlike an overlay, which masks the contents of lower layers, a union shows
+everything contained in any of its file systems.
+*/
+func NewUnionFileSystem(primary FileSystem, secondaries ...FileSystem) FileSystem {
+	var backends = append([]FileSystem{primary}, secondaries...)
+	return &unionFileSystem{primary: primary, backends: backends}
+}
+
+/*
+OpenReader opens the file from the first file system which has it.
+*/
+func (u *unionFileSystem) OpenReader(ctx context.Context, fileurl *url.URL) (
+	ReadCloser, error) {
+	var firstErr error
+
+	for _, backend := range u.backends {
+		var rc, err = backend.OpenReader(ctx, fileurl)
+
+		if err == nil {
+			return rc, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return nil, firstErr
+}
+
+/*
+OpenWriter opens the file for writing in the primary file system.
+*/
+func (u *unionFileSystem) OpenWriter(ctx context.Context, fileurl *url.URL) (
+	WriteCloser, error) {
+	return u.primary.OpenWriter(ctx, fileurl)
+}
+
+/*
+OpenAppender opens the file for appending in the primary file system.
+*/
+func (u *unionFileSystem) OpenAppender(ctx context.Context, fileurl *url.URL) (
+	WriteCloser, error) {
+	return u.primary.OpenAppender(ctx, fileurl)
+}
+
+/*
+ListEntries returns the sorted, de-duplicated entries of the directory in
+all file systems. File systems which do not have the directory are
+skipped; if none of them has it, the error of the primary is returned.
+*/
+func (u *unionFileSystem) ListEntries(ctx context.Context, dirurl *url.URL) (
+	[]string, error) {
+	var seen = make(map[string]bool)
+	var names []string
+	var firstErr error
+	var found bool
+
+	for _, backend := range u.backends {
+		var entries, err = backend.ListEntries(ctx, dirurl)
+
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		found = true
+		for _, entry := range entries {
+			if !seen[entry] {
+				seen[entry] = true
+				names = append(names, entry)
+			}
+		}
+	}
+
+	if !found {
+		return nil, firstErr
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+/*
+WatchFile watches the file in the primary file system.
+*/
+func (u *unionFileSystem) WatchFile(ctx context.Context, fileurl *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	return u.primary.WatchFile(ctx, fileurl, watcher)
+}
+
+/*
+Remove deletes the file from the primary file system.
+*/
+func (u *unionFileSystem) Remove(ctx context.Context, fileurl *url.URL) error {
+	return u.primary.Remove(ctx, fileurl)
+}
+
+/*
+Stat retrieves the metadata of the file from the first file system which
+has it. File systems which do not support Stat are skipped.
+*/
+func (u *unionFileSystem) Stat(ctx context.Context, fileurl *url.URL) (
+	FileInfo, error) {
+	var firstErr error = EUNSUPP
+
+	for _, backend := range u.backends {
+		var fi, err = backend.Stat(ctx, fileurl)
+
+		if err == nil {
+			return fi, nil
+		}
+		if err == EUNSUPP {
+			continue
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if firstErr == EUNSUPP {
+			firstErr = err
+		}
+	}
+
+	return nil, firstErr
+}
@@ -0,0 +1,43 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestUnionFileSystem(t *testing.T) {
+	a := virtualfs.NewVirtualFileSystem()
+	b := virtualfs.NewVirtualFileSystem()
+	filesystem.AddImplementation("uniona", a)
+	filesystem.AddImplementation("unionb", b)
+	filesystem.AddImplementation("union", filesystem.NewUnionFileSystem(a, b))
+
+	writeTestFile(t, &url.URL{Scheme: "uniona", Path: "/dir/x"}, "a/x")
+	writeTestFile(t, &url.URL{Scheme: "uniona", Path: "/dir/y"}, "a/y")
+	writeTestFile(t, &url.URL{Scheme: "unionb", Path: "/dir/y"}, "b/y")
+	writeTestFile(t, &url.URL{Scheme: "unionb", Path: "/dir/z"}, "b/z")
+
+	entries, err := filesystem.ListEntries(context.Background(),
+		&url.URL{Scheme: "union", Path: "/dir"})
+	if err != nil {
+		t.Fatalf("Error reported from ListEntries: %v", err)
+	}
+	if len(entries) != 3 || entries[0] != "x" || entries[1] != "y" ||
+		entries[2] != "z" {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+
+	for path, expected := range map[string]string{
+		"/dir/y": "a/y",
+		"/dir/z": "b/z",
+	} {
+		data, err := readTestFile(t, &url.URL{Scheme: "union", Path: path})
+		if err != nil || data != expected {
+			t.Errorf("Unexpected contents of %s: %q (%v)", path, data, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+/*
+UploadPolicyOptions restricts the uploads permitted by an UploadPolicy.
+*/
+type UploadPolicyOptions struct {
+	// Maximum size of the uploaded file in bytes, or 0 for no limit.
+	MaxSizeBytes int64
+
+	// Content types the uploaded file may have. Any content type is
+	// permitted if the list is empty.
+	AllowedContentTypes []string
+
+	// Duration for which the policy is valid.
+	ExpiresIn time.Duration
+}
+
+/*
+UploadPolicy describes how a client, usually a web browser, can upload a
+file directly to the storage backend without holding credentials for it.
+*/
+type UploadPolicy struct {
+	// URL the upload has to be sent to.
+	URL string
+
+	// Form fields which must be included in the upload request, such as
+	// the signed policy document.
+	Fields map[string]string
+
+	// HTTP method to use for the upload, e.g. "POST" or "PUT".
+	Method string
+
+	// Time after which the policy can no longer be used.
+	ExpiresAt time.Time
+}
+
+/*
+UploadPolicyFileSystem is implemented by file systems which can delegate
+uploads of individual files to clients, such as S3 using POST policies or
+GCS using signed resumable upload URLs.
+*/
+type UploadPolicyFileSystem interface {
+	// Create a policy permitting a client to upload the file.
+	GenerateUploadPolicy(context.Context, *url.URL, UploadPolicyOptions) (UploadPolicy, error)
+}
+
+/*
+GenerateUploadPolicy creates a policy allowing a client to upload the
+referenced file directly to the storage backend, within the restrictions
+of opts. File systems which do not implement UploadPolicyFileSystem, which
+includes all non-cloud ones, will cause EUNSUPP to be returned.
+*/
+func GenerateUploadPolicy(ctx context.Context, fileurl *url.URL,
+	opts UploadPolicyOptions) (UploadPolicy, error) {
+	var fs = GetImplementation(fileurl)
+	var ufs UploadPolicyFileSystem
+	var cancel context.CancelFunc
+	var ok bool
+
+	if fs == nil {
+		return UploadPolicy{}, ENOFS
+	}
+
+	if ufs, ok = fs.(UploadPolicyFileSystem); !ok {
+		return UploadPolicy{}, EUNSUPP
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return ufs.GenerateUploadPolicy(ctx, fileurl, opts)
+}
@@ -0,0 +1,58 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+/*
+uploadPolicyFileSystem hands out policies for a fictitious upload endpoint.
+*/
+type uploadPolicyFileSystem struct {
+	*virtualfs.VirtualFileSystem
+}
+
+func (u uploadPolicyFileSystem) GenerateUploadPolicy(ctx context.Context,
+	fileurl *url.URL, opts filesystem.UploadPolicyOptions) (
+	filesystem.UploadPolicy, error) {
+	return filesystem.UploadPolicy{
+		URL:       "https://upload.example.com" + fileurl.Path,
+		Fields:    map[string]string{"max-size": strconv.FormatInt(opts.MaxSizeBytes, 10)},
+		Method:    "PUT",
+		ExpiresAt: time.Unix(0, 0).Add(opts.ExpiresIn),
+	}, nil
+}
+
+func TestGenerateUploadPolicy(t *testing.T) {
+	filesystem.AddImplementation("uploadpolicyplain", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("uploadpolicy", uploadPolicyFileSystem{
+		virtualfs.NewVirtualFileSystem()})
+	opts := filesystem.UploadPolicyOptions{MaxSizeBytes: 1024, ExpiresIn: time.Hour}
+	ctx := context.Background()
+
+	if _, err := filesystem.GenerateUploadPolicy(ctx, &url.URL{
+		Scheme: "uploadpolicyunregistered", Path: "/file"}, opts); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+	if _, err := filesystem.GenerateUploadPolicy(ctx, &url.URL{
+		Scheme: "uploadpolicyplain", Path: "/file"}, opts); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP without support, got %v", err)
+	}
+
+	policy, err := filesystem.GenerateUploadPolicy(ctx, &url.URL{
+		Scheme: "uploadpolicy", Path: "/file"}, opts)
+	if err != nil {
+		t.Fatalf("Error reported from GenerateUploadPolicy: %v", err)
+	}
+	if policy.URL != "https://upload.example.com/file" ||
+		policy.Fields["max-size"] != "1024" ||
+		!policy.ExpiresAt.Equal(time.Unix(3600, 0)) {
+		t.Errorf("Unexpected policy %+v", policy)
+	}
+}
@@ -0,0 +1,108 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+VersionAuditingFileSystem is implemented by file systems which keep
+previous versions of files, such as versioned S3 buckets or GCS buckets
+with object versioning enabled.
+*/
+type VersionAuditingFileSystem interface {
+	// Count the versions of the file, including the current one.
+	GetVersionCount(context.Context, *url.URL) (int64, error)
+
+	// Sum up the sizes of all versions of the file in bytes.
+	GetVersionSize(context.Context, *url.URL) (int64, error)
+
+	// Delete all but the specified number of most recent versions of the
+	// file and return the number of versions deleted.
+	PruneVersions(context.Context, *url.URL, int) (int, error)
+}
+
+/*
+getVersionAuditingFileSystem determines the VersionAuditingFileSystem
+responsible for the URL, or returns ENOFS or EUNSUPP if there is none.
+*/
+func getVersionAuditingFileSystem(fileurl *url.URL) (
+	VersionAuditingFileSystem, error) {
+	var fs = GetImplementation(fileurl)
+	var vfs VersionAuditingFileSystem
+	var ok bool
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if vfs, ok = fs.(VersionAuditingFileSystem); !ok {
+		return nil, EUNSUPP
+	}
+
+	return vfs, nil
+}
+
+/*
+GetVersionCount returns the number of versions of the referenced file kept
+by the file system, including the current one. File systems without
+versioning return EUNSUPP.
+*/
+func GetVersionCount(ctx context.Context, fileurl *url.URL) (int64, error) {
+	var vfs VersionAuditingFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if vfs, err = getVersionAuditingFileSystem(fileurl); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return vfs.GetVersionCount(ctx, fileurl)
+}
+
+/*
+GetVersionSize returns the storage consumed by all versions of the
+referenced file in bytes. File systems without versioning return EUNSUPP.
+*/
+func GetVersionSize(ctx context.Context, fileurl *url.URL) (int64, error) {
+	var vfs VersionAuditingFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if vfs, err = getVersionAuditingFileSystem(fileurl); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return vfs.GetVersionSize(ctx, fileurl)
+}
+
+/*
+PruneVersions deletes all versions of the referenced file except for the
+keepLast most recent ones, and returns the number of versions deleted. The
+current version is always kept, even if keepLast is 0. File systems without
+versioning return EUNSUPP.
+*/
+func PruneVersions(ctx context.Context, fileurl *url.URL, keepLast int) (int, error) {
+	var vfs VersionAuditingFileSystem
+	var cancel context.CancelFunc
+	var err error
+
+	if vfs, err = getVersionAuditingFileSystem(fileurl); err != nil {
+		return 0, err
+	}
+
+	if keepLast < 1 {
+		keepLast = 1
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	return vfs.PruneVersions(ctx, fileurl, keepLast)
+}
@@ -0,0 +1,115 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+/*
+versioningFileSystem remembers the sizes of the previous versions of every
+file replaced using OpenWriter, oldest first.
+*/
+type versioningFileSystem struct {
+	*virtualfs.VirtualFileSystem
+	lock     sync.Mutex
+	previous map[string][]int64
+}
+
+func (v *versioningFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	filesystem.WriteCloser, error) {
+	if fi, err := v.Stat(ctx, u); err == nil {
+		v.lock.Lock()
+		v.previous[u.Path] = append(v.previous[u.Path], fi.Size())
+		v.lock.Unlock()
+	}
+	return v.VirtualFileSystem.OpenWriter(ctx, u)
+}
+
+func (v *versioningFileSystem) GetVersionCount(ctx context.Context,
+	u *url.URL) (int64, error) {
+	if _, err := v.Stat(ctx, u); err != nil {
+		return 0, err
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return int64(len(v.previous[u.Path])) + 1, nil
+}
+
+func (v *versioningFileSystem) GetVersionSize(ctx context.Context,
+	u *url.URL) (int64, error) {
+	fi, err := v.Stat(ctx, u)
+	if err != nil {
+		return 0, err
+	}
+	total := fi.Size()
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	for _, size := range v.previous[u.Path] {
+		total += size
+	}
+	return total, nil
+}
+
+func (v *versioningFileSystem) PruneVersions(ctx context.Context,
+	u *url.URL, keepLast int) (int, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	previous := v.previous[u.Path]
+	pruned := len(previous) - (keepLast - 1)
+	if pruned <= 0 {
+		return 0, nil
+	}
+	v.previous[u.Path] = previous[pruned:]
+	return pruned, nil
+}
+
+func TestVersions(t *testing.T) {
+	filesystem.AddImplementation("versionsplain", virtualfs.NewVirtualFileSystem())
+	filesystem.AddImplementation("versions", &versioningFileSystem{
+		VirtualFileSystem: virtualfs.NewVirtualFileSystem(),
+		previous:          make(map[string][]int64),
+	})
+	u := &url.URL{Scheme: "versions", Path: "/file"}
+	plain := &url.URL{Scheme: "versionsplain", Path: "/file"}
+	ctx := context.Background()
+
+	if _, err := filesystem.GetVersionCount(ctx, &url.URL{
+		Scheme: "versionsunregistered", Path: "/file"}); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+	writeTestFile(t, plain, "unversioned")
+	if _, err := filesystem.GetVersionSize(ctx, plain); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP from GetVersionSize, got %v", err)
+	}
+	if _, err := filesystem.PruneVersions(ctx, plain, 1); err != filesystem.EUNSUPP {
+		t.Errorf("Expected EUNSUPP from PruneVersions, got %v", err)
+	}
+
+	writeTestFile(t, u, "a")
+	writeTestFile(t, u, "bb")
+	writeTestFile(t, u, "ccc")
+
+	if n, err := filesystem.GetVersionCount(ctx, u); err != nil || n != 3 {
+		t.Errorf("Unexpected version count %d (%v)", n, err)
+	}
+	if n, err := filesystem.GetVersionSize(ctx, u); err != nil || n != 6 {
+		t.Errorf("Unexpected version size %d (%v)", n, err)
+	}
+
+	// The current version must be kept even when asked to keep none.
+	if pruned, err := filesystem.PruneVersions(ctx, u, 0); err != nil ||
+		pruned != 2 {
+		t.Errorf("Unexpected number of pruned versions %d (%v)", pruned, err)
+	}
+	if n, err := filesystem.GetVersionCount(ctx, u); err != nil || n != 1 {
+		t.Errorf("Unexpected version count %d after pruning (%v)", n, err)
+	}
+	if data, _ := readTestFile(t, u); data != "ccc" {
+		t.Errorf("Unexpected contents %q after pruning", data)
+	}
+}
@@ -0,0 +1,720 @@
+/*
+Package virtualfs provides a file system which keeps all of its contents in
+memory. It is mostly useful for tests and benchmarks, and safe for
+concurrent use.
+
+Only the path component of URLs is used to identify files. Directories do
+not exist on their own; they are implied by the files stored beneath them.
+*/
+package virtualfs
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"maps"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+)
+
+/*
+A single file stored in the virtual file system.
+*/
+type virtualFile struct {
+	data      []byte
+	modTime   time.Time
+	expiresAt time.Time
+	encoding  string
+	metadata  map[string]string
+	tags      map[string]string
+}
+
+/*
+A registered watcher for a file.
+*/
+type watch struct {
+	fileurl *url.URL
+	watcher filesystem.FileWatchFunc
+}
+
+/*
+VirtualFileSystem is an in-memory implementation of the
+filesystem.FileSystem API.
+*/
+type VirtualFileSystem struct {
+	lock    sync.RWMutex
+	files   map[string]*virtualFile
+	watches map[string]map[*watch]struct{}
+}
+
+/*
+NewVirtualFileSystem creates a new, empty VirtualFileSystem.
+*/
+func NewVirtualFileSystem() *VirtualFileSystem {
+	return &VirtualFileSystem{
+		files:   make(map[string]*virtualFile),
+		watches: make(map[string]map[*watch]struct{}),
+	}
+}
+
+/*
+Reset removes all files and watches from the file system.
+*/
+func (v *VirtualFileSystem) Reset() {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.files = make(map[string]*virtualFile)
+	v.watches = make(map[string]map[*watch]struct{})
+}
+
+/*
+Determine the key under which the file referenced by the URL is stored.
+*/
+func key(u *url.URL) string {
+	return path.Clean("/" + u.Path)
+}
+
+/*
+Reader for a snapshot of the contents of a file.
+*/
+type reader struct {
+	data []byte
+	pos  int
+}
+
+/*
+Read copies the next part of the file contents into p.
+*/
+func (r *reader) Read(ctx context.Context, p []byte) (int, error) {
+	var n int
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if r.pos >= len(r.data) {
+		return 0, io.EOF
+	}
+
+	n = copy(p, r.data[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+/*
+Close does nothing.
+*/
+func (r *reader) Close(ctx context.Context) error {
+	return nil
+}
+
+/*
+Writer which appends directly to a file.
+*/
+type writer struct {
+	fs      *VirtualFileSystem
+	fileurl *url.URL
+	name    string
+}
+
+/*
+Write appends p to the file.
+*/
+func (w *writer) Write(ctx context.Context, p []byte) (int, error) {
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	w.fs.lock.Lock()
+	defer w.fs.lock.Unlock()
+
+	if f, ok = w.fs.files[w.name]; !ok {
+		// The file was removed in the meantime; recreate it.
+		f = &virtualFile{}
+		w.fs.files[w.name] = f
+	}
+
+	// Readers and links only ever see data up to their own length, so
+	// appending into the spare capacity is safe.
+	f.data = append(f.data, p...)
+	f.modTime = time.Now()
+
+	return len(p), nil
+}
+
+/*
+Close notifies all watchers of the file about the modification.
+*/
+func (w *writer) Close(ctx context.Context) error {
+	w.fs.notify(w.fileurl, w.name)
+	return nil
+}
+
+/*
+notify invokes all watchers registered for the named file.
+*/
+func (v *VirtualFileSystem) notify(fileurl *url.URL, name string) {
+	var watches []*watch
+	var data []byte
+
+	v.lock.RLock()
+	for w := range v.watches[name] {
+		watches = append(watches, w)
+	}
+	if f, ok := v.files[name]; ok {
+		data = f.data
+	}
+	v.lock.RUnlock()
+
+	for _, w := range watches {
+		go w.watcher(fileurl, &reader{data: data})
+	}
+}
+
+/*
+OpenReader opens a snapshot of the current contents of the file for reading.
+*/
+func (v *VirtualFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	filesystem.ReadCloser, error) {
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	if f, ok = v.files[key(u)]; !ok {
+		return nil, &fs.PathError{Op: "open", Path: key(u), Err: fs.ErrNotExist}
+	}
+	if !f.expiresAt.IsZero() && !time.Now().Before(f.expiresAt) {
+		return nil, filesystem.ErrExpired
+	}
+
+	return &reader{data: f.data}, nil
+}
+
+/*
+openWriter creates a writer for the file, truncating it if requested.
+*/
+func (v *VirtualFileSystem) openWriter(ctx context.Context, u *url.URL,
+	truncate bool) (filesystem.WriteCloser, error) {
+	var name = key(u)
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if f, ok = v.files[name]; !ok || truncate {
+		f = &virtualFile{modTime: time.Now()}
+		v.files[name] = f
+	}
+
+	return &writer{fs: v, fileurl: u, name: name}, nil
+}
+
+/*
+OpenWriter creates or truncates the file and opens it for writing.
+*/
+func (v *VirtualFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	filesystem.WriteCloser, error) {
+	return v.openWriter(ctx, u, true)
+}
+
+/*
+OpenAppender opens the file for appending, creating it if necessary.
+*/
+func (v *VirtualFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	filesystem.WriteCloser, error) {
+	return v.openWriter(ctx, u, false)
+}
+
+/*
+OpenWriterExclusive creates the file and opens it for writing, failing with
+filesystem.ErrAlreadyExists if it exists already.
+*/
+func (v *VirtualFileSystem) OpenWriterExclusive(ctx context.Context, u *url.URL) (
+	filesystem.WriteCloser, error) {
+	var name = key(u)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if _, ok := v.files[name]; ok {
+		return nil, filesystem.ErrAlreadyExists
+	}
+	v.files[name] = &virtualFile{modTime: time.Now()}
+
+	return &writer{fs: v, fileurl: u, name: name}, nil
+}
+
+/*
+OpenAppenderExclusive creates the file and opens it for appending, failing
+with filesystem.ErrAlreadyExists if it exists already. Since the file is
+new, this is equivalent to OpenWriterExclusive.
+*/
+func (v *VirtualFileSystem) OpenAppenderExclusive(ctx context.Context, u *url.URL) (
+	filesystem.WriteCloser, error) {
+	return v.OpenWriterExclusive(ctx, u)
+}
+
+/*
+ListEntries lists the names of all files and implied directories directly
+beneath the URL, in lexical order. If nothing is stored beneath the URL, an
+error matching fs.ErrNotExist is returned, unless it refers to the root.
+*/
+func (v *VirtualFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	var prefix = key(u)
+	var seen = make(map[string]bool)
+	var names []string
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	for name := range v.files {
+		var entry string
+
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		entry, _, _ = strings.Cut(name[len(prefix):], "/")
+		if !seen[entry] {
+			seen[entry] = true
+			names = append(names, entry)
+		}
+	}
+
+	if len(names) == 0 && prefix != "/" {
+		return nil, &fs.PathError{Op: "readdir", Path: key(u), Err: fs.ErrNotExist}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+/*
+WatchFile invokes the watcher every time a writer for the file is closed.
+*/
+func (v *VirtualFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher filesystem.FileWatchFunc) (
+	filesystem.CancelWatchFunc, chan error, error) {
+	var name = key(u)
+	var w = &watch{fileurl: u, watcher: watcher}
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.watches[name] == nil {
+		v.watches[name] = make(map[*watch]struct{})
+	}
+	v.watches[name][w] = struct{}{}
+
+	return func() error {
+		v.lock.Lock()
+		defer v.lock.Unlock()
+		delete(v.watches[name], w)
+		return nil
+	}, make(chan error), nil
+}
+
+/*
+Remove deletes the file.
+*/
+func (v *VirtualFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	var name = key(u)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if _, ok := v.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(v.files, name)
+
+	return nil
+}
+
+/*
+Metadata about a virtual file or directory.
+*/
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.isDir }
+
+func (fi *fileInfo) Mode() fs.FileMode {
+	if fi.isDir {
+		return fs.ModeDir | 0777
+	}
+	return 0666
+}
+
+/*
+Stat retrieves metadata about the file or implied directory.
+*/
+func (v *VirtualFileSystem) Stat(ctx context.Context, u *url.URL) (
+	filesystem.FileInfo, error) {
+	var name = key(u)
+	var prefix = strings.TrimSuffix(name, "/") + "/"
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	if f, ok := v.files[name]; ok {
+		return &fileInfo{
+			name:    path.Base(name),
+			size:    int64(len(f.data)),
+			modTime: f.modTime,
+		}, nil
+	}
+
+	for other := range v.files {
+		if strings.HasPrefix(other, prefix) {
+			return &fileInfo{name: path.Base(name), isDir: true}, nil
+		}
+	}
+
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+/*
+Touch creates the file if it does not exist and sets its modification time
+to t.
+*/
+func (v *VirtualFileSystem) Touch(ctx context.Context, u *url.URL, t time.Time) error {
+	var name = key(u)
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if f, ok = v.files[name]; !ok {
+		f = &virtualFile{}
+		v.files[name] = f
+	}
+	f.modTime = t
+
+	return nil
+}
+
+/*
+CreateLink makes dst share the contents of src. Since file contents are
+never modified in place, subsequent writes to either file do not affect the
+other one.
+*/
+func (v *VirtualFileSystem) CreateLink(ctx context.Context, src, dst *url.URL) error {
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if f, ok = v.files[key(src)]; !ok {
+		return &fs.PathError{Op: "link", Path: key(src), Err: fs.ErrNotExist}
+	}
+	// Clip the capacity so appending to either file never affects the other.
+	v.files[key(dst)] = &virtualFile{
+		data:    f.data[:len(f.data):len(f.data)],
+		modTime: time.Now(),
+	}
+
+	return nil
+}
+
+/*
+Move atomically renames the file.
+*/
+func (v *VirtualFileSystem) Move(ctx context.Context, src, dst *url.URL) error {
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if f, ok = v.files[key(src)]; !ok {
+		return &fs.PathError{Op: "move", Path: key(src), Err: fs.ErrNotExist}
+	}
+	delete(v.files, key(src))
+	v.files[key(dst)] = f
+
+	return nil
+}
+
+/*
+SetExpiry sets the time after which the file can no longer be opened for
+reading. Expired files are not removed automatically.
+*/
+func (v *VirtualFileSystem) SetExpiry(ctx context.Context, u *url.URL,
+	expiresAt time.Time) error {
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if f, ok = v.files[key(u)]; !ok {
+		return &fs.PathError{Op: "setexpiry", Path: key(u), Err: fs.ErrNotExist}
+	}
+	f.expiresAt = expiresAt
+
+	return nil
+}
+
+/*
+GetExpiry returns the expiration time of the file, or the zero time if it
+does not expire.
+*/
+func (v *VirtualFileSystem) GetExpiry(ctx context.Context, u *url.URL) (
+	time.Time, error) {
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	if f, ok = v.files[key(u)]; !ok {
+		return time.Time{}, &fs.PathError{
+			Op: "getexpiry", Path: key(u), Err: fs.ErrNotExist}
+	}
+
+	return f.expiresAt, nil
+}
+
+/*
+SetContentEncoding records the content encoding of the file.
+*/
+func (v *VirtualFileSystem) SetContentEncoding(ctx context.Context, u *url.URL,
+	encoding string) error {
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if f, ok = v.files[key(u)]; !ok {
+		return &fs.PathError{
+			Op: "setcontentencoding", Path: key(u), Err: fs.ErrNotExist}
+	}
+	f.encoding = encoding
+
+	return nil
+}
+
+/*
+GetContentEncoding returns the content encoding recorded for the file.
+*/
+func (v *VirtualFileSystem) GetContentEncoding(ctx context.Context, u *url.URL) (
+	string, error) {
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	if f, ok = v.files[key(u)]; !ok {
+		return "", &fs.PathError{
+			Op: "getcontentencoding", Path: key(u), Err: fs.ErrNotExist}
+	}
+
+	return f.encoding, nil
+}
+
+/*
+GetMetadata returns a copy of the metadata stored for the file.
+*/
+func (v *VirtualFileSystem) GetMetadata(ctx context.Context, u *url.URL) (
+	map[string]string, error) {
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	if f, ok = v.files[key(u)]; !ok {
+		return nil, &fs.PathError{Op: "getmetadata", Path: key(u), Err: fs.ErrNotExist}
+	}
+
+	return maps.Clone(f.metadata), nil
+}
+
+/*
+SetMetadata stores the metadata for the file, preserving other keys.
+*/
+func (v *VirtualFileSystem) SetMetadata(ctx context.Context, u *url.URL,
+	metadata map[string]string) error {
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if f, ok = v.files[key(u)]; !ok {
+		return &fs.PathError{Op: "setmetadata", Path: key(u), Err: fs.ErrNotExist}
+	}
+	if f.metadata == nil {
+		f.metadata = make(map[string]string)
+	}
+	maps.Copy(f.metadata, metadata)
+
+	return nil
+}
+
+/*
+Tag sets the tags on the file, preserving tags with other keys.
+*/
+func (v *VirtualFileSystem) Tag(ctx context.Context, u *url.URL,
+	tags map[string]string) error {
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if f, ok = v.files[key(u)]; !ok {
+		return &fs.PathError{Op: "tag", Path: key(u), Err: fs.ErrNotExist}
+	}
+	if f.tags == nil {
+		f.tags = make(map[string]string)
+	}
+	maps.Copy(f.tags, tags)
+
+	return nil
+}
+
+/*
+GetTags returns a copy of the tags of the file.
+*/
+func (v *VirtualFileSystem) GetTags(ctx context.Context, u *url.URL) (
+	map[string]string, error) {
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	if f, ok = v.files[key(u)]; !ok {
+		return nil, &fs.PathError{Op: "gettags", Path: key(u), Err: fs.ErrNotExist}
+	}
+
+	return maps.Clone(f.tags), nil
+}
+
+/*
+Untag removes the tags with the specified keys from the file.
+*/
+func (v *VirtualFileSystem) Untag(ctx context.Context, u *url.URL,
+	keys []string) error {
+	var f *virtualFile
+	var ok bool
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if f, ok = v.files[key(u)]; !ok {
+		return &fs.PathError{Op: "untag", Path: key(u), Err: fs.ErrNotExist}
+	}
+	for _, k := range keys {
+		delete(f.tags, k)
+	}
+
+	return nil
+}
@@ -0,0 +1,268 @@
+package virtualfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/fstest"
+)
+
+func mustParse(t *testing.T, rawurl string) *url.URL {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		t.Fatalf("Cannot parse URL %s: %v", rawurl, err)
+	}
+	return u
+}
+
+func writeFile(t *testing.T, v *VirtualFileSystem, u *url.URL, data string) {
+	wc, err := v.OpenWriter(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenWriter: %v", err)
+	}
+	wc.Write(context.Background(), []byte(data))
+	wc.Close(context.Background())
+}
+
+func readFile(t *testing.T, v *VirtualFileSystem, u *url.URL) string {
+	rc, err := v.OpenReader(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from OpenReader: %v", err)
+	}
+	data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc))
+	return string(data)
+}
+
+func TestWriteAndRead(t *testing.T) {
+	v := NewVirtualFileSystem()
+	u := mustParse(t, "memory:///dir/file.txt")
+
+	writeFile(t, v, u, "hello")
+	if data := readFile(t, v, u); data != "hello" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+
+	wc, _ := v.OpenAppender(context.Background(), u)
+	wc.Write(context.Background(), []byte(" world"))
+	wc.Close(context.Background())
+	if data := readFile(t, v, u); data != "hello world" {
+		t.Errorf("Unexpected contents after append %q", data)
+	}
+}
+
+func TestReaderSnapshot(t *testing.T) {
+	v := NewVirtualFileSystem()
+	u := mustParse(t, "memory:///file.txt")
+
+	writeFile(t, v, u, "old")
+	rc, _ := v.OpenReader(context.Background(), u)
+	writeFile(t, v, u, "new")
+
+	data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc))
+	if string(data) != "old" {
+		t.Errorf("Reader saw modified contents %q", string(data))
+	}
+}
+
+func TestListEntries(t *testing.T) {
+	v := NewVirtualFileSystem()
+	writeFile(t, v, mustParse(t, "memory:///dir/b"), "")
+	writeFile(t, v, mustParse(t, "memory:///dir/a"), "")
+	writeFile(t, v, mustParse(t, "memory:///dir/sub/c"), "")
+	writeFile(t, v, mustParse(t, "memory:///other/d"), "")
+
+	entries, err := v.ListEntries(context.Background(),
+		mustParse(t, "memory:///dir"))
+	if err != nil {
+		t.Fatalf("Error reported from ListEntries: %v", err)
+	}
+	if len(entries) != 3 || entries[0] != "a" || entries[1] != "b" ||
+		entries[2] != "sub" {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+}
+
+func TestStat(t *testing.T) {
+	v := NewVirtualFileSystem()
+	writeFile(t, v, mustParse(t, "memory:///dir/file"), "12345")
+
+	fi, err := v.Stat(context.Background(), mustParse(t, "memory:///dir/file"))
+	if err != nil {
+		t.Fatalf("Error reported from Stat: %v", err)
+	}
+	if fi.Size() != 5 || fi.IsDir() {
+		t.Errorf("Unexpected file info %d, %v", fi.Size(), fi.IsDir())
+	}
+
+	fi, err = v.Stat(context.Background(), mustParse(t, "memory:///dir"))
+	if err != nil {
+		t.Fatalf("Error reported from Stat: %v", err)
+	}
+	if !fi.IsDir() {
+		t.Error("Implied directory not reported as directory")
+	}
+
+	_, err = v.Stat(context.Background(), mustParse(t, "memory:///missing"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error from Stat: %v", err)
+	}
+}
+
+func TestRemoveAndReset(t *testing.T) {
+	v := NewVirtualFileSystem()
+	a := mustParse(t, "memory:///a")
+	b := mustParse(t, "memory:///b")
+	writeFile(t, v, a, "a")
+	writeFile(t, v, b, "b")
+
+	if err := v.Remove(context.Background(), a); err != nil {
+		t.Errorf("Error reported from Remove: %v", err)
+	}
+	if _, err := v.OpenReader(context.Background(), a); !errors.Is(
+		err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error reading removed file: %v", err)
+	}
+
+	v.Reset()
+	if _, err := v.OpenReader(context.Background(), b); !errors.Is(
+		err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error reading file after reset: %v", err)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	v := NewVirtualFileSystem()
+	u := mustParse(t, "memory:///watched")
+	changes := make(chan string, 1)
+
+	cancel, _, err := v.WatchFile(context.Background(), u,
+		func(changed *url.URL, rc filesystem.ReadCloser) {
+			data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc))
+			changes <- string(data)
+		})
+	if err != nil {
+		t.Fatalf("Error reported from WatchFile: %v", err)
+	}
+	defer cancel()
+
+	writeFile(t, v, u, "changed")
+	if data := <-changes; data != "changed" {
+		t.Errorf("Watcher received unexpected contents %q", data)
+	}
+}
+
+func TestListEntriesMissingDirectory(t *testing.T) {
+	v := NewVirtualFileSystem()
+	writeFile(t, v, mustParse(t, "memory:///dir/a"), "")
+
+	_, err := v.ListEntries(context.Background(),
+		mustParse(t, "memory:///missing"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Unexpected error for missing directory: %v", err)
+	}
+}
+
+func TestAppendKeepsSnapshots(t *testing.T) {
+	v := NewVirtualFileSystem()
+	u := mustParse(t, "memory:///file.txt")
+	ctx := context.Background()
+
+	wc, _ := v.OpenWriter(ctx, u)
+	wc.Write(ctx, []byte("first"))
+	rc, _ := v.OpenReader(ctx, u)
+	for i := 0; i < 1000; i++ {
+		wc.Write(ctx, []byte("."))
+	}
+	wc.Close(ctx)
+
+	data, _ := io.ReadAll(filesystem.ToIoReadCloser(rc))
+	if string(data) != "first" {
+		t.Errorf("Reader saw appended contents %q", string(data))
+	}
+	if data := readFile(t, v, u); len(data) != 1005 {
+		t.Errorf("Unexpected length %d after appending", len(data))
+	}
+}
+
+func TestCreateLink(t *testing.T) {
+	v := NewVirtualFileSystem()
+	src := mustParse(t, "memory:///src")
+	dst := mustParse(t, "memory:///dst")
+	writeFile(t, v, src, "original")
+
+	if err := v.CreateLink(context.Background(), src, dst); err != nil {
+		t.Fatalf("Error reported from CreateLink: %v", err)
+	}
+	if data := readFile(t, v, dst); data != "original" {
+		t.Errorf("Unexpected link contents %q", data)
+	}
+
+	wc, _ := v.OpenAppender(context.Background(), dst)
+	wc.Write(context.Background(), []byte(" modified"))
+	wc.Close(context.Background())
+
+	if data := readFile(t, v, src); data != "original" {
+		t.Errorf("Writing the link modified the source: %q", data)
+	}
+
+	wc, _ = v.OpenAppender(context.Background(), src)
+	wc.Write(context.Background(), []byte(" source"))
+	wc.Close(context.Background())
+
+	if data := readFile(t, v, dst); data != "original modified" {
+		t.Errorf("Writing the source modified the link: %q", data)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	v := NewVirtualFileSystem()
+	u := mustParse(t, "memory:///expiring")
+	writeFile(t, v, u, "data")
+
+	if err := v.SetExpiry(context.Background(), u,
+		time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("Error reported from SetExpiry: %v", err)
+	}
+
+	if _, err := v.OpenReader(context.Background(), u); err != filesystem.ErrExpired {
+		t.Errorf("Unexpected error opening expired file: %v", err)
+	}
+}
+
+func TestTags(t *testing.T) {
+	v := NewVirtualFileSystem()
+	u := mustParse(t, "memory:///tagged")
+	writeFile(t, v, u, "data")
+
+	if err := v.Tag(context.Background(), u,
+		map[string]string{"owner": "tenant-a", "tier": "hot"}); err != nil {
+		t.Fatalf("Error reported from Tag: %v", err)
+	}
+	if err := v.Untag(context.Background(), u, []string{"tier"}); err != nil {
+		t.Fatalf("Error reported from Untag: %v", err)
+	}
+
+	tags, err := v.GetTags(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Error reported from GetTags: %v", err)
+	}
+	if len(tags) != 1 || tags["owner"] != "tenant-a" {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+
+	if md, _ := v.GetMetadata(context.Background(), u); len(md) != 0 {
+		t.Errorf("Tags leaked into metadata: %v", md)
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	fstest.TestFileSystemConcurrency(t, func() filesystem.FileSystem {
+		return NewVirtualFileSystem()
+	}, mustParse(t, "virtual:///concurrency"))
+}
@@ -0,0 +1,224 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	iofs "io/fs"
+	"net/url"
+	"sync"
+)
+
+/*
+WalkFunc is the type of the function called by ParallelWalkDir for every
+file and directory visited. If listing a directory fails, the function is
+called for the directory again with info set to nil and the error.
+
+Returning fs.SkipDir for a directory prevents it from being descended into;
+returning it for a file skips the remaining entries of its directory.
+Returning fs.SkipAll stops the walk without an error. Any other error
+aborts the walk and is returned to the caller.
+*/
+type WalkFunc func(fileurl *url.URL, info FileInfo, err error) error
+
+/*
+InfoListingFileSystem is implemented by file systems which return metadata
+along with directory listings, e.g. object stores listing by prefix.
+*/
+type InfoListingFileSystem interface {
+	// Retrieve the metadata of all entries of the directory.
+	ListEntriesWithInfo(context.Context, *url.URL) ([]FileInfo, error)
+}
+
+/*
+ListEntriesWithInfo retrieves the metadata of all entries of the referenced
+directory. File systems which do not implement InfoListingFileSystem are
+listed using ListEntries and BulkStat; entries removed in the meantime are
+omitted.
+*/
+func ListEntriesWithInfo(ctx context.Context, dirurl *url.URL) ([]FileInfo, error) {
+	var fs = GetImplementation(dirurl)
+	var entries []string
+	var urls []*url.URL
+	var infos, found []FileInfo
+	var errs []error
+	var err error
+
+	if fs == nil {
+		return nil, ENOFS
+	}
+
+	if ifs, ok := fs.(InfoListingFileSystem); ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = withDefaultTimeout(ctx)
+		defer cancel()
+
+		return ifs.ListEntriesWithInfo(ctx, dirurl)
+	}
+
+	if entries, err = ListEntries(ctx, dirurl); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		urls = append(urls, childURL(dirurl, entry))
+	}
+
+	if infos, errs, err = BulkStat(ctx, urls); err != nil {
+		return nil, err
+	}
+
+	for i, fi := range infos {
+		if errors.Is(errs[i], iofs.ErrNotExist) {
+			continue
+		}
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		found = append(found, fi)
+	}
+
+	return found, nil
+}
+
+/*
+State of a walk in progress.
+*/
+type parallelWalker struct {
+	fn     WalkFunc
+	sem    chan struct{}
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+
+	lock sync.Mutex
+	err  error
+}
+
+/*
+call invokes the WalkFunc, unless the walk was aborted already.
+*/
+func (w *parallelWalker) call(u *url.URL, info FileInfo, err error) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+	return w.fn(u, info, err)
+}
+
+/*
+fail aborts the walk, recording the first error.
+*/
+func (w *parallelWalker) fail(err error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.err == nil {
+		w.err = err
+		w.cancel()
+	}
+}
+
+/*
+walk lists the directory and visits its entries, starting new goroutines
+for all subdirectories.
+*/
+func (w *parallelWalker) walk(ctx context.Context, dir *url.URL) {
+	var infos []FileInfo
+	var err error
+
+	defer w.wg.Done()
+
+	select {
+	case w.sem <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	infos, err = ListEntriesWithInfo(ctx, dir)
+	<-w.sem
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err != nil {
+		if err = w.call(dir, nil, err); err != nil && err != iofs.SkipDir {
+			w.fail(err)
+		}
+		return
+	}
+
+	for _, info := range infos {
+		var u = childURL(dir, info.Name())
+
+		err = w.call(u, info, nil)
+		if err == iofs.SkipDir {
+			if info.IsDir() {
+				continue
+			}
+			return
+		}
+		if err != nil {
+			w.fail(err)
+			return
+		}
+
+		if info.IsDir() {
+			w.wg.Add(1)
+			go w.walk(ctx, u)
+		}
+	}
+}
+
+/*
+ParallelWalkDir walks the tree rooted at root, calling fn for root and
+every file and directory beneath it. Up to concurrency directories are
+listed at the same time using ListEntriesWithInfo, which speeds up walks on
+remote file systems considerably.
+
+Unlike with a sequential walk, entries are not visited in lexical order:
+the entries of a directory are visited in the order they are listed, but
+different directories are visited in no particular order. Calls of fn are
+serialized, so fn need not be safe for concurrent use.
+
+File systems which do not implement InfoListingFileSystem must support
+Stat.
+*/
+func ParallelWalkDir(ctx context.Context, root *url.URL, concurrency int,
+	fn WalkFunc) error {
+	var walkCtx, cancel = context.WithCancel(ctx)
+	var w = &parallelWalker{fn: fn, cancel: cancel}
+	var info FileInfo
+	var err error
+
+	defer cancel()
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	w.sem = make(chan struct{}, concurrency)
+
+	info, err = Stat(ctx, root)
+	if err = fn(root, info, err); err != nil {
+		if err == iofs.SkipDir || err == iofs.SkipAll {
+			return nil
+		}
+		return err
+	}
+	if info == nil || !info.IsDir() {
+		return nil
+	}
+
+	w.wg.Add(1)
+	go w.walk(walkCtx, root)
+	w.wg.Wait()
+
+	if w.err == iofs.SkipAll {
+		return nil
+	}
+	if w.err != nil {
+		return w.err
+	}
+	return ctx.Err()
+}
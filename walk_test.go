@@ -0,0 +1,47 @@
+package filesystem_test
+
+import (
+	"context"
+	"io/fs"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestParallelWalkDir(t *testing.T) {
+	filesystem.AddImplementation("walk", virtualfs.NewVirtualFileSystem())
+
+	for _, path := range []string{
+		"/root/a", "/root/b/c", "/root/b/d/e", "/root/skip/f", "/root/g/h",
+	} {
+		writeTestFile(t, &url.URL{Scheme: "walk", Path: path}, "x")
+	}
+
+	var visited []string
+	err := filesystem.ParallelWalkDir(context.Background(),
+		&url.URL{Scheme: "walk", Path: "/root"}, 4,
+		func(u *url.URL, info filesystem.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			visited = append(visited, u.Path)
+			if info.IsDir() && info.Name() == "skip" {
+				return fs.SkipDir
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("Error reported from ParallelWalkDir: %v", err)
+	}
+
+	sort.Strings(visited)
+	expected := "/root,/root/a,/root/b,/root/b/c,/root/b/d,/root/b/d/e," +
+		"/root/g,/root/g/h,/root/skip"
+	if strings.Join(visited, ",") != expected {
+		t.Errorf("Unexpected paths visited: %v", visited)
+	}
+}
@@ -0,0 +1,170 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+/*
+WatchOptions controls the behavior of WatchDirectory.
+*/
+type WatchOptions struct {
+	// Watch all subdirectories as well, instead of only the files directly
+	// inside the directory.
+	Recursive bool
+
+	// If set, all changes of a file within this interval after its first
+	// change are reported with a single call to the watcher, receiving
+	// the reader of the last change.
+	CoalesceInterval time.Duration
+}
+
+/*
+DirectoryWatchingFileSystem is implemented by file systems which can watch
+all files in a directory.
+*/
+type DirectoryWatchingFileSystem interface {
+	// Watch for changes of files in the directory, and call the
+	// FileWatchFunc for every changed file. The CoalesceInterval of the
+	// options is always zero; coalescing is done by WatchDirectory.
+	WatchDirectory(context.Context, *url.URL, WatchOptions, FileWatchFunc) (
+		CancelWatchFunc, chan error, error)
+}
+
+/*
+A change waiting to be passed on by a coalescingWatcher.
+*/
+type pendingChange struct {
+	fileurl *url.URL
+	reader  ReadCloser
+}
+
+/*
+Wrapper for a FileWatchFunc which coalesces changes of the same file.
+*/
+type coalescingWatcher struct {
+	watcher  FileWatchFunc
+	interval time.Duration
+	lock     sync.Mutex
+	pending  map[string]*pendingChange
+	timers   map[string]*time.Timer
+	stopped  bool
+}
+
+/*
+watch records a change, superseding any pending change of the same file.
+Changes arriving after stop are discarded.
+*/
+func (c *coalescingWatcher) watch(fileurl *url.URL, rc ReadCloser) {
+	var key = fileurl.String()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.stopped {
+		rc.Close(context.Background())
+		return
+	}
+
+	if p, ok := c.pending[key]; ok {
+		p.reader.Close(context.Background())
+		p.reader = rc
+		return
+	}
+
+	c.pending[key] = &pendingChange{fileurl: fileurl, reader: rc}
+	c.timers[key] = time.AfterFunc(c.interval, func() { c.flush(key) })
+}
+
+/*
+flush passes the pending change of the file on to the watcher.
+*/
+func (c *coalescingWatcher) flush(key string) {
+	var p *pendingChange
+	var ok bool
+
+	c.lock.Lock()
+	if p, ok = c.pending[key]; ok {
+		delete(c.pending, key)
+		delete(c.timers, key)
+	}
+	c.lock.Unlock()
+
+	if ok {
+		c.watcher(p.fileurl, p.reader)
+	}
+}
+
+/*
+stop discards all pending changes and any changes arriving later.
+*/
+func (c *coalescingWatcher) stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.stopped = true
+
+	for key, timer := range c.timers {
+		if timer.Stop() {
+			c.pending[key].reader.Close(context.Background())
+		}
+	}
+	clear(c.pending)
+	clear(c.timers)
+}
+
+/*
+WatchDirectory waits for changes of the files in the directory at the
+specified URL, and invokes the watcher for every changed file like
+WatchFile. If opts.Recursive is set, files in subdirectories are watched as
+well, including subdirectories created later. Removals are not reported.
+
+With opts.CoalesceInterval, rapid successive changes of a file, e.g. from
+an editor saving in multiple steps, result in a single call of the watcher.
+As with WatchFile, the default timeout is not applied to the watch.
+
+File systems which do not implement DirectoryWatchingFileSystem return
+EUNSUPP.
+*/
+func WatchDirectory(ctx context.Context, dirurl *url.URL, opts WatchOptions,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	var fs = GetImplementation(dirurl)
+	var dfs DirectoryWatchingFileSystem
+	var coalescer *coalescingWatcher
+	var cancel CancelWatchFunc
+	var errs chan error
+	var ok bool
+	var err error
+
+	if fs == nil {
+		return nil, nil, ENOFS
+	}
+
+	if dfs, ok = fs.(DirectoryWatchingFileSystem); !ok {
+		return nil, nil, EUNSUPP
+	}
+
+	if opts.CoalesceInterval <= 0 {
+		return dfs.WatchDirectory(ctx, dirurl, opts, watcher)
+	}
+
+	coalescer = &coalescingWatcher{
+		watcher:  watcher,
+		interval: opts.CoalesceInterval,
+		pending:  make(map[string]*pendingChange),
+		timers:   make(map[string]*time.Timer),
+	}
+	opts.CoalesceInterval = 0
+
+	if cancel, errs, err = dfs.WatchDirectory(ctx, dirurl, opts, coalescer.watch); err != nil {
+		return nil, nil, err
+	}
+
+	return func() error {
+		var err = cancel()
+		coalescer.stop()
+		return err
+	}, errs, nil
+}
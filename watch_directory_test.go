@@ -0,0 +1,35 @@
+package filesystem
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCoalescingWatcherDiscardsChangesAfterStop(t *testing.T) {
+	var called bool
+	c := &coalescingWatcher{
+		watcher:  func(*url.URL, ReadCloser) { called = true },
+		interval: time.Millisecond,
+		pending:  make(map[string]*pendingChange),
+		timers:   make(map[string]*time.Timer),
+	}
+	rc := &CloseRecordingReadCloser{closed: make(chan struct{})}
+
+	c.stop()
+	c.watch(&url.URL{Scheme: "mock", Path: "/file"}, rc)
+
+	select {
+	case <-rc.closed:
+	default:
+		t.Error("Reader of change after stop was not closed")
+	}
+	if len(c.pending) != 0 || len(c.timers) != 0 {
+		t.Errorf("Change after stop is pending: %v", c.pending)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if called {
+		t.Error("Watcher called after stop")
+	}
+}
@@ -0,0 +1,92 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+EventKind describes the kind of change reported to a FileWatchFuncV2.
+*/
+type EventKind int
+
+const (
+	// The file was modified.
+	EventModified EventKind = iota
+
+	// The file was created.
+	EventCreated
+
+	// The file was removed. No reader is passed along with this event.
+	EventRemoved
+)
+
+/*
+String returns a human readable name of the event kind.
+*/
+func (k EventKind) String() string {
+	switch k {
+	case EventModified:
+		return "modified"
+	case EventCreated:
+		return "created"
+	case EventRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+/*
+ChangeEvent describes a change of a watched file.
+*/
+type ChangeEvent struct {
+	// What happened to the file.
+	Kind EventKind
+
+	// Reader for the new contents of the file, or nil for EventRemoved.
+	// Like with FileWatchFunc, the reader may be discarded without reading.
+	Reader ReadCloser
+}
+
+/*
+FileWatchFuncV2 is the successor of FileWatchFunc, which additionally
+receives the kind of change which occurred.
+*/
+type FileWatchFuncV2 func(*url.URL, ChangeEvent)
+
+/*
+WatchingFileSystemV2 is implemented by file systems which can tell apart
+the kinds of changes made to watched files.
+*/
+type WatchingFileSystemV2 interface {
+	// Watch for changes in a given file like WatchFile, and call the
+	// FileWatchFuncV2 on every change.
+	WatchFileV2(context.Context, *url.URL, FileWatchFuncV2) (
+		CancelWatchFunc, chan error, error)
+}
+
+/*
+WatchFileV2 waits for changes of the file at the specified URL and invokes
+the watcher with a description of every change. Like WatchFile, it does not
+apply the default timeout to such long lived watches.
+
+For file systems which do not implement WatchingFileSystemV2, WatchFile is
+used and all changes are reported as EventModified.
+*/
+func WatchFileV2(ctx context.Context, fileurl *url.URL, watcher FileWatchFuncV2) (
+	CancelWatchFunc, chan error, error) {
+	var fs = GetImplementation(fileurl)
+
+	if fs == nil {
+		return nil, nil, ENOFS
+	}
+
+	if wfs, ok := fs.(WatchingFileSystemV2); ok {
+		return wfs.WatchFileV2(ctx, fileurl, watcher)
+	}
+
+	return fs.WatchFile(ctx, fileurl, func(u *url.URL, rc ReadCloser) {
+		watcher(u, ChangeEvent{Kind: EventModified, Reader: rc})
+	})
+}
@@ -0,0 +1,142 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+/*
+ErrImmutable is returned by the file system created by NewWORMFileSystem
+when attempting to modify or remove an existing file. It matches
+os.ErrPermission when compared using errors.Is.
+*/
+var ErrImmutable = fmt.Errorf("File is immutable: %w", os.ErrPermission)
+
+/*
+FileSystem wrapper which only permits writing files which do not exist yet.
+*/
+type wormFileSystem struct {
+	inner FileSystem
+}
+
+/*
+NewWORMFileSystem wraps inner into a write-once-read-many file system: new
+files can be created, but existing files can neither be overwritten,
+appended to, moved nor removed. Such attempts fail with ErrImmutable.
+Reading and listing are unrestricted.
+
+The policy is enforced by checking for the existence of files before
+opening them, so concurrent writers may still race to create the same
+file unless inner implements ExclusiveWriterFileSystem and
+OpenWriterExclusive is used. File systems with native WORM support (e.g. S3
+Object Lock) should be configured to enforce it themselves.
+*/
+func NewWORMFileSystem(inner FileSystem) FileSystem {
+	return &wormFileSystem{inner: inner}
+}
+
+/*
+checkNotExists fails with ErrImmutable if the file exists.
+*/
+func (w *wormFileSystem) checkNotExists(ctx context.Context, u *url.URL) error {
+	var found, err = fileExists(ctx, w.inner, u)
+
+	if err != nil {
+		return err
+	}
+	if found {
+		return ErrImmutable
+	}
+	return nil
+}
+
+/*
+OpenReader opens the file for reading.
+*/
+func (w *wormFileSystem) OpenReader(ctx context.Context, u *url.URL) (
+	ReadCloser, error) {
+	return w.inner.OpenReader(ctx, u)
+}
+
+/*
+OpenWriter creates the file for writing, unless it exists already.
+*/
+func (w *wormFileSystem) OpenWriter(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	if err := w.checkNotExists(ctx, u); err != nil {
+		return nil, err
+	}
+	return w.inner.OpenWriter(ctx, u)
+}
+
+/*
+OpenWriterExclusive creates the file for writing, unless it exists already.
+The check is atomic if inner implements ExclusiveWriterFileSystem.
+*/
+func (w *wormFileSystem) OpenWriterExclusive(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	if efs, ok := w.inner.(ExclusiveWriterFileSystem); ok {
+		var wc, err = efs.OpenWriterExclusive(ctx, u)
+
+		if errors.Is(err, ErrAlreadyExists) {
+			return nil, ErrImmutable
+		}
+		return wc, err
+	}
+	return w.OpenWriter(ctx, u)
+}
+
+/*
+OpenAppender creates the file for appending, unless it exists already.
+*/
+func (w *wormFileSystem) OpenAppender(ctx context.Context, u *url.URL) (
+	WriteCloser, error) {
+	if err := w.checkNotExists(ctx, u); err != nil {
+		return nil, err
+	}
+	return w.inner.OpenAppender(ctx, u)
+}
+
+/*
+ListEntries lists the directory.
+*/
+func (w *wormFileSystem) ListEntries(ctx context.Context, u *url.URL) (
+	[]string, error) {
+	return w.inner.ListEntries(ctx, u)
+}
+
+/*
+WatchFile watches the file for changes.
+*/
+func (w *wormFileSystem) WatchFile(ctx context.Context, u *url.URL,
+	watcher FileWatchFunc) (CancelWatchFunc, chan error, error) {
+	return w.inner.WatchFile(ctx, u, watcher)
+}
+
+/*
+Remove fails with ErrImmutable for existing files.
+*/
+func (w *wormFileSystem) Remove(ctx context.Context, u *url.URL) error {
+	if err := w.checkNotExists(ctx, u); err != nil {
+		return err
+	}
+	return w.inner.Remove(ctx, u)
+}
+
+/*
+Move is never permitted, since it would remove the source file.
+*/
+func (w *wormFileSystem) Move(ctx context.Context, src, dst *url.URL) error {
+	return ErrImmutable
+}
+
+/*
+Stat retrieves the metadata of the file from inner.
+*/
+func (w *wormFileSystem) Stat(ctx context.Context, u *url.URL) (
+	FileInfo, error) {
+	return w.inner.Stat(ctx, u)
+}
@@ -0,0 +1,75 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestWORMFileSystem(t *testing.T) {
+	filesystem.AddImplementation("worm", filesystem.NewWORMFileSystem(
+		virtualfs.NewVirtualFileSystem()))
+	u := &url.URL{Scheme: "worm", Path: "/file"}
+	ctx := context.Background()
+
+	writeTestFile(t, u, "first")
+
+	if _, err := filesystem.OpenWriter(ctx, u); !errors.Is(
+		err, filesystem.ErrImmutable) {
+		t.Errorf("Unexpected error overwriting file: %v", err)
+	}
+	if _, err := filesystem.OpenAppender(ctx, u); !errors.Is(
+		err, filesystem.ErrImmutable) {
+		t.Errorf("Unexpected error appending to file: %v", err)
+	}
+	if _, err := filesystem.OpenWriterExclusive(ctx, u); !errors.Is(
+		err, filesystem.ErrImmutable) {
+		t.Errorf("Unexpected error exclusively opening file: %v", err)
+	}
+	if err := filesystem.Remove(ctx, u); !errors.Is(
+		err, filesystem.ErrImmutable) {
+		t.Errorf("Unexpected error removing file: %v", err)
+	}
+	if err := filesystem.Move(ctx, u, &url.URL{Scheme: "worm", Path: "/x"}); !errors.Is(
+		err, filesystem.ErrImmutable) {
+		t.Errorf("Unexpected error moving file: %v", err)
+	}
+
+	if data, err := readTestFile(t, u); err != nil || data != "first" {
+		t.Errorf("Unexpected contents %q (%v)", data, err)
+	}
+}
+
+/*
+wrappingExclusiveFileSystem reports existing files with a wrapped
+ErrAlreadyExists from OpenWriterExclusive.
+*/
+type wrappingExclusiveFileSystem struct {
+	*virtualfs.VirtualFileSystem
+}
+
+func (w wrappingExclusiveFileSystem) OpenWriterExclusive(ctx context.Context,
+	u *url.URL) (filesystem.WriteCloser, error) {
+	if _, err := w.Stat(ctx, u); err == nil {
+		return nil, fmt.Errorf("creating %s: %w", u, filesystem.ErrAlreadyExists)
+	}
+	return w.OpenWriter(ctx, u)
+}
+
+func TestWORMFileSystemWrappedAlreadyExists(t *testing.T) {
+	filesystem.AddImplementation("wormwrapped", filesystem.NewWORMFileSystem(
+		wrappingExclusiveFileSystem{virtualfs.NewVirtualFileSystem()}))
+	u := &url.URL{Scheme: "wormwrapped", Path: "/file"}
+
+	writeTestFile(t, u, "first")
+
+	if _, err := filesystem.OpenWriterExclusive(context.Background(),
+		u); !errors.Is(err, filesystem.ErrImmutable) {
+		t.Errorf("Unexpected error creating existing file: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+)
+
+/*
+ReaderUploadingFileSystem is implemented by file systems which can upload
+the contents of a reader directly, e.g. as the body of a streaming HTTP PUT,
+rather than handing out a WriteCloser to write into.
+*/
+type ReaderUploadingFileSystem interface {
+	// Store everything read from r in the file, then close r. The length
+	// is -1 if unknown.
+	OpenWriterFromReader(context.Context, *url.URL, ReadCloser, int64) error
+}
+
+/*
+OpenWriterFromReader stores the contents of r in the referenced file,
+replacing it, and closes r. contentLength is the number of bytes r will
+produce, or -1 if it is not known in advance; backends may use it to avoid
+chunked transfers.
+
+File systems which do not implement ReaderUploadingFileSystem receive the
+data through OpenWriter instead, copying in the calling goroutine.
+*/
+func OpenWriterFromReader(ctx context.Context, fileurl *url.URL, r ReadCloser,
+	contentLength int64) error {
+	var fs = GetImplementation(fileurl)
+	var wc WriteCloser
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		r.Close(ctx)
+		return ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if ufs, ok := fs.(ReaderUploadingFileSystem); ok {
+		return ufs.OpenWriterFromReader(ctx, fileurl, r, contentLength)
+	}
+
+	defer r.Close(ctx)
+
+	if wc, err = fs.OpenWriter(ctx, fileurl); err != nil {
+		return err
+	}
+
+	if _, err = copyContents(ctx, wc, r); err != nil {
+		wc.Close(ctx)
+		return err
+	}
+
+	return wc.Close(ctx)
+}
@@ -0,0 +1,56 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+type closeTrackingReadCloser struct {
+	filesystem.ReadCloser
+	closed int
+}
+
+func (c *closeTrackingReadCloser) Close(ctx context.Context) error {
+	c.closed++
+	return c.ReadCloser.Close(ctx)
+}
+
+func TestOpenWriterFromReaderFallback(t *testing.T) {
+	filesystem.AddImplementation("writefromreader", virtualfs.NewVirtualFileSystem())
+	src := &url.URL{Scheme: "writefromreader", Path: "/src"}
+	dst := &url.URL{Scheme: "writefromreader", Path: "/dst"}
+
+	writeTestFile(t, src, "hello world")
+	writeTestFile(t, dst, "previous contents")
+
+	rc, err := filesystem.OpenReader(context.Background(), src)
+	if err != nil {
+		t.Fatalf("Error reported from OpenReader: %v", err)
+	}
+	r := &closeTrackingReadCloser{ReadCloser: rc}
+
+	if err = filesystem.OpenWriterFromReader(context.Background(), dst, r,
+		-1); err != nil {
+		t.Fatalf("Error reported from OpenWriterFromReader: %v", err)
+	}
+	if data, _ := readTestFile(t, dst); data != "hello world" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+	if r.closed != 1 {
+		t.Errorf("Reader closed %d times, expected once", r.closed)
+	}
+
+	r = &closeTrackingReadCloser{ReadCloser: rc}
+	if err = filesystem.OpenWriterFromReader(context.Background(),
+		&url.URL{Scheme: "writefromreaderunregistered", Path: "/dst"}, r,
+		-1); err != filesystem.ENOFS {
+		t.Errorf("Unexpected error for unregistered scheme: %v", err)
+	}
+	if r.closed != 1 {
+		t.Errorf("Reader closed %d times without implementation", r.closed)
+	}
+}
@@ -0,0 +1,127 @@
+package filesystem
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"hash"
+	iofs "io/fs"
+	"net/url"
+	"path"
+	"strings"
+)
+
+/*
+HashingWriteCloser is a WriteCloser which computes the hash of all data
+written to it.
+*/
+type HashingWriteCloser interface {
+	WriteCloser
+
+	// Hex encoded DefaultChecksumAlgorithm hash of the data written. Only
+	// valid after Close succeeded.
+	Hash() string
+}
+
+/*
+WriteCloser which writes to a temporary file next to the destination and
+moves it into place on Close.
+*/
+type changedWriteCloser struct {
+	w        WriteCloser
+	tmp, dst *url.URL
+	h        hash.Hash
+	sum      string
+}
+
+/*
+Write writes p to the temporary file and adds it to the hash.
+*/
+func (c *changedWriteCloser) Write(ctx context.Context, p []byte) (int, error) {
+	var n, err = c.w.Write(ctx, p)
+
+	c.h.Write(p[:n])
+	return n, err
+}
+
+/*
+Close closes the temporary file and moves it to the destination. If
+anything fails, the temporary file is removed.
+*/
+func (c *changedWriteCloser) Close(ctx context.Context) error {
+	var err error
+
+	if err = c.w.Close(ctx); err == nil {
+		err = Move(ctx, c.tmp, c.dst)
+	}
+	if err != nil {
+		Remove(ctx, c.tmp)
+		return err
+	}
+
+	c.sum = hex.EncodeToString(c.h.Sum(nil))
+	return nil
+}
+
+/*
+Hash returns the hash of the data written. See HashingWriteCloser.
+*/
+func (c *changedWriteCloser) Hash() string {
+	return c.sum
+}
+
+/*
+OpenWriterIfChanged opens the referenced file for replacing its contents,
+unless its current DefaultChecksumAlgorithm hash, as returned by
+ChecksumFile and hex encoded, equals expectedHash. In that case, no write is
+necessary and nil, false, nil is returned. This avoids needless writes and
+the notifications they trigger, e.g. in configuration management.
+
+Data is written to a temporary file next to the destination, which is
+moved into place on Close. On file systems with a server side Move, other
+readers thus never observe partially written contents.
+*/
+func OpenWriterIfChanged(ctx context.Context, fileurl *url.URL,
+	expectedHash string) (HashingWriteCloser, bool, error) {
+	var fs = GetImplementation(fileurl)
+	var id = make([]byte, 8)
+	var tmp = *fileurl
+	var h hash.Hash
+	var w WriteCloser
+	var sum []byte
+	var cancel context.CancelFunc
+	var err error
+
+	if fs == nil {
+		return nil, false, ENOFS
+	}
+
+	ctx, cancel = withDefaultTimeout(ctx)
+	defer cancel()
+
+	if expectedHash != "" {
+		sum, err = ChecksumFile(ctx, fileurl, DefaultChecksumAlgorithm)
+		if err == nil && strings.EqualFold(hex.EncodeToString(sum), expectedHash) {
+			return nil, false, nil
+		}
+		if err != nil && !errors.Is(err, iofs.ErrNotExist) {
+			return nil, false, err
+		}
+	}
+
+	if h, err = newHash(DefaultChecksumAlgorithm); err != nil {
+		return nil, false, err
+	}
+	if _, err = rand.Read(id); err != nil {
+		return nil, false, err
+	}
+	tmp.Path = path.Join(path.Dir(fileurl.Path),
+		"."+path.Base(fileurl.Path)+".tmp-"+hex.EncodeToString(id))
+
+	if w, err = fs.OpenWriter(ctx, &tmp); err != nil {
+		return nil, false, err
+	}
+
+	return &changedWriteCloser{w: w, tmp: &tmp, dst: fileurl, h: h}, true, nil
+}
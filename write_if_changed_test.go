@@ -0,0 +1,45 @@
+package filesystem_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"testing"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/filesystem/virtualfs"
+)
+
+func TestOpenWriterIfChanged(t *testing.T) {
+	filesystem.AddImplementation("ifchanged", virtualfs.NewVirtualFileSystem())
+	u := &url.URL{Scheme: "ifchanged", Path: "/config"}
+	ctx := context.Background()
+	sum := sha256.Sum256([]byte("setting=1"))
+	hash := hex.EncodeToString(sum[:])
+
+	wc, changed, err := filesystem.OpenWriterIfChanged(ctx, u, hash)
+	if err != nil || !changed {
+		t.Fatalf("Unexpected result for missing file: %v, %v", changed, err)
+	}
+	wc.Write(ctx, []byte("setting=1"))
+	if err = wc.Close(ctx); err != nil {
+		t.Fatalf("Error reported from Close: %v", err)
+	}
+	if wc.Hash() != hash {
+		t.Errorf("Unexpected hash %s", wc.Hash())
+	}
+	if data, _ := readTestFile(t, u); data != "setting=1" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+
+	wc, changed, err = filesystem.OpenWriterIfChanged(ctx, u, hash)
+	if err != nil || changed || wc != nil {
+		t.Errorf("Unexpected result for unchanged file: %v, %v, %v", wc, changed, err)
+	}
+
+	entries, _ := filesystem.ListEntries(ctx, &url.URL{Scheme: "ifchanged", Path: "/"})
+	if len(entries) != 1 {
+		t.Errorf("Temporary files left behind: %v", entries)
+	}
+}